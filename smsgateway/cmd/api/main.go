@@ -4,14 +4,19 @@ import (
 	"context"
 
 	"github.com/Behyna/common/pkg/httpclient"
+	"github.com/Behyna/common/pkg/mq"
 	"github.com/Behyna/common/pkg/mysql"
 	"github.com/Behyna/sms-services/smsgateway/internal/api"
 	v1 "github.com/Behyna/sms-services/smsgateway/internal/api/v1"
+	v2 "github.com/Behyna/sms-services/smsgateway/internal/api/validator"
 	"github.com/Behyna/sms-services/smsgateway/internal/config"
 	"github.com/Behyna/sms-services/smsgateway/internal/error"
 	"github.com/Behyna/sms-services/smsgateway/internal/repository"
 	"github.com/Behyna/sms-services/smsgateway/internal/service"
 	"github.com/Behyna/sms-services/smsgateway/pkg/paymentgateway"
+	"github.com/Behyna/sms-services/smsgateway/pkg/ratelimit"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
@@ -25,16 +30,34 @@ func main() {
 			zap.NewProduction,
 			NewFiberApp,
 			NewConnectionDB,
+			NewValidator,
+			v2.NewXValidator,
 
 			repository.NewMessageRepository,
 			repository.NewTxLogRepository,
 			repository.NewTransactionManager,
+			repository.NewWebhookRepository,
+			repository.NewWebhookEventRepository,
+			repository.NewAccountRepository,
+			repository.NewTemplateRepository,
+			repository.NewSuppressionRepository,
+			repository.NewInboundMessageRepository,
 			NewPaymentGateway,
 			service.NewPaymentService,
 			service.NewMessageService,
+			service.NewWebhookService,
+			service.NewDeliveryReportService,
+			service.NewAccountService,
+			NewRateLimiter,
+			service.NewRateLimitService,
+			service.NewTemplateService,
+			service.NewSuppressionService,
+			NewInboundParsers,
+			service.NewInboundService,
 
 			v1.NewHandler,
 		),
+		fx.Decorate(NewWakingMessageService),
 		fx.Invoke(startServer),
 	).Run()
 }
@@ -70,6 +93,60 @@ func NewPaymentGateway(cfg *config.Config) paymentgateway.PaymentGateway {
 	return paymentgateway.NewPaymentGateway(cfg.PaymentGateway, client)
 }
 
+func NewRateLimiter(cfg *config.Config, db *gorm.DB) ratelimit.Limiter {
+	if cfg.RateLimit.Backend == ratelimit.BackendMySQL {
+		return ratelimit.NewMySQL(db)
+	}
+	return ratelimit.NewMemory()
+}
+
+func NewInboundParsers(cfg *config.Config) (smsprovider.InboundParsers, error) {
+	return smsprovider.NewInboundParsers(cfg.Inbound.Providers)
+}
+
+// NewWakingMessageService nudges the send publisher over RabbitMQ whenever a message is created, when outbox wakes
+// are enabled.
+func NewWakingMessageService(messages service.MessageService, cfg *config.Config, logger *zap.Logger,
+	lc fx.Lifecycle) (service.MessageService, error) {
+	if !cfg.Outbox.Wake {
+		return messages, nil
+	}
+
+	if err := service.DeclareOutboxWakeQueue(cfg.RabbitMQ.URL); err != nil {
+		return nil, err
+	}
+
+	rabbit, err := mq.NewConnection(cfg.RabbitMQ, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	publisher, err := rabbit.CreatePublisher()
+	if err != nil {
+		_ = rabbit.Close()
+		return nil, err
+	}
+
+	waker := service.NewOutboxWaker(publisher, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go waker.Run(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return rabbit.Close()
+		},
+	})
+
+	return service.NewWakingMessageService(messages, waker), nil
+}
+
+func NewValidator() *validator.Validate {
+	return validator.New()
+}
+
 func NewFiberApp() *fiber.App {
 	return fiber.New(fiber.Config{
 		ErrorHandler: middleware.ErrorHandler(),
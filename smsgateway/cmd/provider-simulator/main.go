@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Behyna/common/pkg/httpclient"
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/pkg/simulator"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// sendRequest is the default provider contract: from, to and text as JSON, a form or query parameters.
+type sendRequest struct {
+	From string `json:"from" form:"from" query:"from"`
+	To   string `json:"to" form:"to" query:"to"`
+	Text string `json:"text" form:"text" query:"text"`
+}
+
+func main() {
+	fx.New(
+		fx.Provide(
+			config.Load,
+			zap.NewProduction,
+			NewSimulator,
+			NewReceiptClient,
+		),
+		fx.Invoke(runSimulator),
+	).Run()
+}
+
+func NewSimulator(cfg *config.Config) *simulator.Simulator {
+	return simulator.New(cfg.Simulator)
+}
+
+func NewReceiptClient(cfg *config.Config) httpclient.HTTPClient {
+	return httpclient.NewHTTPClient(cfg.Simulator.Receipts.Timeout)
+}
+
+func runSimulator(cfg *config.Config, sim *simulator.Simulator, receipts httpclient.HTTPClient, logger *zap.Logger,
+	lc fx.Lifecycle,
+) {
+	appCtx, cancel := context.WithCancel(context.Background())
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	handler := &simulatorHandler{sim: sim, receipts: receipts, receiptURL: cfg.Simulator.Receipts.URL,
+		receiptAuth: cfg.Simulator.Receipts.Auth, logger: logger, ctx: appCtx}
+	app.Post("/send", handler.Send)
+	app.Get("/send", handler.Send)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				logger.Info("Starting provider simulator", zap.String("port", cfg.Simulator.Port))
+				if err := app.Listen(cfg.Simulator.Port); err != nil {
+					logger.Error("Provider simulator stopped", zap.Error(err))
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			return app.ShutdownWithContext(ctx)
+		},
+	})
+}
+
+type simulatorHandler struct {
+	sim         *simulator.Simulator
+	receipts    httpclient.HTTPClient
+	receiptURL  string
+	receiptAuth smsprovider.CallbackAuth
+	logger      *zap.Logger
+	ctx         context.Context
+}
+
+func (h *simulatorHandler) Send(c *fiber.Ctx) error {
+	var request sendRequest
+	if c.Method() == fiber.MethodGet {
+		if err := c.QueryParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"code": "invalid_request"})
+		}
+	} else if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"code": "invalid_request"})
+	}
+
+	if request.To == "" || request.Text == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"code": "invalid_request"})
+	}
+
+	outcome := h.sim.Outcome(request.To)
+	time.Sleep(h.sim.Latency())
+
+	switch outcome {
+	case simulator.OutcomeInvalidNumber:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"code": "invalid_number"})
+	case simulator.OutcomeServerError:
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"code": "server_error"})
+	case simulator.OutcomeTimeout:
+		time.Sleep(h.sim.TimeoutDelay())
+		return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{"code": "timeout"})
+	}
+
+	messageID := h.sim.NextMessageID()
+	if h.receiptURL != "" {
+		go h.sendReceipt(messageID, outcome)
+	}
+
+	h.logger.Debug("Message accepted", zap.String("messageID", messageID), zap.String("to", request.To),
+		zap.String("outcome", string(outcome)))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message_id": messageID, "status": "accepted"})
+}
+
+func (h *simulatorHandler) sendReceipt(messageID string, outcome simulator.Outcome) {
+	select {
+	case <-time.After(h.sim.ReceiptDelay()):
+	case <-h.ctx.Done():
+		return
+	}
+
+	receipt, ok := h.sim.Receipt(messageID, outcome, time.Now())
+	if !ok {
+		return
+	}
+
+	body, err := json.Marshal(receipt)
+	if err != nil {
+		return
+	}
+
+	headers := map[string]string{"Content-Type": "application/json",
+		h.receiptAuth.HeaderName(): h.receiptAuth.Sign(body)}
+	resp, err := h.receipts.Post(h.ctx, h.receiptURL, bytes.NewReader(body), headers)
+	if err != nil {
+		h.logger.Warn("Failed to send delivery receipt", zap.String("messageID", messageID), zap.Error(err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		h.logger.Warn("Delivery receipt rejected", zap.String("messageID", messageID),
+			zap.Int("statusCode", resp.StatusCode))
+	}
+}
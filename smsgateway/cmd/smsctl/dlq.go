@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/mqretry"
+)
+
+type parkedCount struct {
+	Queue  string `json:"queue"`
+	Parked int    `json:"parked"`
+}
+
+type sweepResult struct {
+	Queue    string `json:"queue"`
+	Replayed int    `json:"replayed,omitempty"`
+	Purged   int    `json:"purged,omitempty"`
+}
+
+// dlqFilter selects parked messages by a case-insensitive substring of their last error and by message id.
+type dlqFilter struct {
+	reason     string
+	messageIDs []int64
+}
+
+func (f dlqFilter) empty() bool {
+	return f.reason == "" && len(f.messageIDs) == 0
+}
+
+func (f dlqFilter) match(letter mqretry.DeadLetter) bool {
+	if f.reason != "" && !strings.Contains(strings.ToLower(letter.Error), strings.ToLower(f.reason)) {
+		return false
+	}
+
+	if len(f.messageIDs) > 0 {
+		var body struct {
+			MessageID int64 `json:"message_id"`
+		}
+		if err := json.Unmarshal([]byte(letter.Body), &body); err != nil ||
+			!slices.Contains(f.messageIDs, body.MessageID) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func listDeadLetters(_ context.Context, d *deps, args []string) (any, error) {
+	fs := flag.NewFlagSet("dlq list", flag.ExitOnError)
+	queue := fs.String("queue", "", "source queue, all of them when omitted")
+	_ = fs.Parse(args)
+
+	queues := dlqQueues()
+	if *queue != "" {
+		if err := checkQueue(*queue); err != nil {
+			return nil, err
+		}
+		queues = []string{*queue}
+	}
+
+	broker, err := mqretry.Dial(d.cfg.RabbitMQ.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer broker.Close()
+
+	counts := make([]parkedCount, 0, len(queues))
+	for _, q := range queues {
+		parked, err := broker.Count(q)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", q, err)
+		}
+		counts = append(counts, parkedCount{Queue: q, Parked: parked})
+	}
+
+	return counts, nil
+}
+
+func peekDeadLetters(ctx context.Context, d *deps, args []string) (any, error) {
+	fs := flag.NewFlagSet("dlq peek", flag.ExitOnError)
+	queue, filter := dlqFlags(fs)
+	limit := fs.Int("limit", 20, "most messages to show")
+	_ = fs.Parse(args)
+
+	f, err := filter()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkQueue(*queue); err != nil {
+		return nil, err
+	}
+
+	broker, err := mqretry.Dial(d.cfg.RabbitMQ.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer broker.Close()
+
+	letters := make([]mqretry.DeadLetter, 0)
+	_, err = broker.Sweep(ctx, *queue, func(letter mqretry.DeadLetter) mqretry.Action {
+		if len(letters) < *limit && f.match(letter) {
+			letters = append(letters, letter)
+		}
+		return mqretry.Keep
+	})
+
+	return letters, err
+}
+
+func replayDeadLetters(ctx context.Context, d *deps, args []string) (any, error) {
+	fs := flag.NewFlagSet("dlq replay", flag.ExitOnError)
+	queue, filter := dlqFlags(fs)
+	all := fs.Bool("all", false, "replay every parked message when no filter is given")
+	_ = fs.Parse(args)
+
+	f, err := filter()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkQueue(*queue); err != nil {
+		return nil, err
+	}
+	if f.empty() && !*all {
+		return nil, fmt.Errorf("-reason, -message or -all is required")
+	}
+
+	broker, err := mqretry.Dial(d.cfg.RabbitMQ.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer broker.Close()
+
+	replayed, err := broker.Sweep(ctx, *queue, func(letter mqretry.DeadLetter) mqretry.Action {
+		if f.match(letter) {
+			return mqretry.Replay
+		}
+		return mqretry.Keep
+	})
+
+	return sweepResult{Queue: *queue, Replayed: replayed}, err
+}
+
+func purgeDeadLetters(ctx context.Context, d *deps, args []string) (any, error) {
+	fs := flag.NewFlagSet("dlq purge", flag.ExitOnError)
+	queue, filter := dlqFlags(fs)
+	all := fs.Bool("all", false, "purge every parked message when no filter is given")
+	_ = fs.Parse(args)
+
+	f, err := filter()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkQueue(*queue); err != nil {
+		return nil, err
+	}
+	if f.empty() && !*all {
+		return nil, fmt.Errorf("-reason, -message or -all is required")
+	}
+
+	broker, err := mqretry.Dial(d.cfg.RabbitMQ.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer broker.Close()
+
+	var purged int
+	if f.empty() {
+		purged, err = broker.Purge(*queue)
+	} else {
+		purged, err = broker.Sweep(ctx, *queue, func(letter mqretry.DeadLetter) mqretry.Action {
+			if f.match(letter) {
+				return mqretry.Remove
+			}
+			return mqretry.Keep
+		})
+	}
+
+	return sweepResult{Queue: *queue, Purged: purged}, err
+}
+
+// dlqFlags registers the flags shared by the commands that act on one queue's parked messages.
+func dlqFlags(fs *flag.FlagSet) (*string, func() (dlqFilter, error)) {
+	queue := fs.String("queue", "", "source queue whose parked messages to act on")
+	reason := fs.String("reason", "", "only messages whose last error contains this text")
+	messages := fs.String("message", "", "only these comma separated message ids")
+
+	return queue, func() (dlqFilter, error) {
+		f := dlqFilter{reason: *reason}
+		if *messages == "" {
+			return f, nil
+		}
+
+		for _, raw := range strings.Split(*messages, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+			if err != nil || id <= 0 {
+				return f, fmt.Errorf("invalid message id %q", raw)
+			}
+			f.messageIDs = append(f.messageIDs, id)
+		}
+
+		return f, nil
+	}
+}
+
+// dlqQueues lists the queues that park messages they cannot process.
+func dlqQueues() []string {
+	return append(service.SendQueues(), service.RefundQueue)
+}
+
+func checkQueue(queue string) error {
+	if queue == "" {
+		return fmt.Errorf("-queue is required")
+	}
+	if !slices.Contains(dlqQueues(), queue) {
+		return fmt.Errorf("unknown queue %q, expected one of %s", queue, strings.Join(dlqQueues(), ", "))
+	}
+	return nil
+}
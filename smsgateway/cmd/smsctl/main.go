@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Behyna/common/pkg/mysql"
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"go.uber.org/zap"
+)
+
+const usage = `usage: smsctl <command> [flags]
+
+commands:
+  account create -name NAME -senders MSISDN[,MSISDN...] [-otp]
+  key issue -account ID -name NAME
+  key revoke -id ID
+  suppression add -msisdn MSISDN -reason REASON [-account ID] [-by NAME]
+  suppression remove -msisdn MSISDN [-account ID]
+  suppression get -msisdn MSISDN [-account ID]
+  suppression import -file PATH -reason REASON [-account ID] [-by NAME]
+  dlq list [-queue QUEUE]
+  dlq peek -queue QUEUE [-reason TEXT] [-message ID[,ID...]] [-limit N]
+  dlq replay -queue QUEUE [-reason TEXT] [-message ID[,ID...]] [-all]
+  dlq purge -queue QUEUE [-reason TEXT] [-message ID[,ID...]] [-all]
+
+suppression commands act on the global list unless -account is given. Import files hold one
+MSISDN per line, optionally followed by ",REASON" to override -reason for that line.
+
+dlq commands act on the parking lot of a source queue (sms.send, sms.send.otp, sms.send.bulk or
+sms.refund). -reason matches the last error case-insensitively; replay and purge need a filter or
+-all. Replayed messages start their retry schedule again; messages already handled are skipped.
+`
+
+type command func(ctx context.Context, deps *deps, args []string) (any, error)
+
+var commands = map[string]command{
+	"account create": createAccount,
+	"key issue":      issueKey,
+	"key revoke":     revokeKey,
+
+	"suppression add":    addSuppression,
+	"suppression remove": removeSuppression,
+	"suppression get":    getSuppression,
+	"suppression import": importSuppressions,
+
+	"dlq list":   listDeadLetters,
+	"dlq peek":   peekDeadLetters,
+	"dlq replay": replayDeadLetters,
+	"dlq purge":  purgeDeadLetters,
+}
+
+type deps struct {
+	cfg          *config.Config
+	accounts     service.AccountService
+	suppressions service.SuppressionService
+}
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	run, ok := commands[os.Args[1]+" "+os.Args[2]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	d, err := newDeps(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	result, err := run(ctx, d, os.Args[3:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if result != nil {
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		_ = out.Encode(result)
+	}
+}
+
+func newDeps(ctx context.Context) (*deps, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	logger := zap.NewNop()
+	db, err := mysql.NewConnection(ctx, cfg.Database, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	accountRepo := repository.NewAccountRepository(db)
+	suppressionRepo := repository.NewSuppressionRepository(db)
+	return &deps{
+		cfg:          cfg,
+		accounts:     service.NewAccountService(accountRepo, logger),
+		suppressions: service.NewSuppressionService(suppressionRepo, logger),
+	}, nil
+}
+
+func createAccount(ctx context.Context, d *deps, args []string) (any, error) {
+	fs := flag.NewFlagSet("account create", flag.ExitOnError)
+	name := fs.String("name", "", "account name")
+	senders := fs.String("senders", "", "comma separated sender MSISDNs")
+	otp := fs.Bool("otp", false, "entitle the account to the otp priority lane")
+	_ = fs.Parse(args)
+
+	if *name == "" || *senders == "" {
+		return nil, fmt.Errorf("-name and -senders are required")
+	}
+
+	return d.accounts.CreateAccount(ctx, service.CreateAccountCommand{
+		Name:       *name,
+		Senders:    strings.Split(*senders, ","),
+		OTPEnabled: *otp,
+	})
+}
+
+func issueKey(ctx context.Context, d *deps, args []string) (any, error) {
+	fs := flag.NewFlagSet("key issue", flag.ExitOnError)
+	accountID := fs.Int64("account", 0, "account id")
+	name := fs.String("name", "", "key name")
+	_ = fs.Parse(args)
+
+	if *accountID <= 0 || *name == "" {
+		return nil, fmt.Errorf("-account and -name are required")
+	}
+
+	return d.accounts.IssueAPIKey(ctx, service.IssueAPIKeyCommand{AccountID: *accountID, Name: *name})
+}
+
+func revokeKey(ctx context.Context, d *deps, args []string) (any, error) {
+	fs := flag.NewFlagSet("key revoke", flag.ExitOnError)
+	id := fs.Int64("id", 0, "API key id")
+	_ = fs.Parse(args)
+
+	if *id <= 0 {
+		return nil, fmt.Errorf("-id is required")
+	}
+
+	return nil, d.accounts.RevokeAPIKey(ctx, *id)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"strings"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+)
+
+var msisdnPattern = regexp.MustCompile(`^\+?[0-9]{10,15}$`)
+
+func addSuppression(ctx context.Context, d *deps, args []string) (any, error) {
+	fs := flag.NewFlagSet("suppression add", flag.ExitOnError)
+	msisdn := fs.String("msisdn", "", "number to suppress")
+	reason := fs.String("reason", "", "why the number is suppressed")
+	accountID := fs.Int64("account", 0, "account id, global when omitted")
+	by := fs.String("by", defaultAuthor(), "who is adding the entry")
+	_ = fs.Parse(args)
+
+	if *msisdn == "" || *reason == "" {
+		return nil, fmt.Errorf("-msisdn and -reason are required")
+	}
+
+	return d.suppressions.AddSuppression(ctx, service.AddSuppressionCommand{
+		AccountID: scope(*accountID),
+		MSISDN:    *msisdn,
+		Reason:    *reason,
+		AddedBy:   *by,
+	})
+}
+
+func removeSuppression(ctx context.Context, d *deps, args []string) (any, error) {
+	fs := flag.NewFlagSet("suppression remove", flag.ExitOnError)
+	msisdn := fs.String("msisdn", "", "number to remove")
+	accountID := fs.Int64("account", 0, "account id, global when omitted")
+	_ = fs.Parse(args)
+
+	if *msisdn == "" {
+		return nil, fmt.Errorf("-msisdn is required")
+	}
+
+	return nil, d.suppressions.RemoveSuppression(ctx,
+		service.RemoveSuppressionCommand{AccountID: scope(*accountID), MSISDN: *msisdn})
+}
+
+func getSuppression(ctx context.Context, d *deps, args []string) (any, error) {
+	fs := flag.NewFlagSet("suppression get", flag.ExitOnError)
+	msisdn := fs.String("msisdn", "", "number to look up")
+	accountID := fs.Int64("account", 0, "also include this account's entries")
+	_ = fs.Parse(args)
+
+	if *msisdn == "" {
+		return nil, fmt.Errorf("-msisdn is required")
+	}
+
+	return d.suppressions.GetSuppression(ctx, *accountID, *msisdn)
+}
+
+func importSuppressions(ctx context.Context, d *deps, args []string) (any, error) {
+	fs := flag.NewFlagSet("suppression import", flag.ExitOnError)
+	path := fs.String("file", "", "file with one MSISDN[,REASON] per line")
+	reason := fs.String("reason", "", "reason for lines that do not give one")
+	accountID := fs.Int64("account", 0, "account id, global when omitted")
+	by := fs.String("by", defaultAuthor(), "who is adding the entries")
+	_ = fs.Parse(args)
+
+	if *path == "" || *reason == "" {
+		return nil, fmt.Errorf("-file and -reason are required")
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	cmd := service.ImportSuppressionsCommand{AccountID: scope(*accountID), AddedBy: *by}
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		msisdn, lineReason, _ := strings.Cut(line, ",")
+		entry := service.SuppressionEntry{MSISDN: strings.TrimSpace(msisdn), Reason: strings.TrimSpace(lineReason)}
+		if entry.Reason == "" {
+			entry.Reason = *reason
+		}
+
+		if !msisdnPattern.MatchString(entry.MSISDN) {
+			return nil, fmt.Errorf("line %d: invalid MSISDN %q", lineNo, entry.MSISDN)
+		}
+
+		cmd.Entries = append(cmd.Entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return d.suppressions.ImportSuppressions(ctx, cmd)
+}
+
+// scope turns the -account flag into a suppression scope: zero means the global list.
+func scope(accountID int64) *int64 {
+	if accountID <= 0 {
+		return nil
+	}
+	return &accountID
+}
+
+func defaultAuthor() string {
+	if u, err := user.Current(); err == nil {
+		return "smsctl:" + u.Username
+	}
+	return "smsctl"
+}
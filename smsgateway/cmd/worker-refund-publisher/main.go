@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"time"
 
 	"github.com/Behyna/common/pkg/mq"
 	"github.com/Behyna/common/pkg/mysql"
@@ -26,6 +25,8 @@ func main() {
 			NewMQPublisher,
 
 			repository.NewTxLogRepository,
+			repository.NewMessageRepository,
+			repository.NewTransactionManager,
 			service.NewMessageQueueService,
 
 			publishers.NewRefundPublisher,
@@ -39,29 +40,14 @@ func runRefundPublisher(cfg *config.Config, publisher publishers.RefundPublisher
 	appCtx, cancel := context.WithCancel(context.Background())
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			if err := rabbit.DeclareTopology([]string{"sms.refund"}); err != nil {
+			if err := rabbit.DeclareTopology([]string{service.RefundQueue}); err != nil {
 				logger.Error("declare topology failed", zap.Error(err))
 				return err
 			}
 
-			logger.Info("queue declared", zap.String("queue", "sms.refund"))
+			logger.Info("queue declared", zap.String("queue", service.RefundQueue))
 
-			go func() {
-				ticker := time.NewTicker(30 * time.Second)
-				defer ticker.Stop()
-
-				for {
-					select {
-					case <-ticker.C:
-						if err := publisher.Publish(appCtx); err != nil {
-							logger.Error("failed to publish refund tx", zap.Error(err))
-						}
-					case <-appCtx.Done():
-						logger.Info("publisher context cancelled")
-						return
-					}
-				}
-			}()
+			go publishers.Run(appCtx, publisher, cfg.Outbox, nil, logger)
 
 			logger.Info("refund publisher started")
 			return nil
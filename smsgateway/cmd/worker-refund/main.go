@@ -10,6 +10,7 @@ import (
 	"github.com/Behyna/sms-services/smsgateway/internal/consumers"
 	"github.com/Behyna/sms-services/smsgateway/internal/repository"
 	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/mqretry"
 	"github.com/Behyna/sms-services/smsgateway/pkg/paymentgateway"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
@@ -24,12 +25,17 @@ func main() {
 			NewConnectionDB,
 			NewMQConnection,
 			NewMQConsumer,
+			NewRetryBroker,
+			NewRetrier,
 
 			repository.NewMessageRepository,
 			repository.NewTxLogRepository,
 			repository.NewTransactionManager,
+			repository.NewWebhookRepository,
+			repository.NewWebhookEventRepository,
 			NewPaymentGateway,
 			service.NewPaymentService,
+			service.NewWebhookService,
 			service.NewRefundService,
 
 			consumers.NewRefundConsumer,
@@ -39,16 +45,21 @@ func main() {
 }
 
 func runRefundConsumer(cfg *config.Config, refundConsumer consumers.RefundConsumer, logger *zap.Logger,
-	rabbit *mq.RabbitMQ, lc fx.Lifecycle,
+	rabbit *mq.RabbitMQ, retries *mqretry.Broker, lc fx.Lifecycle,
 ) {
 	appCtx, cancel := context.WithCancel(context.Background())
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			if err := rabbit.DeclareTopology([]string{"sms.refund"}); err != nil {
+			if err := rabbit.DeclareTopology([]string{service.RefundQueue}); err != nil {
 				logger.Error("declare topology failed", zap.Error(err))
 				return err
 			}
-			logger.Info("queue declared", zap.String("queue", "sms.refund"))
+			logger.Info("queue declared", zap.String("queue", service.RefundQueue))
+
+			if err := retries.Declare(service.RefundQueue, cfg.Retry.Refund); err != nil {
+				logger.Error("declare retry topology failed", zap.Error(err))
+				return err
+			}
 
 			go func() {
 				if err := refundConsumer.Consume(appCtx); err != nil {
@@ -62,6 +73,7 @@ func runRefundConsumer(cfg *config.Config, refundConsumer consumers.RefundConsum
 		OnStop: func(ctx context.Context) error {
 			logger.Info("stopping refund consumer")
 			cancel()
+			_ = retries.Close()
 			return rabbit.Close()
 		},
 	})
@@ -84,3 +96,11 @@ func NewMQConnection(cfg *config.Config, logger *zap.Logger) (*mq.RabbitMQ, erro
 func NewMQConsumer(rabbitMQ *mq.RabbitMQ) (mq.Consumer, error) {
 	return rabbitMQ.CreateConsumer()
 }
+
+func NewRetryBroker(cfg *config.Config) (*mqretry.Broker, error) {
+	return mqretry.Dial(cfg.RabbitMQ.URL)
+}
+
+func NewRetrier(broker *mqretry.Broker) mqretry.Retrier {
+	return broker
+}
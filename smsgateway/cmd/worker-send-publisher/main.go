@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"time"
 
 	"github.com/Behyna/common/pkg/mq"
 	"github.com/Behyna/common/pkg/mysql"
@@ -25,6 +24,8 @@ func main() {
 			NewMQPublisher,
 
 			repository.NewTxLogRepository,
+			repository.NewMessageRepository,
+			repository.NewTransactionManager,
 			service.NewMessageQueueService,
 
 			publishers.NewSendPublisher,
@@ -38,28 +39,25 @@ func runSendPublisher(cfg *config.Config, publisher publishers.SendPublisher, lo
 	appCtx, cancel := context.WithCancel(context.Background())
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			if err := rabbit.DeclareTopology([]string{"sms.send"}); err != nil {
+			queues := service.SendQueues()
+			if err := rabbit.DeclareTopology(queues); err != nil {
 				logger.Error("declare topology failed", zap.Error(err))
 				return err
 			}
-			logger.Info("queue declared", zap.String("queue", "sms.send"))
-
-			go func() {
-				ticker := time.NewTicker(30 * time.Second)
-				defer ticker.Stop()
-
-				for {
-					select {
-					case <-ticker.C:
-						if err := publisher.Publish(appCtx); err != nil {
-							logger.Error("failed to publish messages", zap.Error(err))
-						}
-					case <-appCtx.Done():
-						logger.Info("publisher context cancelled")
-						return
-					}
+			if cfg.Outbox.Wake {
+				if err := service.DeclareOutboxWakeQueue(cfg.RabbitMQ.URL); err != nil {
+					logger.Error("declare wake queue failed", zap.Error(err))
+					return err
 				}
-			}()
+			}
+			logger.Info("queues declared", zap.Strings("queues", queues))
+
+			wake, err := consumeWakes(appCtx, cfg, rabbit, logger)
+			if err != nil {
+				return err
+			}
+
+			go publishers.Run(appCtx, publisher, cfg.Outbox, wake, logger)
 
 			logger.Info("send publisher started")
 			return nil
@@ -72,6 +70,37 @@ func runSendPublisher(cfg *config.Config, publisher publishers.SendPublisher, lo
 	})
 }
 
+// consumeWakes relays the API's wake messages to the publisher loop. Wakes that arrive while one is already pending
+// are merged into it, since a single poll picks up everything committed so far.
+func consumeWakes(ctx context.Context, cfg *config.Config, rabbit *mq.RabbitMQ, logger *zap.Logger) (
+	<-chan struct{}, error) {
+	if !cfg.Outbox.Wake {
+		return nil, nil
+	}
+
+	consumer, err := rabbit.CreateConsumer()
+	if err != nil {
+		logger.Error("create wake consumer failed", zap.Error(err))
+		return nil, err
+	}
+
+	wake := make(chan struct{}, 1)
+	go func() {
+		err := consumer.Consume(ctx, 10, service.OutboxWakeQueue, func(ctx context.Context, body []byte) error {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Error("wake consumer stopped", zap.Error(err))
+		}
+	}()
+
+	return wake, nil
+}
+
 func NewConnectionDB(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error) {
 	ctx := context.Background()
 	return mysql.NewConnection(ctx, cfg.Database, logger)
@@ -2,20 +2,33 @@ package main
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Behyna/common/pkg/httpclient"
 	"github.com/Behyna/common/pkg/mq"
 	"github.com/Behyna/common/pkg/mysql"
+	"github.com/Behyna/sms-services/smsgateway/internal/api"
+	v1 "github.com/Behyna/sms-services/smsgateway/internal/api/v1"
 	"github.com/Behyna/sms-services/smsgateway/internal/config"
 	"github.com/Behyna/sms-services/smsgateway/internal/consumers"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
 	"github.com/Behyna/sms-services/smsgateway/internal/repository"
 	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/mqretry"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smpp"
 	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/gofiber/fiber/v2"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+const (
+	// receiptQueueSize bounds the SMPP receipts held in memory; beyond it the SMSC is asked to redeliver them.
+	receiptQueueSize = 1000
+	receiptWorkers   = 4
+)
+
 func main() {
 	fx.New(
 		fx.Provide(
@@ -23,64 +36,208 @@ func main() {
 			zap.NewProduction,
 			NewConnectionDB,
 			NewMQConnection,
-			NewMQConsumer,
+			NewRetryBroker,
 
 			repository.NewMessageRepository,
 			repository.NewTxLogRepository,
 			repository.NewTransactionManager,
-			NewSMSProvider,
+			repository.NewWebhookRepository,
+			repository.NewWebhookEventRepository,
+			repository.NewSuppressionRepository,
+			NewProviderRouter,
 			service.NewProviderService,
+			service.NewWebhookService,
+			service.NewSuppressionService,
+			service.NewDeliveryReportService,
+			NewReceiptQueue,
 			service.NewSendService,
 
-			consumers.NewSendConsumer,
+			v1.NewProviderHandler,
 		),
-		fx.Invoke(runSendConsumer),
+		fx.Invoke(runSendConsumer, startHealthServer),
 	).Run()
 }
 
-func runSendConsumer(cfg *config.Config, sendConsumer consumers.SendConsumer, logger *zap.Logger,
-	rabbit *mq.RabbitMQ, lc fx.Lifecycle,
+// runSendConsumer starts the configured number of consumers on every priority lane. Each lane has its own queue and
+// consumers, so a backlog of bulk messages never delays OTPs.
+func runSendConsumer(cfg *config.Config, sendService service.SendService, logger *zap.Logger,
+	rabbit *mq.RabbitMQ, retries *mqretry.Broker, lc fx.Lifecycle,
 ) {
 	appCtx, cancel := context.WithCancel(context.Background())
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			if err := rabbit.DeclareTopology([]string{"sms.send"}); err != nil {
+			queues := service.SendQueues()
+			if err := rabbit.DeclareTopology(queues); err != nil {
 				logger.Error("declare topology failed", zap.Error(err))
 				return err
 			}
-			logger.Info("queue declared", zap.String("queue", "sms.send"))
+			logger.Info("queues declared", zap.Strings("queues", queues))
 
-			go func() {
-				if err := sendConsumer.Consume(appCtx); err != nil {
-					logger.Error("consumer exited", zap.Error(err))
+			for _, queue := range queues {
+				if err := retries.Declare(queue, cfg.Retry.Send); err != nil {
+					logger.Error("declare retry topology failed", zap.String("queue", queue), zap.Error(err))
+					return err
 				}
-			}()
+			}
+
+			for _, priority := range model.MessagePriorities {
+				queue := service.SendQueue(string(priority))
+				count := cfg.SendWorker.Consumers(string(priority))
+
+				for range count {
+					consumer, err := rabbit.CreateConsumer()
+					if err != nil {
+						logger.Error("create consumer failed", zap.String("queue", queue), zap.Error(err))
+						return err
+					}
+
+					sendConsumer := consumers.NewSendConsumer(sendService, consumer, retries, logger)
+					go func() {
+						if err := sendConsumer.Consume(appCtx, queue); err != nil {
+							logger.Error("consumer exited", zap.String("queue", queue), zap.Error(err))
+						}
+					}()
+				}
+
+				logger.Info("send consumers started", zap.String("queue", queue), zap.Int("consumers", count))
+			}
 
-			logger.Info("send consumer started")
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
 			logger.Info("stopping send consumer")
 			cancel()
+			_ = retries.Close()
 			return rabbit.Close()
 		},
 	})
 }
 
+// startHealthServer serves this worker's provider breaker state. It is optional: without a configured port the
+// worker only reports breaker transitions in its logs.
+func startHealthServer(cfg *config.Config, handler *v1.ProviderHandler, logger *zap.Logger, lc fx.Lifecycle) {
+	if cfg.ProviderHealth.Port == "" {
+		return
+	}
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	api.SetupProviderRoutes(app, handler)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				logger.Info("Starting provider health server", zap.String("port", cfg.ProviderHealth.Port))
+				if err := app.Listen(cfg.ProviderHealth.Port); err != nil {
+					logger.Error("Provider health server stopped", zap.Error(err))
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return app.ShutdownWithContext(ctx)
+		},
+	})
+}
+
 func NewConnectionDB(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error) {
 	ctx := context.Background()
 	return mysql.NewConnection(ctx, cfg.Database, logger)
 }
 
-func NewSMSProvider(cfg *config.Config) smsprovider.Provider {
-	client := httpclient.NewHTTPClient(cfg.Provider.Timeout)
-	return smsprovider.NewSMSProvider(cfg.Provider, client)
+func NewProviderRouter(cfg *config.Config, receipts *service.ReceiptQueue, logger *zap.Logger,
+	lc fx.Lifecycle,
+) (*smsprovider.Router, error) {
+	routes := cfg.ProviderRoutes()
+
+	providers := make(smsprovider.Providers, len(routes))
+	for _, route := range routes {
+		if err := route.Validate(); err != nil {
+			return nil, fmt.Errorf("provider %s: %w", route.Name, err)
+		}
+
+		if route.Type == smsprovider.TypeSMPP {
+			providers[route.Name] = newSMPPProvider(route, receipts, logger, lc)
+			continue
+		}
+
+		client := httpclient.NewHTTPClient(route.Timeout)
+		providers[route.Name] = smsprovider.NewSMSProvider(route.Config, client)
+	}
+
+	return smsprovider.NewRouter(routes, providers)
+}
+
+// newSMPPProvider keeps an SMPP session open for the worker's lifetime. Receipts arrive on that session rather than
+// on the API's delivery report endpoint, so they are applied here.
+func newSMPPProvider(route smsprovider.RouteConfig, receipts *service.ReceiptQueue, logger *zap.Logger,
+	lc fx.Lifecycle,
+) smsprovider.Provider {
+	log := logger.With(zap.String("provider", route.Name))
+
+	client := smpp.NewClient(route.SMPP, smpp.Handlers{
+		OnReceipt: func(receipt smpp.Receipt) bool {
+			cmd := service.DeliveryReportCommand{Provider: route.Name, ProviderMsgID: receipt.MessageID,
+				Status: receipt.Status, ErrorCode: receipt.Error, ReportedAt: receipt.DoneAt}
+			if !receipts.Enqueue(cmd) {
+				log.Warn("SMPP receipt queue full, asking for redelivery", zap.String("providerMsgID", receipt.MessageID))
+				return false
+			}
+			return true
+		},
+		OnBind: func(bound bool, err error) {
+			if bound {
+				log.Info("SMPP session bound")
+				return
+			}
+			log.Warn("SMPP session down", zap.Error(err))
+		},
+	})
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			client.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return client.Close()
+		},
+	})
+
+	return smsprovider.NewSMPPProvider(client)
+}
+
+// NewReceiptQueue applies SMPP receipts for the worker's lifetime.
+func NewReceiptQueue(reports service.DeliveryReportService, logger *zap.Logger, lc fx.Lifecycle,
+) *service.ReceiptQueue {
+	queue := service.NewReceiptQueue(reports, receiptQueueSize, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				queue.Run(ctx, receiptWorkers)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+			case <-stopCtx.Done():
+			}
+			return nil
+		},
+	})
+
+	return queue
 }
 
 func NewMQConnection(cfg *config.Config, logger *zap.Logger) (*mq.RabbitMQ, error) {
 	return mq.NewConnection(cfg.RabbitMQ, logger)
 }
 
-func NewMQConsumer(rabbitMQ *mq.RabbitMQ) (mq.Consumer, error) {
-	return rabbitMQ.CreateConsumer()
+func NewRetryBroker(cfg *config.Config) (*mqretry.Broker, error) {
+	return mqretry.Dial(cfg.RabbitMQ.URL)
 }
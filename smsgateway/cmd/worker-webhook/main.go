@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/Behyna/common/pkg/httpclient"
+	"github.com/Behyna/common/pkg/mysql"
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/webhook"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func main() {
+	fx.New(
+		fx.Provide(
+			config.Load,
+			zap.NewProduction,
+			NewConnectionDB,
+
+			repository.NewWebhookEventRepository,
+			NewWebhookClient,
+			service.NewWebhookDeliveryService,
+		),
+		fx.Invoke(runWebhookWorker),
+	).Run()
+}
+
+func runWebhookWorker(cfg *config.Config, delivery service.WebhookDeliveryService, logger *zap.Logger,
+	lc fx.Lifecycle) {
+	appCtx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				ticker := time.NewTicker(cfg.Webhook.PollInterval)
+				defer ticker.Stop()
+
+				for {
+					select {
+					case <-ticker.C:
+						if err := delivery.DeliverDueEvents(appCtx); err != nil {
+							logger.Error("failed to deliver webhook events", zap.Error(err))
+						}
+					case <-appCtx.Done():
+						logger.Info("webhook worker context cancelled")
+						return
+					}
+				}
+			}()
+
+			logger.Info("webhook worker started")
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("stopping webhook worker")
+			cancel()
+			return nil
+		},
+	})
+}
+
+func NewConnectionDB(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error) {
+	ctx := context.Background()
+	return mysql.NewConnection(ctx, cfg.Database, logger)
+}
+
+func NewWebhookClient(cfg *config.Config) webhook.Client {
+	client := httpclient.NewHTTPClient(cfg.Webhook.Timeout)
+	return webhook.NewClient(client)
+}
@@ -10,6 +10,32 @@ const prefixV1 = "api/v1/"
 func SetupRoutes(app *fiber.App, handler *v1.Handler) {
 
 	app.Get("/ping", handler.Pong)
-	app.Post("/v1/message", handler.CreateMessage)
-	app.Get("/v1/messages", handler.GetMessages)
+	app.Post("/v1/message", handler.Authenticate, handler.CreateMessage)
+	app.Post("/v1/message/batch", handler.Authenticate, handler.CreateMessageBatch)
+	app.Post("/v1/message/template", handler.Authenticate, handler.CreateTemplateMessage)
+	app.Get("/v1/messages", handler.Authenticate, handler.GetMessages)
+	app.Get("/v1/messages/lookup", handler.Authenticate, handler.LookupMessage)
+	app.Get("/v1/messages/:id<int>", handler.Authenticate, handler.GetMessage)
+	app.Post("/v1/messages/:id<int>/cancel", handler.Authenticate, handler.CancelMessage)
+	app.Post("/v1/provider/dlr/:provider", handler.DeliveryReport)
+	app.Post("/v1/provider/inbound/:provider", handler.ReceiveInbound)
+	app.Get("/v1/inbound", handler.Authenticate, handler.ListInbound)
+	app.Put("/v1/webhooks", handler.Authenticate, handler.RegisterWebhook)
+	app.Get("/v1/webhooks", handler.Authenticate, handler.GetWebhook)
+	app.Delete("/v1/webhooks", handler.Authenticate, handler.DeleteWebhook)
+	app.Post("/v1/templates", handler.Authenticate, handler.CreateTemplate)
+	app.Get("/v1/templates", handler.Authenticate, handler.ListTemplates)
+	app.Get("/v1/templates/:id<int>", handler.Authenticate, handler.GetTemplate)
+	app.Put("/v1/templates/:id<int>", handler.Authenticate, handler.UpdateTemplate)
+	app.Delete("/v1/templates/:id<int>", handler.Authenticate, handler.DeleteTemplate)
+	app.Post("/v1/suppressions", handler.Authenticate, handler.AddSuppression)
+	app.Post("/v1/suppressions/import", handler.Authenticate, handler.ImportSuppressions)
+	app.Get("/v1/suppressions", handler.Authenticate, handler.ListSuppressions)
+	app.Get("/v1/suppressions/:msisdn", handler.Authenticate, handler.GetSuppression)
+	app.Delete("/v1/suppressions/:msisdn", handler.Authenticate, handler.RemoveSuppression)
+}
+
+// SetupProviderRoutes registers the status routes the send worker serves on its provider health port.
+func SetupProviderRoutes(app *fiber.App, handler *v1.ProviderHandler) {
+	app.Get("/v1/providers/health", handler.GetProviderHealth)
 }
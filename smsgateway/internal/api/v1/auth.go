@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	apiKeyHeader     = "X-API-Key"
+	accountLocalsKey = "account"
+)
+
+// Authenticate resolves the API key from the X-API-Key header or an "Authorization: Bearer" header and stores the
+// owning account for the handlers that follow it.
+func (h *Handler) Authenticate(c *fiber.Ctx) error {
+	apiKey := c.Get(apiKeyHeader)
+	if apiKey == "" {
+		auth := c.Get(fiber.HeaderAuthorization)
+		if len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+			apiKey = strings.TrimSpace(auth[len("Bearer "):])
+		}
+	}
+
+	account, err := h.accounts.Authenticate(c.UserContext(), apiKey)
+	if err != nil {
+		h.logger.Warn("API key authentication failed", zap.String("path", c.Path()), zap.Error(err))
+		return err
+	}
+
+	c.Locals(accountLocalsKey, account)
+	return c.Next()
+}
+
+func currentAccount(c *fiber.Ctx) service.Account {
+	account, _ := c.Locals(accountLocalsKey).(service.Account)
+	return account
+}
+
+// authorizeSender rejects requests acting on an MSISDN the authenticated account does not own.
+func (h *Handler) authorizeSender(c *fiber.Ctx, msisdn string) error {
+	account := currentAccount(c)
+	if account.OwnsSender(msisdn) {
+		return nil
+	}
+
+	h.logger.Warn("Sender not allowed for account",
+		zap.Int64("accountID", account.ID),
+		zap.String("msisdn", msisdn))
+	return service.NewServiceError(constants.ErrCodeSenderNotAllowed, service.ErrSenderNotAllowed)
+}
+
+// authorizePriority rejects requests for a send lane the authenticated account is not entitled to.
+func (h *Handler) authorizePriority(c *fiber.Ctx, priority string) error {
+	account := currentAccount(c)
+	if account.AllowsPriority(priority) {
+		return nil
+	}
+
+	h.logger.Warn("Priority not allowed for account",
+		zap.Int64("accountID", account.ID),
+		zap.String("priority", priority))
+	return service.NewServiceError(constants.ErrCodePriorityNotAllowed, service.ErrPriorityNotAllowed)
+}
@@ -1,20 +1,41 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/api/validator"
 	"github.com/Behyna/sms-services/smsgateway/internal/constants"
-	"github.com/Behyna/sms-services/smsgateway/internal/model"
 	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smstemplate"
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 )
 
 type Handler struct {
-	logger  *zap.Logger
-	service service.MessageService
+	logger         *zap.Logger
+	service        service.MessageService
+	deliveryReport service.DeliveryReportService
+	webhook        service.WebhookService
+	accounts       service.AccountService
+	rateLimit      service.RateLimitService
+	templates      service.TemplateService
+	suppressions   service.SuppressionService
+	inbound        service.InboundService
+	XValidator     validator.IXValidator
 }
 
-func NewHandler(logger *zap.Logger, service service.MessageService) *Handler {
-	return &Handler{logger: logger, service: service}
+func NewHandler(logger *zap.Logger, service service.MessageService, deliveryReport service.DeliveryReportService,
+	webhook service.WebhookService, accounts service.AccountService, rateLimit service.RateLimitService,
+	templates service.TemplateService, suppressions service.SuppressionService, inbound service.InboundService,
+	XValidator validator.IXValidator) *Handler {
+	return &Handler{logger: logger, service: service, deliveryReport: deliveryReport, webhook: webhook,
+		accounts: accounts, rateLimit: rateLimit, templates: templates, suppressions: suppressions, inbound: inbound,
+		XValidator: XValidator}
 }
 
 func (h *Handler) Pong(c *fiber.Ctx) error {
@@ -22,12 +43,8 @@ func (h *Handler) Pong(c *fiber.Ctx) error {
 }
 
 func (h *Handler) CreateMessage(c *fiber.Ctx) error {
-	ctx := c.UserContext()
-
 	var request SendMessageRequest
 
-	// TODO: add validation to request struct
-
 	if err := c.BodyParser(&request); err != nil {
 		h.logger.Warn("Failed to parse body",
 			zap.Error(err),
@@ -38,33 +55,260 @@ func (h *Handler) CreateMessage(c *fiber.Ctx) error {
 		})
 	}
 
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		h.logger.Warn("Request validation failed",
+			zap.Any("errors", errs),
+			zap.String("messageID", request.MessageID))
+		return h.validationError(c, errs)
+	}
+
 	cmd := service.CreateMessageCommand{
 		ClientMessageID: request.MessageID,
 		FromMSISDN:      request.From,
 		ToMSISDN:        request.To,
 		Text:            request.Text,
+		SendAt:          request.SendAt,
+		ValidityPeriod:  time.Duration(request.ValidityPeriod) * time.Second,
+		Priority:        request.Priority,
+	}
+
+	return h.submitMessage(c, cmd, nil)
+}
+
+func (h *Handler) CreateTemplateMessage(c *fiber.Ctx) error {
+	var request SendTemplateMessageRequest
+
+	if err := c.BodyParser(&request); err != nil {
+		h.logger.Warn("Failed to parse body", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		h.logger.Warn("Request validation failed",
+			zap.Any("errors", errs),
+			zap.String("messageID", request.MessageID))
+		return h.validationError(c, errs)
+	}
+
+	cmd := service.CreateMessageCommand{
+		ClientMessageID: request.MessageID,
+		FromMSISDN:      request.From,
+		ToMSISDN:        request.To,
+		SendAt:          request.SendAt,
+		ValidityPeriod:  time.Duration(request.ValidityPeriod) * time.Second,
+		Priority:        request.Priority,
+	}
+
+	render := &service.RenderTemplateCommand{
+		AccountID:  currentAccount(c).ID,
+		TemplateID: request.TemplateID,
+		Variables:  request.Variables,
+	}
+
+	return h.submitMessage(c, cmd, render)
+}
+
+// submitMessage authorizes a validated send request, renders its template when one is given and creates the message.
+// A resubmission of a stored message is answered before the rate limit, so idempotent retries spend no tokens.
+func (h *Handler) submitMessage(c *fiber.Ctx, cmd service.CreateMessageCommand,
+	render *service.RenderTemplateCommand) error {
+	ctx := c.UserContext()
+
+	if err := h.authorizeSender(c, cmd.FromMSISDN); err != nil {
+		return err
+	}
+
+	if err := h.authorizePriority(c, cmd.Priority); err != nil {
+		return err
+	}
+
+	cmd.AccountID = currentAccount(c).ID
+
+	if render != nil {
+		rendered, err := h.templates.RenderTemplate(ctx, *render)
+		if err != nil {
+			h.logger.Warn("Failed to render template",
+				zap.Error(err),
+				zap.Int64("templateID", render.TemplateID),
+				zap.String("messageID", cmd.ClientMessageID))
+			return h.templateVariablesError(c, err)
+		}
+
+		cmd.Text = rendered.Text
+		cmd.TemplateID = &rendered.TemplateID
+		cmd.TemplateVersion = &rendered.Version
+		cmd.TemplateVariables = render.Variables
+	}
+
+	resp, found, err := h.service.ReplayMessage(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		limitCmd := service.CheckRateLimitCommand{AccountID: cmd.AccountID, FromMSISDN: cmd.FromMSISDN,
+			ToMSISDNs: []string{cmd.ToMSISDN}}
+		if err := h.checkRateLimit(c, limitCmd); err != nil {
+			return err
+		}
+
+		resp, err = h.service.CreateMessage(ctx, cmd)
+		if err != nil {
+			h.logger.Error("Failed to create message transaction",
+				zap.Error(err),
+				zap.String("from", cmd.FromMSISDN),
+				zap.String("to", cmd.ToMSISDN),
+				zap.String("messageID", cmd.ClientMessageID),
+			)
+
+			return err
+		}
+	}
+
+	h.logger.Info("Message received successfully",
+		zap.String("from", cmd.FromMSISDN),
+		zap.String("to", cmd.ToMSISDN),
+		zap.String("messageID", cmd.ClientMessageID),
+		zap.Bool("duplicate", resp.Duplicate),
+	)
+
+	status := fiber.StatusCreated
+	if resp.Duplicate {
+		status = fiber.StatusOK
+	}
+
+	return c.Status(status).JSON(
+		SendMessageResponse{Status: resp.Status, MessageID: resp.MessageID, Duplicate: resp.Duplicate})
+}
+
+func (h *Handler) CreateMessageBatch(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var request SendBatchRequest
+
+	if err := c.BodyParser(&request); err != nil {
+		h.logger.Warn("Failed to parse batch body", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		h.logger.Warn("Batch validation failed",
+			zap.Any("errors", errs),
+			zap.String("batchID", request.BatchID))
+		return h.validationError(c, errs)
+	}
+
+	if err := h.authorizeSender(c, request.From); err != nil {
+		return err
 	}
 
-	resp, err := h.service.CreateMessage(ctx, cmd)
+	if err := h.authorizePriority(c, request.Priority); err != nil {
+		return err
+	}
+
+	results := make([]BatchItemResponse, len(request.Messages))
+	items := make([]service.BatchItem, 0, len(request.Messages))
+	for i, msg := range request.Messages {
+		item := SendMessageRequest{From: request.From, To: msg.To, Text: msg.Text, MessageID: msg.MessageID}
+		if item.Text == "" {
+			item.Text = request.Text
+		}
+
+		if item.MessageID == "" {
+			item.MessageID = fmt.Sprintf("%s-%d", request.BatchID, i)
+		}
+
+		results[i] = BatchItemResponse{Index: i, ClientMessageID: item.MessageID}
+
+		if errs := h.XValidator.Validate(item); len(errs) > 0 {
+			results[i].Status = service.BatchItemStatusRejected
+			results[i].Errors = toFieldErrors(errs)
+			continue
+		}
+
+		items = append(items, service.BatchItem{
+			Index:           i,
+			ClientMessageID: item.MessageID,
+			ToMSISDN:        item.To,
+			Text:            item.Text,
+		})
+	}
+
+	limitCmd := service.CheckRateLimitCommand{AccountID: currentAccount(c).ID, FromMSISDN: request.From,
+		ToMSISDNs: make([]string, 0, len(items))}
+	for _, item := range items {
+		limitCmd.ToMSISDNs = append(limitCmd.ToMSISDNs, item.ToMSISDN)
+	}
+	if err := h.checkRateLimit(c, limitCmd); err != nil {
+		return err
+	}
+
+	cmd := service.CreateMessageBatchCommand{
+		AccountID:  currentAccount(c).ID,
+		BatchID:    request.BatchID,
+		FromMSISDN: request.From,
+		Priority:   request.Priority,
+		Items:      items,
+	}
+
+	resp, err := h.service.CreateMessageBatch(ctx, cmd)
 	if err != nil {
-		h.logger.Error("Failed to create message transaction",
+		h.logger.Error("Failed to create message batch",
 			zap.Error(err),
 			zap.String("from", request.From),
-			zap.String("to", request.To),
-			zap.String("messageID", request.MessageID),
-		)
-
+			zap.String("batchID", request.BatchID))
 		return err
 	}
 
-	h.logger.Info("Message received successfully",
+	for _, result := range resp.Results {
+		results[result.Index] = BatchItemResponse{
+			Index:           result.Index,
+			ClientMessageID: result.ClientMessageID,
+			MessageID:       result.MessageID,
+			Status:          result.Status,
+			Segments:        result.Segments,
+		}
+
+		if result.ErrorCode != "" {
+			results[result.Index].Errors = []FieldError{{
+				Field:   "to",
+				Code:    result.ErrorCode,
+				Message: constants.GetErrorMessage(result.ErrorCode),
+			}}
+		}
+	}
+
+	response := SendBatchResponse{BatchID: request.BatchID, Amount: resp.Amount, Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case service.BatchItemStatusCreated:
+			response.Accepted++
+		case service.BatchItemStatusDuplicate:
+			response.Duplicates++
+		case service.BatchItemStatusRejected:
+			response.Rejected++
+		}
+	}
+
+	h.logger.Info("Message batch received successfully",
 		zap.String("from", request.From),
-		zap.String("to", request.To),
-		zap.String("messageID", request.MessageID),
-	)
+		zap.String("batchID", request.BatchID),
+		zap.Int("accepted", response.Accepted),
+		zap.Int("duplicates", response.Duplicates),
+		zap.Int("rejected", response.Rejected))
+
+	status := fiber.StatusOK
+	if response.Accepted > 0 {
+		status = fiber.StatusCreated
+	}
 
-	return c.Status(fiber.StatusCreated).JSON(
-		SendMessageResponse{Status: string(model.MessageStatusCreated), MessageID: resp.MessageID})
+	return c.Status(status).JSON(response)
 }
 
 func (h *Handler) GetMessages(c *fiber.Ctx) error {
@@ -81,14 +325,28 @@ func (h *Handler) GetMessages(c *fiber.Ctx) error {
 		})
 	}
 
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	if err := h.authorizeSender(c, request.UserID); err != nil {
+		return err
+	}
+
 	if request.Limit == 0 {
 		request.Limit = 20
 	}
 
 	query := service.GetMessagesQuery{
-		UserID: request.UserID,
-		Limit:  request.Limit,
-		Offset: request.Offset,
+		UserID:                request.UserID,
+		Limit:                 request.Limit,
+		Cursor:                request.Cursor,
+		Status:                request.Status,
+		ToMSISDN:              request.To,
+		CreatedFrom:           parseQueryTime(request.CreatedFrom),
+		CreatedTo:             parseQueryTime(request.CreatedTo),
+		ClientMessageIDPrefix: request.ClientMessageIDPrefix,
+		IncludeTotal:          request.IncludeTotal,
 	}
 
 	response, err := h.service.GetMessagesByUserID(ctx, query)
@@ -101,3 +359,305 @@ func (h *Handler) GetMessages(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(response)
 }
+
+func (h *Handler) GetMessage(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		h.logger.Warn("Invalid message id", zap.String("id", c.Params("id")))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	response, err := h.ownedMessage(c, int64(id))
+	if err != nil {
+		h.logger.Warn("Failed to get message", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (h *Handler) CancelMessage(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		h.logger.Warn("Invalid message id", zap.String("id", c.Params("id")))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if _, err := h.ownedMessage(c, int64(id)); err != nil {
+		h.logger.Warn("Failed to get message for cancellation", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	response, err := h.service.CancelMessage(ctx, int64(id))
+	if err != nil {
+		h.logger.Warn("Failed to cancel message", zap.Error(err), zap.Int("id", id))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (h *Handler) LookupMessage(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var request GetMessageRequest
+
+	if err := c.QueryParser(&request); err != nil {
+		h.logger.Warn("Failed to parse query parameters", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	if err := h.authorizeSender(c, request.From); err != nil {
+		return err
+	}
+
+	query := service.GetMessageQuery{FromMSISDN: request.From, ClientMessageID: request.ClientMessageID}
+
+	response, err := h.service.GetMessageByClientMessageID(ctx, query)
+	if err != nil {
+		h.logger.Warn("Failed to look up message",
+			zap.Error(err),
+			zap.String("from", request.From),
+			zap.String("clientMessageID", request.ClientMessageID))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// DeliveryReport accepts a receipt from the provider named in the path. The provider's credential is checked against
+// the raw body before anything in it is trusted.
+func (h *Handler) DeliveryReport(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	provider := strings.ToLower(c.Params("provider"))
+
+	header := func(name string) string { return c.Get(name) }
+	if err := h.deliveryReport.AuthenticateReport(provider, header, c.Body()); err != nil {
+		return err
+	}
+
+	var request DeliveryReportRequest
+
+	if err := c.BodyParser(&request); err != nil {
+		h.logger.Warn("Failed to parse delivery report",
+			zap.Error(err),
+			zap.String("provider", provider),
+			zap.Int("bodyLength", len(c.Body())))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	cmd := service.DeliveryReportCommand{
+		Provider:      provider,
+		ProviderMsgID: request.ProviderMsgID,
+		Status:        request.Status,
+		ErrorCode:     request.ErrorCode,
+		ReportedAt:    request.ReportedAt,
+	}
+
+	result, err := h.deliveryReport.ProcessDeliveryReport(ctx, cmd)
+	if err != nil {
+		h.logger.Warn("Failed to process delivery report",
+			zap.Error(err),
+			zap.String("providerMsgID", request.ProviderMsgID),
+			zap.String("status", request.Status))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+func (h *Handler) RegisterWebhook(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var request RegisterWebhookRequest
+
+	if err := c.BodyParser(&request); err != nil {
+		h.logger.Warn("Failed to parse webhook registration", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	if err := h.authorizeSender(c, request.UserID); err != nil {
+		return err
+	}
+
+	cmd := service.RegisterWebhookCommand{UserID: request.UserID, URL: request.URL, Secret: request.Secret}
+
+	response, err := h.webhook.RegisterWebhook(ctx, cmd)
+	if err != nil {
+		h.logger.Error("Failed to register webhook",
+			zap.Error(err),
+			zap.String("user_id", request.UserID))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (h *Handler) GetWebhook(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var request WebhookRequest
+
+	if err := c.QueryParser(&request); err != nil {
+		h.logger.Warn("Failed to parse query parameters", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	if err := h.authorizeSender(c, request.UserID); err != nil {
+		return err
+	}
+
+	response, err := h.webhook.GetWebhook(ctx, request.UserID)
+	if err != nil {
+		h.logger.Warn("Failed to get webhook", zap.Error(err), zap.String("user_id", request.UserID))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (h *Handler) DeleteWebhook(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var request WebhookRequest
+
+	if err := c.QueryParser(&request); err != nil {
+		h.logger.Warn("Failed to parse query parameters", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	if err := h.authorizeSender(c, request.UserID); err != nil {
+		return err
+	}
+
+	if err := h.webhook.DeleteWebhook(ctx, request.UserID); err != nil {
+		h.logger.Warn("Failed to delete webhook", zap.Error(err), zap.String("user_id", request.UserID))
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// checkRateLimit sets Retry-After, in whole seconds rounded up, on requests rejected by the rate limiter.
+func (h *Handler) checkRateLimit(c *fiber.Ctx, cmd service.CheckRateLimitCommand) error {
+	err := h.rateLimit.CheckSend(c.UserContext(), cmd)
+
+	var limitErr *service.RateLimitError
+	if errors.As(err, &limitErr) {
+		retryAfter := int(math.Ceil(limitErr.RetryAfter.Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(retryAfter, 1)))
+	}
+
+	return err
+}
+
+// ownedMessage loads a message by id and hides messages of other accounts behind MESSAGE_NOT_FOUND.
+func (h *Handler) ownedMessage(c *fiber.Ctx, id int64) (service.MessageDetails, error) {
+	details, err := h.service.GetMessageByID(c.UserContext(), id)
+	if err != nil {
+		return service.MessageDetails{}, err
+	}
+
+	if !currentAccount(c).OwnsSender(details.From) {
+		return service.MessageDetails{}, service.NewServiceError(constants.ErrCodeMessageNotFound, service.ErrMessageNotFound)
+	}
+
+	return details, nil
+}
+
+func (h *Handler) validationError(c *fiber.Ctx, errs []validator.Error) error {
+	return c.Status(constants.GetHTTPStatus(constants.ErrCodeValidationFailed)).JSON(ValidationErrorResponse{
+		Code:    constants.ErrCodeValidationFailed,
+		Message: constants.GetErrorMessage(constants.ErrCodeValidationFailed),
+		Errors:  toFieldErrors(errs),
+	})
+}
+
+// templateVariablesError reports a variable that failed to render against its placeholder as a field error, the
+// same way a rejected request field is reported. Other render failures are left to the error handler.
+func (h *Handler) templateVariablesError(c *fiber.Ctx, err error) error {
+	var varErr *smstemplate.VariableError
+	if !errors.As(err, &varErr) {
+		return err
+	}
+
+	return c.Status(constants.GetHTTPStatus(constants.ErrCodeInvalidTemplateVariables)).JSON(ValidationErrorResponse{
+		Code:    constants.ErrCodeInvalidTemplateVariables,
+		Message: constants.GetErrorMessage(constants.ErrCodeInvalidTemplateVariables),
+		Errors: []FieldError{{
+			Field:   "variables." + varErr.Name,
+			Code:    constants.ErrCodeInvalidTemplateVariables,
+			Message: varErr.Error(),
+		}},
+	})
+}
+
+func toFieldErrors(errs []validator.Error) []FieldError {
+	fieldErrors := make([]FieldError, 0, len(errs))
+	for _, err := range errs {
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:   err.FailedField,
+			Code:    err.Code,
+			Message: constants.GetErrorMessage(err.Code),
+		})
+	}
+
+	return fieldErrors
+}
+
+// parseQueryTime converts an already validated RFC3339 query value, treating an empty value as no bound.
+func parseQueryTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
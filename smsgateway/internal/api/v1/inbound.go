@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const defaultInboundLimit = 20
+
+// ReceiveInbound accepts mobile-originated messages pushed by the provider named in the path. The body is handed to
+// that provider's parser as is, since every provider has its own format. Bodies carry phone numbers and message text,
+// so they are never logged.
+func (h *Handler) ReceiveInbound(c *fiber.Ctx) error {
+	cmd := service.ReceiveInboundCommand{
+		Provider:    strings.ToLower(c.Params("provider")),
+		ContentType: c.Get(fiber.HeaderContentType),
+		Body:        c.Body(),
+		Header:      func(name string) string { return c.Get(name) },
+	}
+
+	result, err := h.inbound.ReceiveInbound(c.UserContext(), cmd)
+	if err != nil {
+		h.logger.Warn("Failed to receive inbound messages",
+			zap.Error(err),
+			zap.String("provider", cmd.Provider),
+			zap.Int("bodySize", len(cmd.Body)))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+func (h *Handler) ListInbound(c *fiber.Ctx) error {
+	var request ListInboundRequest
+
+	if err := c.QueryParser(&request); err != nil {
+		h.logger.Warn("Failed to parse query parameters", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	if request.To != "" {
+		if err := h.authorizeSender(c, request.To); err != nil {
+			return err
+		}
+	}
+
+	if request.Limit == 0 {
+		request.Limit = defaultInboundLimit
+	}
+
+	query := service.ListInboundQuery{AccountID: currentAccount(c).ID, ToMSISDN: request.To,
+		BeforeID: request.BeforeID, Limit: request.Limit}
+
+	response, err := h.inbound.ListInbound(c.UserContext(), query)
+	if err != nil {
+		h.logger.Warn("Failed to list inbound messages", zap.Error(err))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProviderHandler serves the send worker's view of its providers. Breaker state lives in each worker process, so
+// every worker answers for itself rather than the API answering for all of them.
+type ProviderHandler struct {
+	provider service.ProviderService
+}
+
+func NewProviderHandler(provider service.ProviderService) *ProviderHandler {
+	return &ProviderHandler{provider: provider}
+}
+
+func (h *ProviderHandler) GetProviderHealth(c *fiber.Ctx) error {
+	return c.JSON(ProviderHealthResponse{Providers: h.provider.ProviderHealth()})
+}
@@ -1,14 +1,113 @@
 package v1
 
+import "time"
+
 type SendMessageRequest struct {
-	From      string `json:"from"`
+	From           string     `json:"from" validate:"required,msisdn"`
+	To             string     `json:"to" validate:"required,msisdn"`
+	Text           string     `json:"text" validate:"required,sms_text"`
+	MessageID      string     `json:"message_id" validate:"required,client_message_id"`
+	SendAt         *time.Time `json:"send_at" validate:"omitempty,send_at"`
+	ValidityPeriod int        `json:"validity_period" validate:"omitempty,validity_period"`
+	Priority       string     `json:"priority" validate:"omitempty,oneof=otp transactional bulk"`
+}
+
+type SendBatchRequest struct {
+	BatchID  string                `json:"batch_id" validate:"required,client_message_id"`
+	From     string                `json:"from" validate:"required,msisdn"`
+	Text     string                `json:"text"`
+	Priority string                `json:"priority" validate:"omitempty,oneof=otp transactional bulk"`
+	Messages []BatchMessageRequest `json:"messages" validate:"batch_size"`
+}
+
+type BatchMessageRequest struct {
 	To        string `json:"to"`
 	Text      string `json:"text"`
 	MessageID string `json:"message_id"`
 }
 
 type GetMessagesRequest struct {
-	UserID string `query:"user_id"`
-	Limit  int    `query:"limit"`
-	Offset int    `query:"offset"`
+	UserID                string `query:"user_id" validate:"required"`
+	Limit                 int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor                string `query:"cursor" validate:"omitempty,max=512"`
+	Status                string `query:"status" validate:"omitempty,oneof=CREATED SENDING SUBMITTED FAILED_TEMP FAILED_PERM REFUNDED DELIVERED UNDELIVERED EXPIRED REJECTED CANCELLED"`
+	To                    string `query:"to" validate:"omitempty,msisdn"`
+	CreatedFrom           string `query:"created_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedTo             string `query:"created_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ClientMessageIDPrefix string `query:"client_message_id_prefix" validate:"omitempty,max=64"`
+	IncludeTotal          bool   `query:"include_total"`
+}
+
+type GetMessageRequest struct {
+	From            string `query:"from" validate:"required,msisdn"`
+	ClientMessageID string `query:"client_message_id" validate:"required,client_message_id"`
+}
+
+type DeliveryReportRequest struct {
+	ProviderMsgID string     `json:"provider_msg_id" validate:"required"`
+	Status        string     `json:"status" validate:"required"`
+	ErrorCode     string     `json:"error_code"`
+	ReportedAt    *time.Time `json:"reported_at"`
+}
+
+type RegisterWebhookRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+	URL    string `json:"url" validate:"required,webhook_url"`
+	Secret string `json:"secret" validate:"omitempty,min=16,max=255"`
+}
+
+type WebhookRequest struct {
+	UserID string `query:"user_id" validate:"required"`
+}
+
+type SendTemplateMessageRequest struct {
+	From           string            `json:"from" validate:"required,msisdn"`
+	To             string            `json:"to" validate:"required,msisdn"`
+	TemplateID     int64             `json:"template_id" validate:"required,min=1"`
+	Variables      map[string]string `json:"variables"`
+	MessageID      string            `json:"message_id" validate:"required,client_message_id"`
+	SendAt         *time.Time        `json:"send_at" validate:"omitempty,send_at"`
+	ValidityPeriod int               `json:"validity_period" validate:"omitempty,validity_period"`
+	Priority       string            `json:"priority" validate:"omitempty,oneof=otp transactional bulk"`
+}
+
+type CreateTemplateRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+	Body string `json:"body" validate:"required,sms_text"`
+}
+
+type UpdateTemplateRequest struct {
+	Name string `json:"name" validate:"omitempty,max=255"`
+	Body string `json:"body" validate:"required,sms_text"`
+}
+
+type AddSuppressionRequest struct {
+	MSISDN string `json:"msisdn" validate:"required,msisdn"`
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type ImportSuppressionsRequest struct {
+	Reason  string                  `json:"reason" validate:"required,max=255"`
+	Entries []SuppressionEntryInput `json:"entries" validate:"required,min=1,max=10000"`
+}
+
+// SuppressionEntryInput is one imported number. An empty Reason falls back to the request's reason.
+type SuppressionEntryInput struct {
+	MSISDN string `json:"msisdn" validate:"required,msisdn"`
+	Reason string `json:"reason" validate:"omitempty,max=255"`
+}
+
+type SuppressionRequest struct {
+	MSISDN string `params:"msisdn" validate:"required,msisdn"`
+}
+
+type ListSuppressionsRequest struct {
+	Limit   int   `query:"limit" validate:"omitempty,min=1,max=1000"`
+	AfterID int64 `query:"after_id" validate:"omitempty,min=0"`
+}
+
+type ListInboundRequest struct {
+	To       string `query:"to" validate:"omitempty,msisdn"`
+	Limit    int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	BeforeID int64  `query:"before_id" validate:"omitempty,min=0"`
 }
@@ -1,14 +1,38 @@
 package v1
 
+import (
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+)
+
 type SendMessageResponse struct {
 	Status    string `json:"status"`
 	MessageID int64  `json:"message_id"`
 	Duplicate bool   `json:"duplicate"`
 }
 
+type SendBatchResponse struct {
+	BatchID    string              `json:"batch_id"`
+	Accepted   int                 `json:"accepted"`
+	Duplicates int                 `json:"duplicates"`
+	Rejected   int                 `json:"rejected"`
+	Amount     int64               `json:"amount"`
+	Results    []BatchItemResponse `json:"results"`
+}
+
+type BatchItemResponse struct {
+	Index           int          `json:"index"`
+	ClientMessageID string       `json:"client_message_id"`
+	MessageID       int64        `json:"message_id,omitempty"`
+	Status          string       `json:"status"`
+	Segments        int          `json:"segments,omitempty"`
+	Errors          []FieldError `json:"errors,omitempty"`
+}
+
 type GetMessagesResponse struct {
-	Messages []MessageResponse `json:"messages"`
-	Total    int               `json:"total"`
+	Messages   []MessageResponse `json:"messages"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	Total      *int64            `json:"total,omitempty"`
 }
 
 type MessageResponse struct {
@@ -19,3 +43,34 @@ type MessageResponse struct {
 	Status    string `json:"status"`
 	CreatedAt string `json:"created_at"`
 }
+
+type ValidationErrorResponse struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type TemplatesResponse struct {
+	Templates []service.TemplateDetails `json:"templates"`
+}
+
+type ImportSuppressionsResponse struct {
+	Added    int64                  `json:"added"`
+	Existing int64                  `json:"existing"`
+	Rejected []ImportRejectionEntry `json:"rejected"`
+}
+
+type ImportRejectionEntry struct {
+	Index  int          `json:"index"`
+	Errors []FieldError `json:"errors"`
+}
+
+type ProviderHealthResponse struct {
+	Providers []smsprovider.BreakerStatus `json:"providers"`
+}
@@ -0,0 +1,178 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const defaultSuppressionsLimit = 100
+
+func (h *Handler) AddSuppression(c *fiber.Ctx) error {
+	var request AddSuppressionRequest
+
+	if err := c.BodyParser(&request); err != nil {
+		h.logger.Warn("Failed to parse body", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	accountID := currentAccount(c).ID
+	cmd := service.AddSuppressionCommand{AccountID: &accountID, MSISDN: request.MSISDN, Reason: request.Reason,
+		AddedBy: suppressionAuthor(accountID)}
+
+	response, err := h.suppressions.AddSuppression(c.UserContext(), cmd)
+	if err != nil {
+		h.logger.Warn("Failed to add suppression", zap.Error(err), zap.String("msisdn", request.MSISDN))
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// ImportSuppressions adds the valid entries of a bulk import and reports the invalid ones by index, the same way a
+// batch send reports rejected messages.
+func (h *Handler) ImportSuppressions(c *fiber.Ctx) error {
+	var request ImportSuppressionsRequest
+
+	if err := c.BodyParser(&request); err != nil {
+		h.logger.Warn("Failed to parse body", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	accountID := currentAccount(c).ID
+	cmd := service.ImportSuppressionsCommand{AccountID: &accountID, AddedBy: suppressionAuthor(accountID),
+		Entries: make([]service.SuppressionEntry, 0, len(request.Entries))}
+
+	rejected := make([]ImportRejectionEntry, 0)
+	for i, entry := range request.Entries {
+		if errs := h.XValidator.Validate(entry); len(errs) > 0 {
+			rejected = append(rejected, ImportRejectionEntry{Index: i, Errors: toFieldErrors(errs)})
+			continue
+		}
+
+		reason := entry.Reason
+		if reason == "" {
+			reason = request.Reason
+		}
+
+		cmd.Entries = append(cmd.Entries, service.SuppressionEntry{MSISDN: entry.MSISDN, Reason: reason})
+	}
+
+	resp, err := h.suppressions.ImportSuppressions(c.UserContext(), cmd)
+	if err != nil {
+		h.logger.Warn("Failed to import suppressions", zap.Error(err), zap.Int("entries", len(cmd.Entries)))
+		return err
+	}
+
+	h.logger.Info("Suppressions imported",
+		zap.Int64("accountID", accountID),
+		zap.Int64("added", resp.Added),
+		zap.Int64("existing", resp.Existing),
+		zap.Int("rejected", len(rejected)))
+
+	return c.Status(fiber.StatusOK).JSON(ImportSuppressionsResponse{Added: resp.Added, Existing: resp.Existing,
+		Rejected: rejected})
+}
+
+func (h *Handler) GetSuppression(c *fiber.Ctx) error {
+	var request SuppressionRequest
+
+	if err := c.ParamsParser(&request); err != nil {
+		h.logger.Warn("Failed to parse path parameters", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	response, err := h.suppressions.GetSuppression(c.UserContext(), currentAccount(c).ID, request.MSISDN)
+	if err != nil {
+		h.logger.Warn("Failed to get suppression", zap.Error(err), zap.String("msisdn", request.MSISDN))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (h *Handler) ListSuppressions(c *fiber.Ctx) error {
+	var request ListSuppressionsRequest
+
+	if err := c.QueryParser(&request); err != nil {
+		h.logger.Warn("Failed to parse query parameters", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	if request.Limit == 0 {
+		request.Limit = defaultSuppressionsLimit
+	}
+
+	accountID := currentAccount(c).ID
+	query := service.ListSuppressionsQuery{AccountID: &accountID, AfterID: request.AfterID, Limit: request.Limit}
+
+	response, err := h.suppressions.ListSuppressions(c.UserContext(), query)
+	if err != nil {
+		h.logger.Warn("Failed to list suppressions", zap.Error(err))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// RemoveSuppression only removes the account's own entry. Global entries are managed with smsctl.
+func (h *Handler) RemoveSuppression(c *fiber.Ctx) error {
+	var request SuppressionRequest
+
+	if err := c.ParamsParser(&request); err != nil {
+		h.logger.Warn("Failed to parse path parameters", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	accountID := currentAccount(c).ID
+	cmd := service.RemoveSuppressionCommand{AccountID: &accountID, MSISDN: request.MSISDN}
+
+	if err := h.suppressions.RemoveSuppression(c.UserContext(), cmd); err != nil {
+		h.logger.Warn("Failed to remove suppression", zap.Error(err), zap.String("msisdn", request.MSISDN))
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func suppressionAuthor(accountID int64) string {
+	return fmt.Sprintf("account:%d", accountID)
+}
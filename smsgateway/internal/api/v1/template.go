@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+func (h *Handler) CreateTemplate(c *fiber.Ctx) error {
+	var request CreateTemplateRequest
+
+	if err := c.BodyParser(&request); err != nil {
+		h.logger.Warn("Failed to parse body", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	cmd := service.CreateTemplateCommand{AccountID: currentAccount(c).ID, Name: request.Name, Body: request.Body}
+
+	response, err := h.templates.CreateTemplate(c.UserContext(), cmd)
+	if err != nil {
+		h.logger.Warn("Failed to create template", zap.Error(err), zap.String("name", request.Name))
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+func (h *Handler) UpdateTemplate(c *fiber.Ctx) error {
+	id, ok := h.templateID(c)
+	if !ok {
+		return h.invalidTemplateID(c)
+	}
+
+	var request UpdateTemplateRequest
+
+	if err := c.BodyParser(&request); err != nil {
+		h.logger.Warn("Failed to parse body", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"code":    constants.ErrCodeInvalidRequestBody,
+			"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+		})
+	}
+
+	if errs := h.XValidator.Validate(request); len(errs) > 0 {
+		return h.validationError(c, errs)
+	}
+
+	cmd := service.UpdateTemplateCommand{AccountID: currentAccount(c).ID, TemplateID: id, Name: request.Name,
+		Body: request.Body}
+
+	response, err := h.templates.UpdateTemplate(c.UserContext(), cmd)
+	if err != nil {
+		h.logger.Warn("Failed to update template", zap.Error(err), zap.Int64("id", id))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (h *Handler) GetTemplate(c *fiber.Ctx) error {
+	id, ok := h.templateID(c)
+	if !ok {
+		return h.invalidTemplateID(c)
+	}
+
+	response, err := h.templates.GetTemplate(c.UserContext(), currentAccount(c).ID, id)
+	if err != nil {
+		h.logger.Warn("Failed to get template", zap.Error(err), zap.Int64("id", id))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+func (h *Handler) ListTemplates(c *fiber.Ctx) error {
+	templates, err := h.templates.ListTemplates(c.UserContext(), currentAccount(c).ID)
+	if err != nil {
+		h.logger.Warn("Failed to list templates", zap.Error(err))
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(TemplatesResponse{Templates: templates})
+}
+
+func (h *Handler) DeleteTemplate(c *fiber.Ctx) error {
+	id, ok := h.templateID(c)
+	if !ok {
+		return h.invalidTemplateID(c)
+	}
+
+	if err := h.templates.DeleteTemplate(c.UserContext(), currentAccount(c).ID, id); err != nil {
+		h.logger.Warn("Failed to delete template", zap.Error(err), zap.Int64("id", id))
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) templateID(c *fiber.Ctx) (int64, bool) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		h.logger.Warn("Invalid template id", zap.String("id", c.Params("id")))
+		return 0, false
+	}
+
+	return int64(id), true
+}
+
+func (h *Handler) invalidTemplateID(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"code":    constants.ErrCodeInvalidRequestBody,
+		"message": constants.GetErrorMessage(constants.ErrCodeInvalidRequestBody),
+	})
+}
@@ -0,0 +1,91 @@
+package validator
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/go-playground/validator/v10"
+)
+
+const requiredTag = "required"
+
+var tagErrorCodes = map[string]string{
+	requiredTag:        constants.ErrCodeFieldRequired,
+	MSISDNTag:          constants.ErrCodeInvalidMSISDN,
+	ClientMessageIDTag: constants.ErrCodeInvalidClientMessageID,
+	TextTag:            constants.ErrCodeInvalidTextLength,
+	BatchSizeTag:       constants.ErrCodeInvalidBatchSize,
+	WebhookURLTag:      constants.ErrCodeInvalidURL,
+	SendAtTag:          constants.ErrCodeInvalidSendAt,
+	ValidityPeriodTag:  constants.ErrCodeInvalidValidityPeriod,
+}
+
+type Error struct {
+	FailedField string
+	Tag         string
+	Code        string
+	Value       interface{}
+}
+
+type IXValidator interface {
+	Validate(data interface{}) []Error
+}
+
+type XValidator struct {
+	validator *validator.Validate
+}
+
+func NewXValidator(validator *validator.Validate) IXValidator {
+	for key, function := range valid {
+		validator.RegisterValidation(key, function)
+	}
+
+	validator.RegisterTagNameFunc(fieldName)
+
+	return &XValidator{validator: validator}
+}
+
+func (x XValidator) Validate(data interface{}) []Error {
+	var validationErrors []Error
+
+	errs := x.validator.Struct(data)
+	if errs == nil {
+		return nil
+	}
+
+	fieldErrs, ok := errs.(validator.ValidationErrors)
+	if !ok {
+		return []Error{{Code: constants.ErrCodeInvalidField}}
+	}
+
+	for _, err := range fieldErrs {
+		validationErrors = append(validationErrors, Error{
+			FailedField: err.Field(),
+			Tag:         err.Tag(),
+			Code:        errorCode(err.Tag()),
+			Value:       err.Value(),
+		})
+	}
+
+	return validationErrors
+}
+
+func errorCode(tag string) string {
+	if code, exists := tagErrorCodes[tag]; exists {
+		return code
+	}
+
+	return constants.ErrCodeInvalidField
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "query"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
@@ -0,0 +1,87 @@
+package validator
+
+import (
+	"net/url"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	msisdnRegex          = `^\+?[0-9]{10,15}$`
+	clientMessageIDRegex = `^[A-Za-z0-9._:-]{1,64}$`
+	maxTextLength        = 1600
+	maxBatchSize         = 1000
+	maxScheduleAhead     = 30 * 24 * time.Hour
+	minValidityPeriod    = 60
+	maxValidityPeriod    = 72 * 60 * 60
+)
+
+const (
+	MSISDNTag          = "msisdn"
+	ClientMessageIDTag = "client_message_id"
+	TextTag            = "sms_text"
+	BatchSizeTag       = "batch_size"
+	WebhookURLTag      = "webhook_url"
+	SendAtTag          = "send_at"
+	ValidityPeriodTag  = "validity_period"
+)
+
+var (
+	msisdnPattern          = regexp.MustCompile(msisdnRegex)
+	clientMessageIDPattern = regexp.MustCompile(clientMessageIDRegex)
+)
+
+var valid = map[string]func(fl validator.FieldLevel) bool{
+	MSISDNTag:          ValidateMSISDN,
+	ClientMessageIDTag: ValidateClientMessageID,
+	TextTag:            ValidateText,
+	BatchSizeTag:       ValidateBatchSize,
+	WebhookURLTag:      ValidateWebhookURL,
+	SendAtTag:          ValidateSendAt,
+	ValidityPeriodTag:  ValidateValidityPeriod,
+}
+
+func ValidateMSISDN(fl validator.FieldLevel) bool {
+	return msisdnPattern.MatchString(fl.Field().String())
+}
+
+func ValidateClientMessageID(fl validator.FieldLevel) bool {
+	return clientMessageIDPattern.MatchString(fl.Field().String())
+}
+
+func ValidateText(fl validator.FieldLevel) bool {
+	length := utf8.RuneCountInString(fl.Field().String())
+	return length > 0 && length <= maxTextLength
+}
+
+func ValidateBatchSize(fl validator.FieldLevel) bool {
+	size := fl.Field().Len()
+	return size > 0 && size <= maxBatchSize
+}
+
+func ValidateWebhookURL(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func ValidateSendAt(fl validator.FieldLevel) bool {
+	sendAt, ok := fl.Field().Interface().(time.Time)
+	if !ok {
+		return false
+	}
+
+	now := time.Now()
+	return sendAt.After(now) && sendAt.Before(now.Add(maxScheduleAhead))
+}
+
+func ValidateValidityPeriod(fl validator.FieldLevel) bool {
+	seconds := fl.Field().Int()
+	return seconds >= minValidityPeriod && seconds <= maxValidityPeriod
+}
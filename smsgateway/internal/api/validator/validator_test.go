@@ -0,0 +1,125 @@
+package validator_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/Behyna/sms-services/smsgateway/internal/api/v1"
+	"github.com/Behyna/sms-services/smsgateway/internal/api/validator"
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	playground "github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXValidator_Validate(t *testing.T) {
+	v := validator.NewXValidator(playground.New())
+
+	valid := v1.SendMessageRequest{
+		From:      "09121234567",
+		To:        "+989121234567",
+		Text:      "Hello World",
+		MessageID: "order-42:otp_1",
+	}
+
+	t.Run("accepts a valid request", func(t *testing.T) {
+		assert.Empty(t, v.Validate(valid))
+	})
+
+	t.Run("accepts persian text", func(t *testing.T) {
+		req := valid
+		req.Text = "سلام دنیا"
+		assert.Empty(t, v.Validate(req))
+	})
+
+	t.Run("reports every failing field by json name", func(t *testing.T) {
+		errs := v.Validate(v1.SendMessageRequest{To: "12ab", Text: strings.Repeat("a", 1601), MessageID: "bad id!"})
+
+		codes := map[string]string{}
+		for _, err := range errs {
+			codes[err.FailedField] = err.Code
+		}
+
+		assert.Equal(t, map[string]string{
+			"from":       constants.ErrCodeFieldRequired,
+			"to":         constants.ErrCodeInvalidMSISDN,
+			"text":       constants.ErrCodeInvalidTextLength,
+			"message_id": constants.ErrCodeInvalidClientMessageID,
+		}, codes)
+	})
+
+	t.Run("rejects too short msisdn", func(t *testing.T) {
+		req := valid
+		req.To = "12345"
+
+		errs := v.Validate(req)
+
+		assert.Len(t, errs, 1)
+		assert.Equal(t, "to", errs[0].FailedField)
+		assert.Equal(t, constants.ErrCodeInvalidMSISDN, errs[0].Code)
+	})
+
+	t.Run("rejects too long client message id", func(t *testing.T) {
+		req := valid
+		req.MessageID = strings.Repeat("x", 65)
+
+		errs := v.Validate(req)
+
+		assert.Len(t, errs, 1)
+		assert.Equal(t, constants.ErrCodeInvalidClientMessageID, errs[0].Code)
+	})
+
+	t.Run("checks priority and validity period", func(t *testing.T) {
+		req := valid
+		req.Priority = "otp"
+		req.ValidityPeriod = 300
+		assert.Empty(t, v.Validate(req))
+
+		req.Priority = "urgent"
+		req.ValidityPeriod = 30
+		errs := v.Validate(req)
+
+		codes := map[string]string{}
+		for _, err := range errs {
+			codes[err.FailedField] = err.Code
+		}
+
+		assert.Equal(t, map[string]string{
+			"priority":        constants.ErrCodeInvalidField,
+			"validity_period": constants.ErrCodeInvalidValidityPeriod,
+		}, codes)
+	})
+
+	t.Run("rejects non-http webhook url", func(t *testing.T) {
+		valid := v1.RegisterWebhookRequest{UserID: "09121234567", URL: "https://customer.test/hooks"}
+		assert.Empty(t, v.Validate(valid))
+
+		for _, url := range []string{"ftp://customer.test/hooks", "/hooks", "https://"} {
+			req := valid
+			req.URL = url
+			errs := v.Validate(req)
+
+			assert.Len(t, errs, 1)
+			assert.Equal(t, constants.ErrCodeInvalidURL, errs[0].Code)
+		}
+	})
+
+	t.Run("validates send_at scheduling window", func(t *testing.T) {
+		future := time.Now().Add(time.Hour)
+		past := time.Now().Add(-time.Hour)
+		tooFar := time.Now().Add(31 * 24 * time.Hour)
+
+		scheduled := valid
+		scheduled.SendAt = &future
+		assert.Empty(t, v.Validate(scheduled))
+
+		for _, sendAt := range []*time.Time{&past, &tooFar} {
+			req := valid
+			req.SendAt = sendAt
+			errs := v.Validate(req)
+
+			assert.Len(t, errs, 1)
+			assert.Equal(t, constants.ErrCodeInvalidSendAt, errs[0].Code)
+		}
+	})
+}
@@ -2,26 +2,114 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/Behyna/common/pkg/mq"
 	"github.com/Behyna/common/pkg/mysql"
+	"github.com/Behyna/sms-services/smsgateway/pkg/mqretry"
 	"github.com/Behyna/sms-services/smsgateway/pkg/paymentgateway"
+	"github.com/Behyna/sms-services/smsgateway/pkg/ratelimit"
+	"github.com/Behyna/sms-services/smsgateway/pkg/simulator"
 	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/Behyna/sms-services/smsgateway/pkg/webhook"
 	"github.com/spf13/viper"
 )
 
+const defaultMaxAttempts = 3
+
 type Config struct {
-	API            API                   `mapstructure:"api"`
-	Database       mysql.Config          `mapstructure:"database"`
-	RabbitMQ       mq.Config             `mapstructure:"rabbitmq"`
-	Provider       smsprovider.Config    `mapstructure:"provider"`
-	PaymentGateway paymentgateway.Config `mapstructure:"payment_gateway"`
+	API            API                       `mapstructure:"api"`
+	Database       mysql.Config              `mapstructure:"database"`
+	RabbitMQ       mq.Config                 `mapstructure:"rabbitmq"`
+	Provider       smsprovider.Config        `mapstructure:"provider"`
+	Providers      []smsprovider.RouteConfig `mapstructure:"providers"`
+	ProviderHealth ProviderHealth            `mapstructure:"provider_health"`
+	PaymentGateway paymentgateway.Config     `mapstructure:"payment_gateway"`
+	Message        Message                   `mapstructure:"message"`
+	DeliveryReport DeliveryReport            `mapstructure:"delivery_report"`
+	Webhook        webhook.Config            `mapstructure:"webhook"`
+	RateLimit      ratelimit.Config          `mapstructure:"rate_limit"`
+	Inbound        Inbound                   `mapstructure:"inbound"`
+	Simulator      simulator.Config          `mapstructure:"simulator"`
+	SendWorker     SendWorker                `mapstructure:"send_worker"`
+	Retry          Retry                     `mapstructure:"retry"`
+	Outbox         Outbox                    `mapstructure:"outbox"`
 }
 
 type API struct {
 	Port string `mapstructure:"port"`
 }
 
+type Message struct {
+	DefaultValidityPeriod time.Duration `mapstructure:"default_validity_period"`
+}
+
+type DeliveryReport struct {
+	RefundRejected bool `mapstructure:"refund_rejected"`
+}
+
+// Inbound configures mobile-originated message handling. Providers maps the name used in the push URL to the
+// payload format that provider sends.
+type Inbound struct {
+	StopKeywords  []string                             `mapstructure:"stop_keywords"`
+	StartKeywords []string                             `mapstructure:"start_keywords"`
+	Providers     map[string]smsprovider.InboundConfig `mapstructure:"providers"`
+}
+
+// SendWorker sets how many consumers worker-send runs on each priority lane, which is the share of the worker each
+// lane gets. Lanes left out, or set below one, get a single consumer so that no lane is starved. MaxAttempts caps
+// the provider attempts per message before it fails permanently and is refunded; it defaults to 3.
+type SendWorker struct {
+	Lanes       map[string]int `mapstructure:"lanes"`
+	MaxAttempts int            `mapstructure:"max_attempts"`
+}
+
+// Retry holds the backoff schedules for temporarily failed sends, shared by every send lane, and refunds.
+type Retry struct {
+	Send   mqretry.Policy `mapstructure:"send"`
+	Refund mqretry.Policy `mapstructure:"refund"`
+}
+
+// Outbox configures the publishers that move tx_logs to RabbitMQ. ClaimLease is how long a publisher instance holds
+// the logs it claimed before another instance may take them over; it must be longer than publishing a batch takes.
+// Publishers claim BatchSize logs at a time and, while idle, poll at intervals growing from MinPollInterval to
+// PollInterval. With Wake set the API also nudges the send publisher as soon as it commits a message.
+type Outbox struct {
+	ClaimLease      time.Duration `mapstructure:"claim_lease"`
+	BatchSize       int           `mapstructure:"batch_size"`
+	PollInterval    time.Duration `mapstructure:"poll_interval"`
+	MinPollInterval time.Duration `mapstructure:"min_poll_interval"`
+	Wake            bool          `mapstructure:"wake"`
+}
+
+func (s SendWorker) Consumers(priority string) int {
+	return max(s.Lanes[priority], 1)
+}
+
+func (s SendWorker) Attempts() int {
+	if s.MaxAttempts <= 0 {
+		return defaultMaxAttempts
+	}
+	return s.MaxAttempts
+}
+
+// ProviderHealth configures the send worker's per-provider circuit breakers and the port their state is served on.
+type ProviderHealth struct {
+	Port    string                    `mapstructure:"port"`
+	Breaker smsprovider.BreakerConfig `mapstructure:"breaker"`
+}
+
+// ProviderRoutes returns the configured providers. Deployments that only set the single provider section get it
+// as one route named "default" that accepts every message.
+func (c *Config) ProviderRoutes() []smsprovider.RouteConfig {
+	if len(c.Providers) > 0 {
+		return c.Providers
+	}
+
+	return []smsprovider.RouteConfig{{Name: "default", Config: c.Provider}}
+}
+
 func Load() (cfg *Config, err error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yml")
@@ -32,10 +120,33 @@ func Load() (cfg *Config, err error) {
 		return cfg, fmt.Errorf("failed to load config: %w", err)
 	}
 
+	// A profile overlays config.<profile>.yml on the base file, for settings that only hold in one environment such
+	// as a local run against the provider simulator. Lists, like providers, are replaced rather than merged.
+	if profile := os.Getenv("CONFIG_PROFILE"); profile != "" {
+		viper.SetConfigName("config." + profile)
+		if err = viper.MergeInConfig(); err != nil {
+			return cfg, fmt.Errorf("failed to load %s config profile: %w", profile, err)
+		}
+	}
+
 	err = viper.Unmarshal(&cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
+
+// Validate rejects settings that would strand messages at runtime. A send that fails temporarily on its last attempt
+// needs one more retry to be failed permanently and refunded; without it the message is parked while still charged.
+func (c *Config) Validate() error {
+	if attempts, delays := c.SendWorker.Attempts(), len(c.Retry.Send.Delays); attempts > delays {
+		return fmt.Errorf("send_worker.max_attempts is %d but retry.send.delays has only %d entries", attempts, delays)
+	}
+
+	return nil
+}
@@ -1,27 +1,123 @@
 package constants
 
 const (
-	ErrCodeUserNotFound        = "USER_NOT_FOUND"
-	ErrCodeInsufficientBalance = "INSUFFICIENT_BALANCE"
-	ErrCodeDuplicateMessage    = "DUPLICATE_MESSAGE"
-	ErrCodeInternalError       = "INTERNAL_ERROR"
-	ErrCodeInvalidRequestBody  = "INVALID_REQUEST_BODY"
+	ErrCodeUserNotFound             = "USER_NOT_FOUND"
+	ErrCodeMessageNotFound          = "MESSAGE_NOT_FOUND"
+	ErrCodeInsufficientBalance      = "INSUFFICIENT_BALANCE"
+	ErrCodeDuplicateMessage         = "DUPLICATE_MESSAGE"
+	ErrCodeInternalError            = "INTERNAL_ERROR"
+	ErrCodeInvalidRequestBody       = "INVALID_REQUEST_BODY"
+	ErrCodeValidationFailed         = "VALIDATION_FAILED"
+	ErrCodeFieldRequired            = "FIELD_REQUIRED"
+	ErrCodeInvalidField             = "INVALID_FIELD"
+	ErrCodeInvalidMSISDN            = "INVALID_MSISDN"
+	ErrCodeInvalidClientMessageID   = "INVALID_CLIENT_MESSAGE_ID"
+	ErrCodeInvalidTextLength        = "INVALID_TEXT_LENGTH"
+	ErrCodeInvalidBatchSize         = "INVALID_BATCH_SIZE"
+	ErrCodeInvalidURL               = "INVALID_URL"
+	ErrCodeWebhookNotFound          = "WEBHOOK_NOT_FOUND"
+	ErrCodeMessageNotCancellable    = "MESSAGE_NOT_CANCELLABLE"
+	ErrCodeInvalidSendAt            = "INVALID_SEND_AT"
+	ErrCodeInvalidValidityPeriod    = "INVALID_VALIDITY_PERIOD"
+	ErrCodeInvalidCursor            = "INVALID_CURSOR"
+	ErrCodeUnauthorized             = "UNAUTHORIZED"
+	ErrCodeSenderNotAllowed         = "SENDER_NOT_ALLOWED"
+	ErrCodePriorityNotAllowed       = "PRIORITY_NOT_ALLOWED"
+	ErrCodeAccountNotFound          = "ACCOUNT_NOT_FOUND"
+	ErrCodeSenderTaken              = "SENDER_TAKEN"
+	ErrCodeAPIKeyNotFound           = "API_KEY_NOT_FOUND"
+	ErrCodeRateLimited              = "RATE_LIMITED"
+	ErrCodeIdempotencyMismatch      = "IDEMPOTENCY_KEY_MISMATCH"
+	ErrCodeTemplateNotFound         = "TEMPLATE_NOT_FOUND"
+	ErrCodeInvalidTemplate          = "INVALID_TEMPLATE"
+	ErrCodeInvalidTemplateVariables = "INVALID_TEMPLATE_VARIABLES"
+	ErrCodeRecipientSuppressed      = "RECIPIENT_SUPPRESSED"
+	ErrCodeValidityPeriodExpired    = "VALIDITY_PERIOD_EXPIRED"
+	ErrCodeSuppressionExists        = "SUPPRESSION_EXISTS"
+	ErrCodeSuppressionNotFound      = "SUPPRESSION_NOT_FOUND"
+	ErrCodeInboundProviderNotFound  = "INBOUND_PROVIDER_NOT_FOUND"
+	ErrCodeInvalidInboundPayload    = "INVALID_INBOUND_PAYLOAD"
+	ErrCodeInvalidCallbackSignature = "INVALID_CALLBACK_SIGNATURE"
 )
 
 const (
-	ErrMsgUserNotFound        = "user not found"
-	ErrMsgInsufficientBalance = "insufficient balance"
-	ErrMsgDuplicateMessage    = "duplicate message"
-	ErrMsgInternalError       = "Internal server error"
-	ErrMsgInvalidRequestBody  = "failed to parse request body"
+	ErrMsgUserNotFound             = "user not found"
+	ErrMsgMessageNotFound          = "message not found"
+	ErrMsgInsufficientBalance      = "insufficient balance"
+	ErrMsgDuplicateMessage         = "duplicate message"
+	ErrMsgInternalError            = "Internal server error"
+	ErrMsgInvalidRequestBody       = "failed to parse request body"
+	ErrMsgValidationFailed         = "request validation failed"
+	ErrMsgFieldRequired            = "field is required"
+	ErrMsgInvalidField             = "field is invalid"
+	ErrMsgInvalidMSISDN            = "must be 10 to 15 digits with an optional leading '+'"
+	ErrMsgInvalidClientMessageID   = "must be 1 to 64 characters of letters, digits, '.', '_', ':' or '-'"
+	ErrMsgInvalidTextLength        = "must be between 1 and 1600 characters"
+	ErrMsgInvalidBatchSize         = "must contain between 1 and 1000 messages"
+	ErrMsgInvalidURL               = "must be an absolute http or https URL"
+	ErrMsgWebhookNotFound          = "webhook not found"
+	ErrMsgMessageNotCancellable    = "message can only be cancelled before it is queued for sending"
+	ErrMsgInvalidSendAt            = "must be in the future and within the scheduling window"
+	ErrMsgInvalidValidityPeriod    = "must be between 60 and 259200 seconds"
+	ErrMsgInvalidCursor            = "cursor is invalid or expired"
+	ErrMsgUnauthorized             = "missing or invalid API key"
+	ErrMsgSenderNotAllowed         = "sender is not allowed for this account"
+	ErrMsgPriorityNotAllowed       = "otp priority is not enabled for this account"
+	ErrMsgAccountNotFound          = "account not found"
+	ErrMsgSenderTaken              = "sender is already assigned to another account"
+	ErrMsgAPIKeyNotFound           = "API key not found or already revoked"
+	ErrMsgRateLimited              = "rate limit exceeded, retry later"
+	ErrMsgIdempotencyMismatch      = "message_id was already used with a different payload"
+	ErrMsgTemplateNotFound         = "template not found"
+	ErrMsgInvalidTemplate          = "template body has a malformed placeholder"
+	ErrMsgInvalidTemplateVariables = "template variables are missing, unknown or of the wrong type"
+	ErrMsgRecipientSuppressed      = "recipient has opted out and cannot be messaged"
+	ErrMsgValidityPeriodExpired    = "validity period expired before the message was sent"
+	ErrMsgSuppressionExists        = "recipient is already on the suppression list"
+	ErrMsgSuppressionNotFound      = "suppression entry not found"
+	ErrMsgInboundProviderNotFound  = "inbound provider is not configured"
+	ErrMsgInvalidInboundPayload    = "inbound payload could not be parsed"
+	ErrMsgInvalidCallbackSignature = "callback signature is missing or invalid"
 )
 
 var errorMessages = map[string]string{
-	ErrCodeUserNotFound:        ErrMsgUserNotFound,
-	ErrCodeInsufficientBalance: ErrMsgInsufficientBalance,
-	ErrCodeDuplicateMessage:    ErrMsgDuplicateMessage,
-	ErrCodeInternalError:       ErrMsgInternalError,
-	ErrCodeInvalidRequestBody:  ErrMsgInvalidRequestBody,
+	ErrCodeUserNotFound:             ErrMsgUserNotFound,
+	ErrCodeMessageNotFound:          ErrMsgMessageNotFound,
+	ErrCodeInsufficientBalance:      ErrMsgInsufficientBalance,
+	ErrCodeDuplicateMessage:         ErrMsgDuplicateMessage,
+	ErrCodeInternalError:            ErrMsgInternalError,
+	ErrCodeInvalidRequestBody:       ErrMsgInvalidRequestBody,
+	ErrCodeValidationFailed:         ErrMsgValidationFailed,
+	ErrCodeFieldRequired:            ErrMsgFieldRequired,
+	ErrCodeInvalidField:             ErrMsgInvalidField,
+	ErrCodeInvalidMSISDN:            ErrMsgInvalidMSISDN,
+	ErrCodeInvalidClientMessageID:   ErrMsgInvalidClientMessageID,
+	ErrCodeInvalidTextLength:        ErrMsgInvalidTextLength,
+	ErrCodeInvalidBatchSize:         ErrMsgInvalidBatchSize,
+	ErrCodeInvalidURL:               ErrMsgInvalidURL,
+	ErrCodeWebhookNotFound:          ErrMsgWebhookNotFound,
+	ErrCodeMessageNotCancellable:    ErrMsgMessageNotCancellable,
+	ErrCodeInvalidSendAt:            ErrMsgInvalidSendAt,
+	ErrCodeInvalidValidityPeriod:    ErrMsgInvalidValidityPeriod,
+	ErrCodeInvalidCursor:            ErrMsgInvalidCursor,
+	ErrCodeUnauthorized:             ErrMsgUnauthorized,
+	ErrCodeSenderNotAllowed:         ErrMsgSenderNotAllowed,
+	ErrCodePriorityNotAllowed:       ErrMsgPriorityNotAllowed,
+	ErrCodeAccountNotFound:          ErrMsgAccountNotFound,
+	ErrCodeSenderTaken:              ErrMsgSenderTaken,
+	ErrCodeAPIKeyNotFound:           ErrMsgAPIKeyNotFound,
+	ErrCodeRateLimited:              ErrMsgRateLimited,
+	ErrCodeIdempotencyMismatch:      ErrMsgIdempotencyMismatch,
+	ErrCodeTemplateNotFound:         ErrMsgTemplateNotFound,
+	ErrCodeInvalidTemplate:          ErrMsgInvalidTemplate,
+	ErrCodeInvalidTemplateVariables: ErrMsgInvalidTemplateVariables,
+	ErrCodeRecipientSuppressed:      ErrMsgRecipientSuppressed,
+	ErrCodeValidityPeriodExpired:    ErrMsgValidityPeriodExpired,
+	ErrCodeSuppressionExists:        ErrMsgSuppressionExists,
+	ErrCodeSuppressionNotFound:      ErrMsgSuppressionNotFound,
+	ErrCodeInboundProviderNotFound:  ErrMsgInboundProviderNotFound,
+	ErrCodeInvalidInboundPayload:    ErrMsgInvalidInboundPayload,
+	ErrCodeInvalidCallbackSignature: ErrMsgInvalidCallbackSignature,
 }
 
 func GetErrorMessage(code string) string {
@@ -33,12 +129,23 @@ func GetErrorMessage(code string) string {
 
 func GetHTTPStatus(code string) int {
 	switch code {
-	case ErrCodeInvalidRequestBody:
+	case ErrCodeInvalidRequestBody, ErrCodeInvalidCursor, ErrCodeInvalidInboundPayload:
 		return 400
-	case ErrCodeUserNotFound:
+	case ErrCodeUnauthorized, ErrCodeInvalidCallbackSignature:
+		return 401
+	case ErrCodeSenderNotAllowed, ErrCodePriorityNotAllowed:
+		return 403
+	case ErrCodeUserNotFound, ErrCodeMessageNotFound, ErrCodeWebhookNotFound, ErrCodeAccountNotFound,
+		ErrCodeAPIKeyNotFound, ErrCodeTemplateNotFound, ErrCodeSuppressionNotFound, ErrCodeInboundProviderNotFound:
 		return 404
-	case ErrCodeInsufficientBalance, ErrCodeDuplicateMessage:
+	case ErrCodeInsufficientBalance, ErrCodeDuplicateMessage, ErrCodeMessageNotCancellable, ErrCodeSenderTaken,
+		ErrCodeIdempotencyMismatch, ErrCodeSuppressionExists:
 		return 409
+	case ErrCodeValidationFailed, ErrCodeInvalidTemplate, ErrCodeInvalidTemplateVariables,
+		ErrCodeRecipientSuppressed:
+		return 422
+	case ErrCodeRateLimited:
+		return 429
 	case ErrCodeInternalError:
 		return 500
 	default:
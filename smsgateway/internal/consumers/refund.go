@@ -6,6 +6,7 @@ import (
 
 	"github.com/Behyna/common/pkg/mq"
 	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/mqretry"
 	"go.uber.org/zap"
 )
 
@@ -16,15 +17,17 @@ type RefundConsumer interface {
 type refundConsumer struct {
 	service  service.RefundService
 	consumer mq.Consumer
+	retrier  mqretry.Retrier
 	logger   *zap.Logger
 }
 
-func NewRefundConsumer(service service.RefundService, consumer mq.Consumer, logger *zap.Logger) RefundConsumer {
-	return &refundConsumer{service: service, consumer: consumer, logger: logger}
+func NewRefundConsumer(service service.RefundService, consumer mq.Consumer, retrier mqretry.Retrier,
+	logger *zap.Logger) RefundConsumer {
+	return &refundConsumer{service: service, consumer: consumer, retrier: retrier, logger: logger}
 }
 
 func (r *refundConsumer) Consume(ctx context.Context) error {
-	return r.consumer.Consume(ctx, 1, "sms.refund", r.handleMessage)
+	return r.consumer.Consume(ctx, 1, service.RefundQueue, r.handleMessage)
 }
 
 func (r *refundConsumer) handleMessage(ctx context.Context, body []byte) error {
@@ -33,8 +36,14 @@ func (r *refundConsumer) handleMessage(ctx context.Context, body []byte) error {
 	var cmd service.ProcessRefundCommand
 	if err := json.Unmarshal(body, &cmd); err != nil {
 		r.logger.Warn("invalid refund command", zap.Error(err))
+		return park(ctx, r.retrier, r.logger, service.RefundQueue, body, err)
+	}
+
+	err := r.service.Refund(ctx, cmd)
+	if !isTemporary(err) {
 		return err
 	}
 
-	return r.service.Refund(ctx, cmd)
+	cmd.Retry++
+	return scheduleRetry(ctx, r.retrier, r.logger, service.RefundQueue, cmd.Retry, cmd, err)
 }
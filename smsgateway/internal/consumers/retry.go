@@ -0,0 +1,79 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/mqretry"
+	"go.uber.org/zap"
+)
+
+func isTemporary(err error) bool {
+	var temporary interface{ Temporary() bool }
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
+
+// scheduleRetry hands a command that failed temporarily to its queue's delay queues instead of requeueing it at once.
+// If that fails the original error is returned, so the broker still redelivers the message.
+func scheduleRetry(ctx context.Context, retrier mqretry.Retrier, logger *zap.Logger, queue string, retry int,
+	cmd any, cause error) error {
+	body, err := json.Marshal(cmd)
+	if err != nil {
+		return cause
+	}
+
+	parked, err := retrier.Retry(ctx, queue, retry, body, cause)
+	if err != nil {
+		logger.Error("Failed to schedule retry, requeueing",
+			zap.String("queue", queue),
+			zap.Int("retry", retry),
+			zap.Error(err))
+		return cause
+	}
+
+	if parked {
+		logger.Warn("Retries exhausted, message parked",
+			zap.String("queue", mqretry.ParkingQueue(queue)),
+			zap.Int("retries", retry-1),
+			zap.Error(cause))
+		return nil
+	}
+
+	logger.Debug("Retry scheduled", zap.String("queue", queue), zap.Int("retry", retry), zap.Error(cause))
+	return nil
+}
+
+// scheduleDefer hands a command back to its queue after delay without using up a retry. If that fails the original
+// error is returned, so the broker still redelivers the message.
+func scheduleDefer(ctx context.Context, retrier mqretry.Retrier, logger *zap.Logger, queue string,
+	delay time.Duration, cmd any, cause error) error {
+	body, err := json.Marshal(cmd)
+	if err != nil {
+		return cause
+	}
+
+	if err := retrier.Defer(ctx, queue, delay, body); err != nil {
+		logger.Error("Failed to defer message, requeueing",
+			zap.String("queue", queue),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+		return cause
+	}
+
+	logger.Debug("Message deferred", zap.String("queue", queue), zap.Duration("delay", delay), zap.Error(cause))
+	return nil
+}
+
+// park keeps a message that can never be processed in the parking lot for an operator instead of dropping it.
+func park(ctx context.Context, retrier mqretry.Retrier, logger *zap.Logger, queue string, body []byte,
+	cause error) error {
+	if err := retrier.Park(ctx, queue, body, cause); err != nil {
+		logger.Error("Failed to park message", zap.String("queue", queue), zap.Error(err))
+		return cause
+	}
+
+	logger.Warn("Message parked", zap.String("queue", mqretry.ParkingQueue(queue)), zap.Error(cause))
+	return nil
+}
@@ -3,42 +3,65 @@ package consumers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 
 	"github.com/Behyna/common/pkg/mq"
 	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/mqretry"
 	"go.uber.org/zap"
 )
 
 type SendConsumer interface {
-	Consume(ctx context.Context) error
+	Consume(ctx context.Context, queue string) error
 }
 
 type sendConsumer struct {
 	service  service.SendService
 	consumer mq.Consumer
+	retrier  mqretry.Retrier
 	logger   *zap.Logger
 }
 
-func NewSendConsumer(service service.SendService, consumer mq.Consumer, logger *zap.Logger) SendConsumer {
+func NewSendConsumer(service service.SendService, consumer mq.Consumer, retrier mqretry.Retrier,
+	logger *zap.Logger) SendConsumer {
 	return &sendConsumer{
 		service:  service,
 		consumer: consumer,
+		retrier:  retrier,
 		logger:   logger,
 	}
 }
 
-func (s *sendConsumer) Consume(ctx context.Context) error {
-	return s.consumer.Consume(ctx, 1, "sms.send", s.handleMessage)
+func (s *sendConsumer) Consume(ctx context.Context, queue string) error {
+	return s.consumer.Consume(ctx, 1, queue, func(ctx context.Context, body []byte) error {
+		return s.handleMessage(ctx, queue, body)
+	})
 }
 
-func (s *sendConsumer) handleMessage(ctx context.Context, body []byte) error {
+func (s *sendConsumer) handleMessage(ctx context.Context, queue string, body []byte) error {
 	s.logger.Info("received send command", zap.ByteString("body", body))
 
 	var cmd service.SendMessageCommand
 	if err := json.Unmarshal(body, &cmd); err != nil {
 		s.logger.Warn("invalid send command", zap.Error(err))
+		return park(ctx, s.retrier, s.logger, queue, body, err)
+	}
+
+	err := s.service.SendMessage(ctx, cmd)
+	if !isTemporary(err) {
 		return err
 	}
 
-	return s.service.SendMessage(ctx, cmd)
+	var deferred service.DeferError
+	if errors.As(err, &deferred) {
+		return scheduleDefer(ctx, s.retrier, s.logger, queue, deferred.RetryIn, cmd, err)
+	}
+
+	// Only failed provider calls advance the schedule. Other temporary failures wait out the current step again.
+	var attempt service.AttemptError
+	if errors.As(err, &attempt) {
+		cmd.Retry++
+	}
+
+	return scheduleRetry(ctx, s.retrier, s.logger, queue, max(cmd.Retry, 1), cmd, err)
 }
@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    DROP COLUMN encoding,
+    DROP COLUMN segment_count;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    ADD COLUMN segment_count INT NOT NULL DEFAULT 1 AFTER text,
+    ADD COLUMN encoding      ENUM('GSM7','UCS2') NOT NULL DEFAULT 'GSM7' AFTER segment_count;
@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    DROP INDEX idx_messages_batch_id,
+    DROP COLUMN batch_id;
\ No newline at end of file
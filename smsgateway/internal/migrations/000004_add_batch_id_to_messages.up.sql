@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    ADD COLUMN batch_id VARCHAR(255) NULL AFTER from_msisdn,
+    ADD INDEX idx_messages_batch_id (batch_id);
@@ -0,0 +1,6 @@
+UPDATE messages SET status = 'SUBMITTED' WHERE status IN ('DELIVERED','UNDELIVERED','EXPIRED','REJECTED');
+
+ALTER TABLE messages
+    DROP INDEX idx_messages_provider_msg_id,
+    DROP COLUMN reported_at,
+    MODIFY COLUMN status ENUM('CREATED','SENDING','SUBMITTED','FAILED_TEMP','FAILED_PERM','REFUNDED') NOT NULL DEFAULT 'CREATED';
\ No newline at end of file
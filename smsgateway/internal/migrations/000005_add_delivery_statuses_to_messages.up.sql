@@ -0,0 +1,5 @@
+ALTER TABLE messages
+    MODIFY COLUMN status ENUM('CREATED','SENDING','SUBMITTED','FAILED_TEMP','FAILED_PERM','REFUNDED',
+                              'DELIVERED','UNDELIVERED','EXPIRED','REJECTED') NOT NULL DEFAULT 'CREATED',
+    ADD COLUMN reported_at TIMESTAMP NULL AFTER provider_msg_id,
+    ADD INDEX idx_messages_provider_msg_id (provider_msg_id);
@@ -0,0 +1,42 @@
+CREATE TABLE webhooks (
+    id         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id    VARCHAR(255) NOT NULL,
+    url        VARCHAR(2048) NOT NULL,
+    secret     VARCHAR(255) NOT NULL,
+    enabled    BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY idx_webhooks_user_id (user_id)
+);
+
+CREATE TABLE webhook_events (
+    id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+    webhook_id      BIGINT NOT NULL,
+    message_id      BIGINT NOT NULL,
+    event_type      VARCHAR(64) NOT NULL,
+    payload         JSON NOT NULL,
+    state           ENUM('PENDING','DELIVERED','FAILED') NOT NULL DEFAULT 'PENDING',
+    attempt_count   INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error      TEXT,
+    delivered_at    TIMESTAMP NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    KEY idx_webhook_events_due (state, next_attempt_at),
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE,
+    FOREIGN KEY (message_id) REFERENCES messages(id)
+);
+
+CREATE TABLE webhook_attempts (
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    event_id    BIGINT NOT NULL,
+    attempt     INT NOT NULL,
+    status_code INT NULL,
+    error       TEXT,
+    duration_ms BIGINT NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (event_id) REFERENCES webhook_events(id) ON DELETE CASCADE
+);
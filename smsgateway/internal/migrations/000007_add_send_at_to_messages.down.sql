@@ -0,0 +1,7 @@
+UPDATE messages SET status = 'REFUNDED' WHERE status = 'CANCELLED';
+
+ALTER TABLE messages
+    DROP INDEX idx_messages_send_at,
+    DROP COLUMN send_at,
+    MODIFY COLUMN status ENUM('CREATED','SENDING','SUBMITTED','FAILED_TEMP','FAILED_PERM','REFUNDED',
+                              'DELIVERED','UNDELIVERED','EXPIRED','REJECTED') NOT NULL DEFAULT 'CREATED';
\ No newline at end of file
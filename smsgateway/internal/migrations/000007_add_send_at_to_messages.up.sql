@@ -0,0 +1,5 @@
+ALTER TABLE messages
+    MODIFY COLUMN status ENUM('CREATED','SENDING','SUBMITTED','FAILED_TEMP','FAILED_PERM','REFUNDED',
+                              'DELIVERED','UNDELIVERED','EXPIRED','REJECTED','CANCELLED') NOT NULL DEFAULT 'CREATED',
+    ADD COLUMN send_at TIMESTAMP NULL AFTER encoding,
+    ADD INDEX idx_messages_send_at (send_at);
@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    DROP COLUMN expires_at;
\ No newline at end of file
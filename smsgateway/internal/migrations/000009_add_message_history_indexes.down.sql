@@ -0,0 +1,5 @@
+ALTER TABLE messages
+    DROP INDEX idx_messages_from_created,
+    DROP INDEX idx_messages_from_status_created,
+    DROP INDEX idx_messages_from_to_created,
+    DROP INDEX idx_messages_from_client_msg;
\ No newline at end of file
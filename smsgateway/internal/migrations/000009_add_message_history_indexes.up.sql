@@ -0,0 +1,5 @@
+ALTER TABLE messages
+    ADD INDEX idx_messages_from_created (from_msisdn, created_at, id),
+    ADD INDEX idx_messages_from_status_created (from_msisdn, status, created_at, id),
+    ADD INDEX idx_messages_from_to_created (from_msisdn, to_msisdn, created_at, id),
+    ADD INDEX idx_messages_from_client_msg (from_msisdn, client_message_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS account_senders;
+DROP TABLE IF EXISTS accounts;
\ No newline at end of file
@@ -0,0 +1,5 @@
+CREATE TABLE rate_limit_buckets (
+    bucket_key VARCHAR(255) NOT NULL PRIMARY KEY,
+    tokens     DOUBLE NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL
+);
@@ -0,0 +1,7 @@
+ALTER TABLE messages
+    DROP FOREIGN KEY fk_messages_template,
+    DROP COLUMN template_version,
+    DROP COLUMN template_id;
+
+DROP TABLE IF EXISTS template_versions;
+DROP TABLE IF EXISTS templates;
\ No newline at end of file
@@ -0,0 +1,28 @@
+CREATE TABLE templates (
+    id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+    account_id      BIGINT NOT NULL,
+    name            VARCHAR(255) NOT NULL,
+    current_version INT NOT NULL,
+    deleted_at      TIMESTAMP NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    KEY idx_templates_account (account_id, deleted_at),
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE template_versions (
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    template_id BIGINT NOT NULL,
+    version     INT NOT NULL,
+    body        TEXT NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY idx_template_versions_version (template_id, version),
+    FOREIGN KEY (template_id) REFERENCES templates(id) ON DELETE CASCADE
+);
+
+ALTER TABLE messages
+    ADD COLUMN template_id BIGINT NULL AFTER batch_id,
+    ADD COLUMN template_version INT NULL AFTER template_id,
+    ADD CONSTRAINT fk_messages_template FOREIGN KEY (template_id) REFERENCES templates(id);
@@ -0,0 +1,14 @@
+CREATE TABLE suppressions (
+    id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    account_id  BIGINT NULL,
+    -- NULLs never collide in a unique key, so global entries are keyed as account 0.
+    account_key BIGINT AS (IFNULL(account_id, 0)) STORED,
+    msisdn      VARCHAR(20) NOT NULL,
+    reason      VARCHAR(255) NOT NULL,
+    added_by    VARCHAR(255) NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY idx_suppressions_msisdn (msisdn, account_key),
+    KEY idx_suppressions_account (account_key, id),
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
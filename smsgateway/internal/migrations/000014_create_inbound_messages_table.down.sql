@@ -0,0 +1 @@
+DROP TABLE IF EXISTS inbound_messages;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE inbound_messages (
+    id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+    account_id      BIGINT NULL,
+    provider        VARCHAR(64) NOT NULL,
+    provider_msg_id VARCHAR(255) NULL,
+    from_msisdn     VARCHAR(20) NOT NULL,
+    to_msisdn       VARCHAR(20) NOT NULL,
+    text            TEXT NOT NULL,
+    keyword         VARCHAR(16) NULL,
+    received_at     TIMESTAMP NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY idx_inbound_messages_provider_msg (provider, provider_msg_id),
+    KEY idx_inbound_messages_to (to_msisdn, id),
+    KEY idx_inbound_messages_account (account_id, id),
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE SET NULL
+);
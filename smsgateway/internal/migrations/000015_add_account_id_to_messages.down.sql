@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    DROP FOREIGN KEY fk_messages_account,
+    DROP COLUMN account_id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    ADD COLUMN account_id BIGINT NULL AFTER id,
+    ADD CONSTRAINT fk_messages_account FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE SET NULL;
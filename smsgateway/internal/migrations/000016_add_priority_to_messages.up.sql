@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    ADD COLUMN priority ENUM('otp','transactional','bulk') NOT NULL DEFAULT 'transactional' AFTER encoding;
@@ -0,0 +1,4 @@
+ALTER TABLE tx_logs
+    DROP INDEX idx_tx_logs_outbox,
+    DROP COLUMN claimed_until,
+    DROP COLUMN claimed_by;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE tx_logs
+    ADD COLUMN claimed_by VARCHAR(255) NULL AFTER published_at,
+    ADD COLUMN claimed_until TIMESTAMP NULL AFTER claimed_by,
+    ADD INDEX idx_tx_logs_outbox (state, published, claimed_until);
@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    DROP INDEX idx_messages_provider_msg,
+    ADD INDEX idx_messages_provider_msg_id (provider_msg_id);
\ No newline at end of file
@@ -0,0 +1,5 @@
+UPDATE messages SET provider_msg_id = NULL WHERE provider_msg_id = '';
+
+ALTER TABLE messages
+    DROP INDEX idx_messages_provider_msg_id,
+    ADD UNIQUE INDEX idx_messages_provider_msg (provider, provider_msg_id);
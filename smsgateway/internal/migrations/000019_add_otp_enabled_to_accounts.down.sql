@@ -0,0 +1,2 @@
+ALTER TABLE accounts
+    DROP COLUMN otp_enabled;
\ No newline at end of file
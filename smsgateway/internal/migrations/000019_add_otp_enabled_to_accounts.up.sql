@@ -0,0 +1,2 @@
+ALTER TABLE accounts
+    ADD COLUMN otp_enabled BOOLEAN NOT NULL DEFAULT FALSE AFTER enabled;
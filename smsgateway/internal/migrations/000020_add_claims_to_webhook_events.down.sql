@@ -0,0 +1,5 @@
+ALTER TABLE webhook_events
+    DROP INDEX idx_webhook_events_due,
+    ADD INDEX idx_webhook_events_due (state, next_attempt_at),
+    DROP COLUMN claimed_until,
+    DROP COLUMN claimed_by;
\ No newline at end of file
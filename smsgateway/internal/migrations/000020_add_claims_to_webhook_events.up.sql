@@ -0,0 +1,5 @@
+ALTER TABLE webhook_events
+    ADD COLUMN claimed_by VARCHAR(255) NULL AFTER delivered_at,
+    ADD COLUMN claimed_until TIMESTAMP NULL AFTER claimed_by,
+    DROP INDEX idx_webhook_events_due,
+    ADD INDEX idx_webhook_events_due (state, next_attempt_at, claimed_until);
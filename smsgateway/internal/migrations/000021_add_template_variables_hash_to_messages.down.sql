@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    DROP COLUMN template_variables_hash;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    ADD COLUMN template_variables_hash CHAR(64) NULL AFTER template_version;
@@ -0,0 +1,4 @@
+ALTER TABLE tx_logs
+    DROP INDEX idx_tx_logs_lane,
+    DROP COLUMN ready_at,
+    DROP COLUMN priority;
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE tx_logs
+    ADD COLUMN priority ENUM('otp','transactional','bulk') NOT NULL DEFAULT 'transactional' AFTER amount,
+    ADD COLUMN ready_at TIMESTAMP NULL AFTER priority;
+
+UPDATE tx_logs JOIN messages ON messages.id = tx_logs.message_id
+SET tx_logs.priority = messages.priority,
+    tx_logs.ready_at = COALESCE(messages.send_at, tx_logs.created_at);
+
+ALTER TABLE tx_logs
+    MODIFY COLUMN ready_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD INDEX idx_tx_logs_lane (state, published, priority, ready_at);
@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type AccountRepository struct {
+	mock.Mock
+}
+
+func (a *AccountRepository) Create(ctx context.Context, account *model.Account) error {
+	args := a.Called(ctx, account)
+	return args.Error(0)
+}
+
+func (a *AccountRepository) GetByID(id int64) (*model.Account, error) {
+	args := a.Called(id)
+	return args.Get(0).(*model.Account), args.Error(1)
+}
+
+func (a *AccountRepository) GetBySender(msisdns []string) (*model.Account, error) {
+	args := a.Called(msisdns)
+	return args.Get(0).(*model.Account), args.Error(1)
+}
+
+func (a *AccountRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	args := a.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (a *AccountRepository) RevokeAPIKey(ctx context.Context, id int64, revokedAt time.Time) error {
+	args := a.Called(ctx, id, revokedAt)
+	return args.Error(0)
+}
+
+func (a *AccountRepository) GetAPIKeyByHash(keyHash string) (*model.APIKey, error) {
+	args := a.Called(keyHash)
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type AccountService struct {
+	mock.Mock
+}
+
+func (a *AccountService) Authenticate(ctx context.Context, apiKey string) (service.Account, error) {
+	args := a.Called(ctx, apiKey)
+	return args.Get(0).(service.Account), args.Error(1)
+}
+
+func (a *AccountService) CreateAccount(ctx context.Context, cmd service.CreateAccountCommand) (
+	service.AccountDetails, error) {
+	args := a.Called(ctx, cmd)
+	return args.Get(0).(service.AccountDetails), args.Error(1)
+}
+
+func (a *AccountService) IssueAPIKey(ctx context.Context, cmd service.IssueAPIKeyCommand) (service.APIKeyDetails,
+	error) {
+	args := a.Called(ctx, cmd)
+	return args.Get(0).(service.APIKeyDetails), args.Error(1)
+}
+
+func (a *AccountService) RevokeAPIKey(ctx context.Context, id int64) error {
+	args := a.Called(ctx, id)
+	return args.Error(0)
+}
@@ -0,0 +1,24 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type DeliveryReportService struct {
+	mock.Mock
+}
+
+func (d *DeliveryReportService) AuthenticateReport(provider string, header func(name string) string,
+	body []byte) error {
+	args := d.Called(provider, header, body)
+	return args.Error(0)
+}
+
+func (d *DeliveryReportService) ProcessDeliveryReport(ctx context.Context, cmd service.DeliveryReportCommand) (
+	service.DeliveryReportResult, error) {
+	args := d.Called(ctx, cmd)
+	return args.Get(0).(service.DeliveryReportResult), args.Error(1)
+}
@@ -0,0 +1,24 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type InboundMessageRepository struct {
+	mock.Mock
+}
+
+func (i *InboundMessageRepository) Create(ctx context.Context, msg *model.InboundMessage) error {
+	args := i.Called(ctx, msg)
+	return args.Error(0)
+}
+
+func (i *InboundMessageRepository) FindByFilter(filter repository.InboundFilter, beforeID int64, limit int) (
+	[]model.InboundMessage, error) {
+	args := i.Called(filter, beforeID, limit)
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -17,6 +18,11 @@ func (m *MessageRepository) Create(ctx context.Context, message *model.Message)
 	return args.Error(0)
 }
 
+func (m *MessageRepository) CreateBatch(ctx context.Context, messages []model.Message) error {
+	args := m.Called(ctx, messages)
+	return args.Error(0)
+}
+
 func (m *MessageRepository) Update(ctx context.Context, message *model.Message) error {
 	args := m.Called(ctx, message)
 	return args.Error(0)
@@ -27,17 +33,48 @@ func (m *MessageRepository) UpdateForSending(ctx context.Context, message *model
 	return args.Error(0)
 }
 
+func (m *MessageRepository) UpdateDeliveryStatus(ctx context.Context, message *model.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+func (m *MessageRepository) UpdateForCancel(ctx context.Context, message *model.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+func (m *MessageRepository) UpdateForExpiry(ctx context.Context, message *model.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
 func (m *MessageRepository) GetByID(id int64) (*model.Message, error) {
 	args := m.Called(id)
 	return args.Get(0).(*model.Message), args.Error(1)
 }
 
-func (m *MessageRepository) GetByUserID(userID string, limit, offset int) ([]model.Message, error) {
-	args := m.Called(userID, limit, offset)
+func (m *MessageRepository) GetByProviderMsgID(provider, providerMsgID string) (*model.Message, error) {
+	args := m.Called(provider, providerMsgID)
+	return args.Get(0).(*model.Message), args.Error(1)
+}
+
+func (m *MessageRepository) GetByClientMessageID(fromMSISDN, clientMessageID string) (*model.Message, error) {
+	args := m.Called(fromMSISDN, clientMessageID)
+	return args.Get(0).(*model.Message), args.Error(1)
+}
+
+func (m *MessageRepository) GetByClientMessageIDs(fromMSISDN string, clientMessageIDs []string) ([]model.Message, error) {
+	args := m.Called(fromMSISDN, clientMessageIDs)
+	return args.Get(0).([]model.Message), args.Error(1)
+}
+
+func (m *MessageRepository) FindByFilter(filter repository.MessageFilter, cursor *repository.MessageCursor,
+	limit int) ([]model.Message, error) {
+	args := m.Called(filter, cursor, limit)
 	return args.Get(0).([]model.Message), args.Error(1)
 }
 
-func (m *MessageRepository) CountByUserID(userID string) (int, error) {
-	args := m.Called(userID)
-	return args.Int(0), args.Error(1)
+func (m *MessageRepository) CountByFilter(filter repository.MessageFilter) (int64, error) {
+	args := m.Called(filter)
+	return args.Get(0).(int64), args.Error(1)
 }
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
 	"github.com/stretchr/testify/mock"
@@ -11,7 +12,18 @@ type ProviderService struct {
 	mock.Mock
 }
 
-func (p *ProviderService) SendWithRetry(ctx context.Context, fromMSISDN, toMSISDN, text string) (smsprovider.Response, error) {
-	args := p.Called(ctx, fromMSISDN, toMSISDN, text)
+func (p *ProviderService) SendWithRetry(ctx context.Context, accountID int64, fromMSISDN, toMSISDN, text string) (
+	smsprovider.Response, error) {
+	args := p.Called(ctx, accountID, fromMSISDN, toMSISDN, text)
 	return args.Get(0).(smsprovider.Response), args.Error(1)
 }
+
+func (p *ProviderService) CircuitOpen(accountID int64, toMSISDN string) (time.Duration, bool) {
+	args := p.Called(accountID, toMSISDN)
+	return args.Get(0).(time.Duration), args.Bool(1)
+}
+
+func (p *ProviderService) ProviderHealth() []smsprovider.BreakerStatus {
+	args := p.Called()
+	return args.Get(0).([]smsprovider.BreakerStatus)
+}
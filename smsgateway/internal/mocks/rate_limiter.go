@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/ratelimit"
+	"github.com/stretchr/testify/mock"
+)
+
+type RateLimiter struct {
+	mock.Mock
+}
+
+func (r *RateLimiter) Allow(ctx context.Context, key string, limit ratelimit.Limit, cost int) (ratelimit.Result,
+	error) {
+	args := r.Called(ctx, key, limit, cost)
+	return args.Get(0).(ratelimit.Result), args.Error(1)
+}
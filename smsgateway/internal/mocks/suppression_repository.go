@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type SuppressionRepository struct {
+	mock.Mock
+}
+
+func (s *SuppressionRepository) Create(ctx context.Context, suppression *model.Suppression) error {
+	args := s.Called(ctx, suppression)
+	return args.Error(0)
+}
+
+func (s *SuppressionRepository) CreateBatch(ctx context.Context, suppressions []model.Suppression) (int64, error) {
+	args := s.Called(ctx, suppressions)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (s *SuppressionRepository) Delete(ctx context.Context, accountID *int64, msisdn string) error {
+	args := s.Called(ctx, accountID, msisdn)
+	return args.Error(0)
+}
+
+func (s *SuppressionRepository) FindByMSISDNs(accountID int64, msisdns []string) ([]model.Suppression, error) {
+	args := s.Called(accountID, msisdns)
+	return args.Get(0).([]model.Suppression), args.Error(1)
+}
+
+func (s *SuppressionRepository) ListByAccount(accountID *int64, afterID int64, limit int) (
+	[]model.Suppression, error) {
+	args := s.Called(accountID, afterID, limit)
+	return args.Get(0).([]model.Suppression), args.Error(1)
+}
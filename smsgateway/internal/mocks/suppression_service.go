@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type SuppressionService struct {
+	mock.Mock
+}
+
+func (s *SuppressionService) AddSuppression(ctx context.Context, cmd service.AddSuppressionCommand) (
+	service.SuppressionDetails, error) {
+	args := s.Called(ctx, cmd)
+	return args.Get(0).(service.SuppressionDetails), args.Error(1)
+}
+
+func (s *SuppressionService) ImportSuppressions(ctx context.Context, cmd service.ImportSuppressionsCommand) (
+	service.ImportSuppressionsResponse, error) {
+	args := s.Called(ctx, cmd)
+	return args.Get(0).(service.ImportSuppressionsResponse), args.Error(1)
+}
+
+func (s *SuppressionService) RemoveSuppression(ctx context.Context, cmd service.RemoveSuppressionCommand) error {
+	args := s.Called(ctx, cmd)
+	return args.Error(0)
+}
+
+func (s *SuppressionService) GetSuppression(ctx context.Context, accountID int64, msisdn string) (
+	service.SuppressionStatus, error) {
+	args := s.Called(ctx, accountID, msisdn)
+	return args.Get(0).(service.SuppressionStatus), args.Error(1)
+}
+
+func (s *SuppressionService) ListSuppressions(ctx context.Context, query service.ListSuppressionsQuery) (
+	service.ListSuppressionsResponse, error) {
+	args := s.Called(ctx, query)
+	return args.Get(0).(service.ListSuppressionsResponse), args.Error(1)
+}
+
+func (s *SuppressionService) SuppressedRecipients(ctx context.Context, accountID int64, msisdns []string) (
+	map[string]bool, error) {
+	args := s.Called(ctx, accountID, msisdns)
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
@@ -0,0 +1,53 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type TemplateRepository struct {
+	mock.Mock
+}
+
+func (t *TemplateRepository) Create(ctx context.Context, template *model.Template) error {
+	args := t.Called(ctx, template)
+	return args.Error(0)
+}
+
+func (t *TemplateRepository) Update(ctx context.Context, template *model.Template) error {
+	args := t.Called(ctx, template)
+	return args.Error(0)
+}
+
+func (t *TemplateRepository) Delete(ctx context.Context, accountID, id int64, deletedAt time.Time) error {
+	args := t.Called(ctx, accountID, id, deletedAt)
+	return args.Error(0)
+}
+
+func (t *TemplateRepository) GetByID(accountID, id int64) (*model.Template, error) {
+	args := t.Called(accountID, id)
+	return args.Get(0).(*model.Template), args.Error(1)
+}
+
+func (t *TemplateRepository) GetByIDForUpdate(ctx context.Context, accountID, id int64) (*model.Template, error) {
+	args := t.Called(ctx, accountID, id)
+	return args.Get(0).(*model.Template), args.Error(1)
+}
+
+func (t *TemplateRepository) ListByAccount(accountID int64) ([]model.Template, error) {
+	args := t.Called(accountID)
+	return args.Get(0).([]model.Template), args.Error(1)
+}
+
+func (t *TemplateRepository) CreateVersion(ctx context.Context, version *model.TemplateVersion) error {
+	args := t.Called(ctx, version)
+	return args.Error(0)
+}
+
+func (t *TemplateRepository) GetVersion(templateID int64, version int) (*model.TemplateVersion, error) {
+	args := t.Called(templateID, version)
+	return args.Get(0).(*model.TemplateVersion), args.Error(1)
+}
@@ -0,0 +1,45 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type TemplateService struct {
+	mock.Mock
+}
+
+func (t *TemplateService) CreateTemplate(ctx context.Context, cmd service.CreateTemplateCommand) (
+	service.TemplateDetails, error) {
+	args := t.Called(ctx, cmd)
+	return args.Get(0).(service.TemplateDetails), args.Error(1)
+}
+
+func (t *TemplateService) UpdateTemplate(ctx context.Context, cmd service.UpdateTemplateCommand) (
+	service.TemplateDetails, error) {
+	args := t.Called(ctx, cmd)
+	return args.Get(0).(service.TemplateDetails), args.Error(1)
+}
+
+func (t *TemplateService) GetTemplate(ctx context.Context, accountID, id int64) (service.TemplateDetails, error) {
+	args := t.Called(ctx, accountID, id)
+	return args.Get(0).(service.TemplateDetails), args.Error(1)
+}
+
+func (t *TemplateService) ListTemplates(ctx context.Context, accountID int64) ([]service.TemplateDetails, error) {
+	args := t.Called(ctx, accountID)
+	return args.Get(0).([]service.TemplateDetails), args.Error(1)
+}
+
+func (t *TemplateService) DeleteTemplate(ctx context.Context, accountID, id int64) error {
+	args := t.Called(ctx, accountID, id)
+	return args.Error(0)
+}
+
+func (t *TemplateService) RenderTemplate(ctx context.Context, cmd service.RenderTemplateCommand) (
+	service.RenderedTemplate, error) {
+	args := t.Called(ctx, cmd)
+	return args.Get(0).(service.RenderedTemplate), args.Error(1)
+}
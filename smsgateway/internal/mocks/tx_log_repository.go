@@ -2,8 +2,10 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -16,6 +18,11 @@ func (t *TxLogRepository) Create(ctx context.Context, log *model.TxLog) error {
 	return args.Error(0)
 }
 
+func (t *TxLogRepository) CreateBatch(ctx context.Context, logs []model.TxLog) error {
+	args := t.Called(ctx, logs)
+	return args.Error(0)
+}
+
 func (t *TxLogRepository) Update(log *model.TxLog) error {
 	args := t.Called(log)
 	return args.Error(0)
@@ -31,13 +38,34 @@ func (t *TxLogRepository) UpdateForPermFailed(ctx context.Context, log *model.Tx
 	return args.Error(0)
 }
 
-func (t *TxLogRepository) FindUnpublishedFailed(limit int) ([]model.TxLog, error) {
-	args := t.Called(limit)
+func (t *TxLogRepository) UpdateForPublished(ctx context.Context, log *model.TxLog, owner string) error {
+	args := t.Called(ctx, log, owner)
+	return args.Error(0)
+}
+
+func (t *TxLogRepository) UpdateRefundForPublished(ctx context.Context, log *model.TxLog, owner string) error {
+	args := t.Called(ctx, log, owner)
+	return args.Error(0)
+}
+
+func (t *TxLogRepository) ReleaseClaim(ctx context.Context, log *model.TxLog, owner string) error {
+	args := t.Called(ctx, log, owner)
+	return args.Error(0)
+}
+
+func (t *TxLogRepository) ClaimUnpublishedFailed(claim repository.Claim, limit int) ([]model.TxLog, error) {
+	args := t.Called(claim, limit)
 	return args.Get(0).([]model.TxLog), args.Error(1)
 }
 
-func (t *TxLogRepository) FindUnpublishedCreated(limit int) ([]model.TxLog, error) {
-	args := t.Called(limit)
+func (t *TxLogRepository) UpdateForCancel(ctx context.Context, log *model.TxLog) error {
+	args := t.Called(ctx, log)
+	return args.Error(0)
+}
+
+func (t *TxLogRepository) ClaimUnpublishedCreated(claim repository.Claim, now time.Time, limit int) (
+	[]model.TxLog, error) {
+	args := t.Called(claim, now, limit)
 	return args.Get(0).([]model.TxLog), args.Error(1)
 }
 
@@ -45,3 +73,8 @@ func (t *TxLogRepository) GetByID(id int64) (*model.TxLog, error) {
 	args := t.Called(id)
 	return args.Get(0).(*model.TxLog), args.Error(1)
 }
+
+func (t *TxLogRepository) GetByMessageID(messageID int64) (*model.TxLog, error) {
+	args := t.Called(messageID)
+	return args.Get(0).(*model.TxLog), args.Error(1)
+}
@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/webhook"
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookClient struct {
+	mock.Mock
+}
+
+func (w *WebhookClient) Deliver(ctx context.Context, request webhook.Request) (webhook.Response, error) {
+	args := w.Called(ctx, request)
+	return args.Get(0).(webhook.Response), args.Error(1)
+}
@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookEventRepository struct {
+	mock.Mock
+}
+
+func (w *WebhookEventRepository) Create(ctx context.Context, event *model.WebhookEvent) error {
+	args := w.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (w *WebhookEventRepository) Update(ctx context.Context, event *model.WebhookEvent, owner string) error {
+	args := w.Called(ctx, event, owner)
+	return args.Error(0)
+}
+
+func (w *WebhookEventRepository) CreateAttempt(ctx context.Context, attempt *model.WebhookAttempt) error {
+	args := w.Called(ctx, attempt)
+	return args.Error(0)
+}
+
+func (w *WebhookEventRepository) ClaimDue(claim repository.Claim, now time.Time, limit int) (
+	[]model.WebhookEvent, error) {
+	args := w.Called(claim, now, limit)
+	return args.Get(0).([]model.WebhookEvent), args.Error(1)
+}
@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookRepository struct {
+	mock.Mock
+}
+
+func (w *WebhookRepository) Upsert(ctx context.Context, webhook *model.Webhook) error {
+	args := w.Called(ctx, webhook)
+	return args.Error(0)
+}
+
+func (w *WebhookRepository) Delete(ctx context.Context, userID string) error {
+	args := w.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (w *WebhookRepository) GetByUserID(userID string) (*model.Webhook, error) {
+	args := w.Called(userID)
+	return args.Get(0).(*model.Webhook), args.Error(1)
+}
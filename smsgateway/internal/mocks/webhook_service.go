@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookService struct {
+	mock.Mock
+}
+
+func (w *WebhookService) RegisterWebhook(ctx context.Context, cmd service.RegisterWebhookCommand) (service.WebhookDetails, error) {
+	args := w.Called(ctx, cmd)
+	return args.Get(0).(service.WebhookDetails), args.Error(1)
+}
+
+func (w *WebhookService) GetWebhook(ctx context.Context, userID string) (service.WebhookDetails, error) {
+	args := w.Called(ctx, userID)
+	return args.Get(0).(service.WebhookDetails), args.Error(1)
+}
+
+func (w *WebhookService) DeleteWebhook(ctx context.Context, userID string) error {
+	args := w.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (w *WebhookService) Notify(ctx context.Context, cmd service.NotifyWebhookCommand) error {
+	args := w.Called(ctx, cmd)
+	return args.Error(0)
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type Account struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement;<-:create"`
+	Name       string    `gorm:"type:varchar(255);not null"`
+	Enabled    bool      `gorm:"default:true;not null"`
+	OTPEnabled bool      `gorm:"column:otp_enabled;default:false;not null"`
+	CreatedAt  time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt  time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+
+	Senders []AccountSender `gorm:"foreignKey:AccountID"`
+}
+
+type AccountSender struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement;<-:create"`
+	AccountID int64     `gorm:"not null;<-:create"`
+	MSISDN    string    `gorm:"column:msisdn;type:varchar(20);uniqueIndex;not null"`
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
+
+type APIKey struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement;<-:create"`
+	AccountID int64      `gorm:"not null;<-:create"`
+	Name      string     `gorm:"type:varchar(255);not null"`
+	Prefix    string     `gorm:"type:varchar(16);not null"`
+	KeyHash   string     `gorm:"type:char(64);uniqueIndex;not null"`
+	RevokedAt *time.Time `gorm:"type:timestamp;null"`
+	CreatedAt time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+
+	Account Account `gorm:"foreignKey:AccountID"`
+}
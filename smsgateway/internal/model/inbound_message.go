@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// InboundMessage is a mobile-originated message. AccountID is the owner of ToMSISDN when the message arrived and is
+// nil for numbers no account owns.
+type InboundMessage struct {
+	ID            int64     `gorm:"primaryKey;autoIncrement;<-:create"`
+	AccountID     *int64    `gorm:"null;<-:create"`
+	Provider      string    `gorm:"type:varchar(64);not null;<-:create"`
+	ProviderMsgID *string   `gorm:"type:varchar(255);null;<-:create"`
+	FromMSISDN    string    `gorm:"column:from_msisdn;type:varchar(20);not null;<-:create"`
+	ToMSISDN      string    `gorm:"column:to_msisdn;type:varchar(20);not null;<-:create"`
+	Text          string    `gorm:"type:text;not null;<-:create"`
+	Keyword       *string   `gorm:"type:varchar(16);null;<-:create"`
+	ReceivedAt    time.Time `gorm:"type:timestamp;not null;<-:create"`
+	CreatedAt     time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
@@ -11,19 +11,49 @@ const (
 	MessageStatusFailedTemp MessageStatus = "FAILED_TEMP"
 	MessageStatusFailedPerm MessageStatus = "FAILED_PERM"
 	MessageStatusRefunded   MessageStatus = "REFUNDED"
+
+	MessageStatusDelivered   MessageStatus = "DELIVERED"
+	MessageStatusUndelivered MessageStatus = "UNDELIVERED"
+	MessageStatusExpired     MessageStatus = "EXPIRED"
+	MessageStatusRejected    MessageStatus = "REJECTED"
+
+	MessageStatusCancelled MessageStatus = "CANCELLED"
 )
 
+// MessagePriority picks the send lane a message travels on, so bulk traffic never queues ahead of OTPs.
+type MessagePriority string
+
+const (
+	MessagePriorityOTP           MessagePriority = "otp"
+	MessagePriorityTransactional MessagePriority = "transactional"
+	MessagePriorityBulk          MessagePriority = "bulk"
+)
+
+// MessagePriorities lists the priorities from most to least urgent.
+var MessagePriorities = []MessagePriority{MessagePriorityOTP, MessagePriorityTransactional, MessagePriorityBulk}
+
 type Message struct {
-	ID              int64         `gorm:"primaryKey;autoIncrement;column:id;<-:create"`
-	ClientMessageID string        `gorm:"column:client_message_id;index:idx_client_msg_from,unique"`
-	FromMSISDN      string        `gorm:"column:from_msisdn;index:idx_client_msg_from,unique"`
-	ToMSISDN        string        `gorm:"column:to_msisdn"`
-	Text            string        `gorm:"column:text"`
-	Status          MessageStatus `gorm:"column:status"`
-	AttemptCount    int           `gorm:"column:attempt_count"`
-	LastAttemptAt   *time.Time    `gorm:"column:last_attempt_at"`
-	Provider        *string       `gorm:"column:provider"`
-	ProviderMsgID   *string       `gorm:"column:provider_msg_id"`
-	CreatedAt       time.Time     `gorm:"column:created_at"`
-	UpdatedAt       time.Time     `gorm:"column:updated_at"`
+	ID              int64           `gorm:"primaryKey;autoIncrement;column:id;<-:create"`
+	AccountID       *int64          `gorm:"column:account_id"`
+	ClientMessageID string          `gorm:"column:client_message_id;index:idx_client_msg_from,unique"`
+	FromMSISDN      string          `gorm:"column:from_msisdn;index:idx_client_msg_from,unique"`
+	BatchID         *string         `gorm:"column:batch_id"`
+	TemplateID      *int64          `gorm:"column:template_id"`
+	TemplateVersion *int            `gorm:"column:template_version"`
+	TemplateVarHash *string         `gorm:"column:template_variables_hash"`
+	ToMSISDN        string          `gorm:"column:to_msisdn"`
+	Text            string          `gorm:"column:text"`
+	SegmentCount    int             `gorm:"column:segment_count"`
+	Encoding        string          `gorm:"column:encoding"`
+	Priority        MessagePriority `gorm:"column:priority"`
+	SendAt          *time.Time      `gorm:"column:send_at"`
+	ExpiresAt       *time.Time      `gorm:"column:expires_at"`
+	Status          MessageStatus   `gorm:"column:status"`
+	AttemptCount    int             `gorm:"column:attempt_count"`
+	LastAttemptAt   *time.Time      `gorm:"column:last_attempt_at"`
+	Provider        *string         `gorm:"column:provider"`
+	ProviderMsgID   *string         `gorm:"column:provider_msg_id"`
+	ReportedAt      *time.Time      `gorm:"column:reported_at"`
+	CreatedAt       time.Time       `gorm:"column:created_at"`
+	UpdatedAt       time.Time       `gorm:"column:updated_at"`
 }
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Suppression stops messages to an MSISDN. Entries without an AccountID are global and apply to every account.
+type Suppression struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement;<-:create"`
+	AccountID *int64    `gorm:"null;<-:create"`
+	MSISDN    string    `gorm:"column:msisdn;type:varchar(20);not null;<-:create"`
+	Reason    string    `gorm:"type:varchar(255);not null;<-:create"`
+	AddedBy   string    `gorm:"type:varchar(255);not null;<-:create"`
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+type Template struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement;<-:create"`
+	AccountID      int64      `gorm:"not null;<-:create"`
+	Name           string     `gorm:"type:varchar(255);not null"`
+	CurrentVersion int        `gorm:"not null"`
+	DeletedAt      *time.Time `gorm:"type:timestamp;null"`
+	CreatedAt      time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt      time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+type TemplateVersion struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement;<-:create"`
+	TemplateID int64     `gorm:"not null;uniqueIndex:idx_template_versions_version;<-:create"`
+	Version    int       `gorm:"not null;uniqueIndex:idx_template_versions_version;<-:create"`
+	Body       string    `gorm:"type:text;not null;<-:create"`
+	CreatedAt  time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
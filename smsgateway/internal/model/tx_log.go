@@ -10,17 +10,25 @@ const (
 	TxLogStateFailed   = "FAILED"
 )
 
+// TxLog is also the outbox the publishers drain. ClaimedBy and ClaimedUntil record which publisher instance is
+// working on an unpublished log and until when; another instance may take it over once the claim has expired.
+// Priority and ReadyAt copy the message's lane and the time it may be sent, so the outbox index alone orders the
+// logs publishers claim.
 type TxLog struct {
-	ID          int64      `gorm:"primaryKey;autoIncrement;<-:create"`
-	MessageID   int64      `gorm:"not null;<-:create"`
-	FromMSISDN  string     `gorm:"type:varchar(255);not null"`
-	Amount      int        `gorm:"default:1;not null"`
-	State       string     `gorm:"type:enum('CREATED','PENDING','SUCCESS','REFUNDED','FAILED');not null"`
-	Published   bool       `gorm:"default:false;not null"`
-	PublishedAt *time.Time `gorm:"type:timestamp;null"`
-	LastError   *string    `gorm:"type:text;null"`
-	CreatedAt   time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
-	UpdatedAt   time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+	ID           int64           `gorm:"primaryKey;autoIncrement;<-:create"`
+	MessageID    int64           `gorm:"not null;<-:create"`
+	FromMSISDN   string          `gorm:"type:varchar(255);not null"`
+	Amount       int             `gorm:"default:1;not null"`
+	Priority     MessagePriority `gorm:"type:enum('otp','transactional','bulk');default:transactional;not null;<-:create"`
+	ReadyAt      time.Time       `gorm:"type:timestamp;not null;<-:create"`
+	State        string          `gorm:"type:enum('CREATED','PENDING','SUCCESS','REFUNDED','FAILED');not null"`
+	Published    bool            `gorm:"default:false;not null"`
+	PublishedAt  *time.Time      `gorm:"type:timestamp;null"`
+	ClaimedBy    *string         `gorm:"type:varchar(255);null"`
+	ClaimedUntil *time.Time      `gorm:"type:timestamp;null"`
+	LastError    *string         `gorm:"type:text;null"`
+	CreatedAt    time.Time       `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt    time.Time       `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
 
 	Message Message `gorm:"foreignKey:MessageID"`
 }
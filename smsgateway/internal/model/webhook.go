@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+const (
+	WebhookEventSubmitted  = "submitted"
+	WebhookEventFailedPerm = "failed_perm"
+	WebhookEventRefunded   = "refunded"
+	WebhookEventDelivered  = "delivered"
+)
+
+const (
+	WebhookEventStatePending   = "PENDING"
+	WebhookEventStateDelivered = "DELIVERED"
+	WebhookEventStateFailed    = "FAILED"
+)
+
+type Webhook struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement;<-:create"`
+	UserID    string    `gorm:"type:varchar(255);uniqueIndex;not null"`
+	URL       string    `gorm:"type:varchar(2048);not null"`
+	Secret    string    `gorm:"type:varchar(255);not null"`
+	Enabled   bool      `gorm:"default:true;not null"`
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+// WebhookEvent is the outbox the webhook worker drains. ClaimedBy and ClaimedUntil record which worker instance is
+// delivering a due event and until when, as they do for tx_logs.
+type WebhookEvent struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement;<-:create"`
+	WebhookID     int64      `gorm:"not null;<-:create"`
+	MessageID     int64      `gorm:"not null;<-:create"`
+	EventType     string     `gorm:"type:varchar(64);not null"`
+	Payload       string     `gorm:"type:json;not null"`
+	State         string     `gorm:"type:enum('PENDING','DELIVERED','FAILED');not null"`
+	AttemptCount  int        `gorm:"default:0;not null"`
+	NextAttemptAt time.Time  `gorm:"type:timestamp;not null"`
+	LastError     *string    `gorm:"type:text;null"`
+	DeliveredAt   *time.Time `gorm:"type:timestamp;null"`
+	ClaimedBy     *string    `gorm:"type:varchar(255);null"`
+	ClaimedUntil  *time.Time `gorm:"type:timestamp;null"`
+	CreatedAt     time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+
+	Webhook Webhook `gorm:"foreignKey:WebhookID"`
+}
+
+type WebhookAttempt struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement;<-:create"`
+	EventID    int64     `gorm:"not null"`
+	Attempt    int       `gorm:"not null"`
+	StatusCode *int      `gorm:"null"`
+	Error      *string   `gorm:"type:text;null"`
+	DurationMS int64     `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
@@ -0,0 +1,81 @@
+package publishers
+
+import (
+	"context"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBatchSize       = 100
+	defaultPollInterval    = 30 * time.Second
+	defaultMinPollInterval = 250 * time.Millisecond
+)
+
+// Publisher claims up to limit outbox logs, publishes them and returns how many it claimed. It also returns the
+// broker's error if any of them could not be published, so that Run backs off instead of claiming more.
+type Publisher interface {
+	Publish(ctx context.Context, limit int) (int, error)
+}
+
+// Run drives publisher until ctx is done. A full batch means there is a backlog, so the next one is claimed straight
+// away; otherwise the wait between polls doubles from MinPollInterval up to PollInterval while nothing turns up. A
+// signal on wake cuts the wait short. Polling stays on regardless, so a lost wake only costs latency.
+func Run(ctx context.Context, publisher Publisher, cfg config.Outbox, wake <-chan struct{}, logger *zap.Logger) {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	longest := cfg.PollInterval
+	if longest <= 0 {
+		longest = defaultPollInterval
+	}
+
+	shortest := cfg.MinPollInterval
+	if shortest <= 0 {
+		shortest = defaultMinPollInterval
+	}
+	shortest = min(shortest, longest)
+
+	idle := shortest
+	for ctx.Err() == nil {
+		claimed, err := publisher.Publish(ctx, batchSize)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("failed to publish outbox batch", zap.Error(err))
+		}
+
+		wait := idle
+		switch {
+		case err == nil && claimed >= batchSize:
+			idle = shortest
+			continue
+		case err == nil && claimed > 0:
+			idle, wait = shortest, shortest
+		default:
+			idle = min(idle*2, longest)
+		}
+
+		if woken := pause(ctx, wait, wake); woken {
+			idle = shortest
+		}
+	}
+
+	logger.Info("publisher context cancelled")
+}
+
+// pause waits for d, a wake signal or ctx, and reports whether it was woken.
+func pause(ctx context.Context, d time.Duration, wake <-chan struct{}) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-wake:
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package publishers_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/publishers"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakePublisher struct {
+	mu     sync.Mutex
+	calls  int
+	limits []int
+	result func(call int) (int, error)
+}
+
+func (f *fakePublisher) Publish(_ context.Context, limit int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	f.limits = append(f.limits, limit)
+	return f.result(f.calls)
+}
+
+func (f *fakePublisher) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *fakePublisher) Limits() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.limits
+}
+
+func runLoop(t *testing.T, publisher publishers.Publisher, cfg config.Outbox, wake <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		publishers.Run(ctx, publisher, cfg, wake, zap.NewNop())
+		close(done)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestRun(t *testing.T) {
+	slow := config.Outbox{BatchSize: 2, PollInterval: time.Hour, MinPollInterval: time.Hour}
+
+	t.Run("drains a backlog without waiting", func(t *testing.T) {
+		publisher := &fakePublisher{result: func(call int) (int, error) {
+			if call <= 3 {
+				return 2, nil
+			}
+			return 1, nil
+		}}
+
+		runLoop(t, publisher, slow, nil)
+
+		assert.Eventually(t, func() bool { return publisher.Calls() == 4 }, time.Second, time.Millisecond)
+		assert.Never(t, func() bool { return publisher.Calls() > 4 }, 50*time.Millisecond, time.Millisecond)
+		assert.Equal(t, []int{2, 2, 2, 2}, publisher.Limits())
+	})
+
+	t.Run("polls straight away when woken", func(t *testing.T) {
+		publisher := &fakePublisher{result: func(int) (int, error) { return 0, nil }}
+		wake := make(chan struct{}, 1)
+
+		runLoop(t, publisher, slow, wake)
+
+		assert.Eventually(t, func() bool { return publisher.Calls() == 1 }, time.Second, time.Millisecond)
+		wake <- struct{}{}
+		assert.Eventually(t, func() bool { return publisher.Calls() == 2 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("keeps polling while idle", func(t *testing.T) {
+		publisher := &fakePublisher{result: func(int) (int, error) { return 0, nil }}
+
+		runLoop(t, publisher, config.Outbox{PollInterval: 5 * time.Millisecond, MinPollInterval: time.Millisecond},
+			nil)
+
+		assert.Eventually(t, func() bool { return publisher.Calls() >= 5 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("does not treat a failed full batch as a backlog", func(t *testing.T) {
+		publisher := &fakePublisher{result: func(int) (int, error) { return 2, errors.New("broker down") }}
+
+		runLoop(t, publisher, slow, nil)
+
+		assert.Eventually(t, func() bool { return publisher.Calls() == 1 }, time.Second, time.Millisecond)
+		assert.Never(t, func() bool { return publisher.Calls() > 1 }, 50*time.Millisecond, time.Millisecond)
+	})
+
+	t.Run("uses the default batch size", func(t *testing.T) {
+		publisher := &fakePublisher{result: func(int) (int, error) { return 0, nil }}
+
+		runLoop(t, publisher, config.Outbox{PollInterval: time.Hour}, nil)
+
+		assert.Eventually(t, func() bool { return publisher.Calls() == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, []int{100}, publisher.Limits())
+	})
+}
@@ -10,7 +10,7 @@ import (
 )
 
 type RefundPublisher interface {
-	Publish(ctx context.Context) error
+	Publisher
 }
 
 type refundPublisher struct {
@@ -23,25 +23,28 @@ func NewRefundPublisher(service service.MessageQueueService, publisher mq.Publis
 	return &refundPublisher{service: service, publisher: publisher, logger: logger}
 }
 
-func (r *refundPublisher) Publish(ctx context.Context) error {
-	refundRequests, err := r.service.FindRefundsToQueue(ctx, 100)
+func (r *refundPublisher) Publish(ctx context.Context, limit int) (int, error) {
+	refundRequests, err := r.service.FindRefundsToQueue(ctx, limit)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if len(refundRequests) == 0 {
-		return nil
+		return 0, nil
 	}
 
 	r.logger.Info("Publishing refunds", zap.Int("count", len(refundRequests)))
 
+	var publishErr error
 	successCount := 0
 	for _, refundRequest := range refundRequests {
 		body, _ := json.Marshal(refundRequest)
-		if err := r.publisher.Publish(ctx, "", "sms.refund", body); err != nil {
+		if err := r.publisher.Publish(ctx, "", service.RefundQueue, body); err != nil {
 			r.logger.Error("Failed to publish refund",
 				zap.Error(err),
 				zap.Int64("txLogID", refundRequest.TxLogID))
+			r.service.ReleaseRefund(ctx, refundRequest.TxLogID)
+			publishErr = err
 			continue
 		}
 
@@ -58,5 +61,5 @@ func (r *refundPublisher) Publish(ctx context.Context) error {
 			zap.Int("total", len(refundRequests)))
 	}
 
-	return nil
+	return len(refundRequests), publishErr
 }
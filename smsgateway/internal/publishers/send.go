@@ -3,6 +3,7 @@ package publishers
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/Behyna/common/pkg/mq"
 	"github.com/Behyna/sms-services/smsgateway/internal/service"
@@ -10,7 +11,7 @@ import (
 )
 
 type SendPublisher interface {
-	Publish(ctx context.Context) error
+	Publisher
 }
 
 type sendPublisher struct {
@@ -23,25 +24,39 @@ func NewSendPublisher(service service.MessageQueueService, publisher mq.Publishe
 	return &sendPublisher{service: service, publisher: publisher, logger: logger}
 }
 
-func (s *sendPublisher) Publish(ctx context.Context) error {
-	messages, err := s.service.FindMessagesToQueue(ctx, 100)
+func (s *sendPublisher) Publish(ctx context.Context, limit int) (int, error) {
+	messages, err := s.service.FindMessagesToQueue(ctx, limit)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if len(messages) == 0 {
-		return nil
+		return 0, nil
 	}
 
 	s.logger.Info("Publishing messages", zap.Int("count", len(messages)))
 
+	var publishErr error
 	successCount := 0
 	for _, message := range messages {
+		if message.ExpiresAt != nil && time.Now().After(*message.ExpiresAt) {
+			if err := s.service.MarkMessageAsExpired(ctx, message.MessageID); err != nil {
+				s.logger.Error("Failed to expire message",
+					zap.Error(err),
+					zap.Int64("messageID", message.MessageID))
+				s.service.ReleaseMessage(ctx, message.MessageID)
+				publishErr = err
+			}
+			continue
+		}
+
 		body, _ := json.Marshal(message)
-		if err := s.publisher.Publish(ctx, "", "sms.send", body); err != nil {
+		if err := s.publisher.Publish(ctx, "", service.SendQueue(message.Priority), body); err != nil {
 			s.logger.Error("Failed to publish message",
 				zap.Error(err),
 				zap.Int64("messageID", message.MessageID))
+			s.service.ReleaseMessage(ctx, message.MessageID)
+			publishErr = err
 			continue
 		}
 
@@ -58,5 +73,5 @@ func (s *sendPublisher) Publish(ctx context.Context) error {
 			zap.Int("total", len(messages)))
 	}
 
-	return nil
+	return len(messages), publishErr
 }
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+var ErrAccountNotFound = errors.New("ACCOUNT_NOT_FOUND")
+var ErrAPIKeyNotFound = errors.New("API_KEY_NOT_FOUND")
+var ErrSenderTaken = errors.New("SENDER_TAKEN")
+
+type AccountRepository interface {
+	Create(ctx context.Context, account *model.Account) error
+	GetByID(id int64) (*model.Account, error)
+	GetBySender(msisdns []string) (*model.Account, error)
+	CreateAPIKey(ctx context.Context, key *model.APIKey) error
+	RevokeAPIKey(ctx context.Context, id int64, revokedAt time.Time) error
+	GetAPIKeyByHash(keyHash string) (*model.APIKey, error)
+}
+
+type Account struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) AccountRepository {
+	return &Account{db: db}
+}
+
+// Create inserts the account together with its senders. A sender already owned by another account fails the
+// whole insert with ErrSenderTaken.
+func (a *Account) Create(ctx context.Context, account *model.Account) error {
+	db := GetTx(ctx, a.db)
+	err := db.Create(account).Error
+	if err == nil {
+		return nil
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return ErrSenderTaken
+	}
+
+	return err
+}
+
+func (a *Account) GetByID(id int64) (*model.Account, error) {
+	var account model.Account
+
+	err := a.db.Preload("Senders").First(&account, id).Error
+	if err == nil {
+		return &account, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAccountNotFound
+	}
+
+	return nil, err
+}
+
+// GetBySender returns the account that owns any of msisdns, which are alternative spellings of one number.
+func (a *Account) GetBySender(msisdns []string) (*model.Account, error) {
+	var account model.Account
+
+	err := a.db.Joins("JOIN account_senders ON account_senders.account_id = accounts.id").
+		Where("account_senders.msisdn IN ?", msisdns).
+		First(&account).Error
+	if err == nil {
+		return &account, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAccountNotFound
+	}
+
+	return nil, err
+}
+
+func (a *Account) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	db := GetTx(ctx, a.db)
+	return db.Omit("Account").Create(key).Error
+}
+
+func (a *Account) RevokeAPIKey(ctx context.Context, id int64, revokedAt time.Time) error {
+	db := GetTx(ctx, a.db)
+	result := db.Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// GetAPIKeyByHash returns the key with its account and senders. Revoked keys are returned too; callers decide.
+func (a *Account) GetAPIKeyByHash(keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+
+	err := a.db.Preload("Account.Senders").Where("key_hash = ?", keyHash).First(&key).Error
+	if err == nil {
+		return &key, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	return nil, err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+var ErrInboundDuplicate = errors.New("INBOUND_DUPLICATE")
+
+// InboundFilter narrows an account's inbound messages. An empty ToMSISDN matches every receiving number.
+type InboundFilter struct {
+	AccountID int64
+	ToMSISDN  string
+}
+
+type InboundMessageRepository interface {
+	Create(ctx context.Context, msg *model.InboundMessage) error
+	FindByFilter(filter InboundFilter, beforeID int64, limit int) ([]model.InboundMessage, error)
+}
+
+type InboundMessage struct {
+	db *gorm.DB
+}
+
+func NewInboundMessageRepository(db *gorm.DB) InboundMessageRepository {
+	return &InboundMessage{db: db}
+}
+
+// Create returns ErrInboundDuplicate when the provider already pushed a message with the same provider message id.
+func (i *InboundMessage) Create(ctx context.Context, msg *model.InboundMessage) error {
+	db := GetTx(ctx, i.db)
+
+	err := db.Create(msg).Error
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return ErrInboundDuplicate
+	}
+
+	return err
+}
+
+// FindByFilter returns messages newest first. A zero beforeID starts from the newest message.
+func (i *InboundMessage) FindByFilter(filter InboundFilter, beforeID int64, limit int) (
+	[]model.InboundMessage, error) {
+	var messages []model.InboundMessage
+
+	query := i.db.Where("account_id = ?", filter.AccountID)
+	if filter.ToMSISDN != "" {
+		query = query.Where("to_msisdn = ?", filter.ToMSISDN)
+	}
+
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+
+	err := query.Order("id DESC").Limit(limit).Find(&messages).Error
+
+	return messages, err
+}
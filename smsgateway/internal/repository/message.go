@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/Behyna/sms-services/smsgateway/internal/model"
@@ -14,13 +15,40 @@ var ErrMessageNotFound = errors.New("MESSAGE_NOT_FOUND")
 var ErrMessageDuplicate = errors.New("MESSAGE_DUPLICATE")
 var ErrNoRowsAffected = errors.New("NO_ROWS_AFFECTED")
 
+const batchInsertSize = 500
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// MessageFilter narrows a sender's history. FromMSISDN is required; zero-valued fields are ignored.
+type MessageFilter struct {
+	FromMSISDN            string
+	Status                string
+	ToMSISDN              string
+	CreatedFrom           *time.Time
+	CreatedTo             *time.Time
+	ClientMessageIDPrefix string
+}
+
+// MessageCursor is the (created_at, id) position of the last row of the previous page.
+type MessageCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
 type MessageRepository interface {
 	Create(ctx context.Context, message *model.Message) error
+	CreateBatch(ctx context.Context, messages []model.Message) error
 	Update(ctx context.Context, message *model.Message) error
 	UpdateForSending(ctx context.Context, message *model.Message, staleThreshold time.Time) error
+	UpdateDeliveryStatus(ctx context.Context, message *model.Message) error
+	UpdateForCancel(ctx context.Context, message *model.Message) error
+	UpdateForExpiry(ctx context.Context, message *model.Message) error
 	GetByID(id int64) (*model.Message, error)
-	GetByUserID(userID string, limit, offset int) ([]model.Message, error)
-	CountByUserID(userID string) (int, error)
+	GetByProviderMsgID(provider, providerMsgID string) (*model.Message, error)
+	GetByClientMessageID(fromMSISDN, clientMessageID string) (*model.Message, error)
+	GetByClientMessageIDs(fromMSISDN string, clientMessageIDs []string) ([]model.Message, error)
+	FindByFilter(filter MessageFilter, cursor *MessageCursor, limit int) ([]model.Message, error)
+	CountByFilter(filter MessageFilter) (int64, error)
 }
 
 type Message struct {
@@ -46,6 +74,21 @@ func (m *Message) Create(ctx context.Context, message *model.Message) error {
 	return err
 }
 
+func (m *Message) CreateBatch(ctx context.Context, messages []model.Message) error {
+	db := GetTx(ctx, m.db)
+	err := db.CreateInBatches(messages, batchInsertSize).Error
+	if err == nil {
+		return nil
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return ErrMessageDuplicate
+	}
+
+	return err
+}
+
 func (m *Message) Update(ctx context.Context, message *model.Message) error {
 	db := GetTx(ctx, m.db)
 	return db.Model(message).Where("ID = ?", message.ID).Updates(message).Error
@@ -67,6 +110,55 @@ func (m *Message) UpdateForSending(ctx context.Context, message *model.Message,
 	return result.Error
 }
 
+func (m *Message) UpdateDeliveryStatus(ctx context.Context, message *model.Message) error {
+	db := GetTx(ctx, m.db)
+	result := db.Model(message).Where("ID = ? AND status = ?", message.ID, model.MessageStatusSubmitted).
+		Updates(message)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNoRowsAffected
+	}
+
+	return nil
+}
+
+func (m *Message) UpdateForCancel(ctx context.Context, message *model.Message) error {
+	db := GetTx(ctx, m.db)
+	result := db.Model(message).Where("ID = ? AND status = ?", message.ID, model.MessageStatusCreated).
+		Updates(message)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNoRowsAffected
+	}
+
+	return nil
+}
+
+// UpdateForExpiry only touches messages that have not reached the provider yet.
+func (m *Message) UpdateForExpiry(ctx context.Context, message *model.Message) error {
+	db := GetTx(ctx, m.db)
+	result := db.Model(message).Where("ID = ? AND status IN (?, ?, ?)", message.ID,
+		model.MessageStatusCreated, model.MessageStatusSending, model.MessageStatusFailedTemp).Updates(message)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNoRowsAffected
+	}
+
+	return nil
+}
+
 func (m *Message) GetByID(id int64) (*model.Message, error) {
 	var message model.Message
 
@@ -82,13 +174,43 @@ func (m *Message) GetByID(id int64) (*model.Message, error) {
 	return nil, err
 }
 
-func (m *Message) GetByUserID(userID string, limit, offset int) ([]model.Message, error) {
+// GetByProviderMsgID finds the message a provider acknowledged with providerMsgID. Ids are only unique per provider,
+// so the lookup is scoped to the provider that sent it.
+func (m *Message) GetByProviderMsgID(provider, providerMsgID string) (*model.Message, error) {
+	var message model.Message
+
+	err := m.db.Where("provider = ? AND provider_msg_id = ?", provider, providerMsgID).First(&message).Error
+	if err == nil {
+		return &message, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMessageNotFound
+	}
+
+	return nil, err
+}
+
+func (m *Message) GetByClientMessageID(fromMSISDN, clientMessageID string) (*model.Message, error) {
+	var message model.Message
+
+	err := m.db.Where("from_msisdn = ? AND client_message_id = ?", fromMSISDN, clientMessageID).
+		First(&message).Error
+	if err == nil {
+		return &message, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMessageNotFound
+	}
+
+	return nil, err
+}
+
+func (m *Message) GetByClientMessageIDs(fromMSISDN string, clientMessageIDs []string) ([]model.Message, error) {
 	var messages []model.Message
 
-	err := m.db.Where("from_msisdn = ?", userID).
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
+	err := m.db.Where("from_msisdn = ? AND client_message_id IN ?", fromMSISDN, clientMessageIDs).
 		Find(&messages).Error
 	if err != nil {
 		return nil, err
@@ -97,16 +219,56 @@ func (m *Message) GetByUserID(userID string, limit, offset int) ([]model.Message
 	return messages, nil
 }
 
-func (m *Message) CountByUserID(userID string) (int, error) {
-	var count int64
+func (m *Message) FindByFilter(filter MessageFilter, cursor *MessageCursor, limit int) ([]model.Message, error) {
+	var messages []model.Message
 
-	err := m.db.Model(&model.Message{}).
-		Where("from_msisdn = ?", userID).
-		Count(&count).Error
+	query := applyMessageFilter(m.db, filter)
+	if cursor != nil {
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)",
+			cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
 
+	err := query.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (m *Message) CountByFilter(filter MessageFilter) (int64, error) {
+	var count int64
+
+	err := applyMessageFilter(m.db.Model(&model.Message{}), filter).Count(&count).Error
 	if err != nil {
 		return 0, err
 	}
 
-	return int(count), nil
+	return count, nil
+}
+
+func applyMessageFilter(db *gorm.DB, filter MessageFilter) *gorm.DB {
+	query := db.Where("from_msisdn = ?", filter.FromMSISDN)
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.ToMSISDN != "" {
+		query = query.Where("to_msisdn = ?", filter.ToMSISDN)
+	}
+
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+
+	if filter.ClientMessageIDPrefix != "" {
+		query = query.Where("client_message_id LIKE ?", likeEscaper.Replace(filter.ClientMessageIDPrefix)+"%")
+	}
+
+	return query
 }
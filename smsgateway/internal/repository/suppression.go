@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const suppressionImportBatchSize = 500
+
+var (
+	ErrSuppressionExists   = errors.New("SUPPRESSION_EXISTS")
+	ErrSuppressionNotFound = errors.New("SUPPRESSION_NOT_FOUND")
+)
+
+// SuppressionRepository scopes entries by account id, where a nil account id selects the global list.
+type SuppressionRepository interface {
+	Create(ctx context.Context, suppression *model.Suppression) error
+	CreateBatch(ctx context.Context, suppressions []model.Suppression) (int64, error)
+	Delete(ctx context.Context, accountID *int64, msisdn string) error
+	FindByMSISDNs(accountID int64, msisdns []string) ([]model.Suppression, error)
+	ListByAccount(accountID *int64, afterID int64, limit int) ([]model.Suppression, error)
+}
+
+type Suppression struct {
+	db *gorm.DB
+}
+
+func NewSuppressionRepository(db *gorm.DB) SuppressionRepository {
+	return &Suppression{db: db}
+}
+
+func (s *Suppression) Create(ctx context.Context, suppression *model.Suppression) error {
+	db := GetTx(ctx, s.db)
+
+	err := db.Create(suppression).Error
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return ErrSuppressionExists
+	}
+
+	return err
+}
+
+// CreateBatch inserts the entries that are not on the list yet and returns how many were added.
+func (s *Suppression) CreateBatch(ctx context.Context, suppressions []model.Suppression) (int64, error) {
+	db := GetTx(ctx, s.db)
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(suppressions, suppressionImportBatchSize)
+
+	return result.RowsAffected, result.Error
+}
+
+func (s *Suppression) Delete(ctx context.Context, accountID *int64, msisdn string) error {
+	db := GetTx(ctx, s.db)
+
+	result := db.Where("msisdn = ? AND account_key = ?", msisdn, accountKey(accountID)).
+		Delete(&model.Suppression{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrSuppressionNotFound
+	}
+
+	return nil
+}
+
+// FindByMSISDNs returns the account's own entries and the global entries for the given numbers.
+func (s *Suppression) FindByMSISDNs(accountID int64, msisdns []string) ([]model.Suppression, error) {
+	var suppressions []model.Suppression
+
+	err := s.db.Where("msisdn IN ? AND account_key IN ?", msisdns, []int64{0, accountID}).
+		Order("id ASC").
+		Find(&suppressions).Error
+
+	return suppressions, err
+}
+
+func (s *Suppression) ListByAccount(accountID *int64, afterID int64, limit int) ([]model.Suppression, error) {
+	var suppressions []model.Suppression
+
+	err := s.db.Where("account_key = ? AND id > ?", accountKey(accountID), afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&suppressions).Error
+
+	return suppressions, err
+}
+
+func accountKey(accountID *int64) int64 {
+	if accountID == nil {
+		return 0
+	}
+	return *accountID
+}
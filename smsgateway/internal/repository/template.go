@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTemplateNotFound = errors.New("TEMPLATE_NOT_FOUND")
+
+type TemplateRepository interface {
+	Create(ctx context.Context, template *model.Template) error
+	Update(ctx context.Context, template *model.Template) error
+	Delete(ctx context.Context, accountID, id int64, deletedAt time.Time) error
+	GetByID(accountID, id int64) (*model.Template, error)
+	GetByIDForUpdate(ctx context.Context, accountID, id int64) (*model.Template, error)
+	ListByAccount(accountID int64) ([]model.Template, error)
+	CreateVersion(ctx context.Context, version *model.TemplateVersion) error
+	GetVersion(templateID int64, version int) (*model.TemplateVersion, error)
+}
+
+type Template struct {
+	db *gorm.DB
+}
+
+func NewTemplateRepository(db *gorm.DB) TemplateRepository {
+	return &Template{db: db}
+}
+
+func (t *Template) Create(ctx context.Context, template *model.Template) error {
+	db := GetTx(ctx, t.db)
+	return db.Create(template).Error
+}
+
+func (t *Template) Update(ctx context.Context, template *model.Template) error {
+	db := GetTx(ctx, t.db)
+	return db.Model(&model.Template{}).
+		Where("id = ? AND deleted_at IS NULL", template.ID).
+		Select("name", "current_version", "updated_at").
+		Updates(template).Error
+}
+
+func (t *Template) Delete(ctx context.Context, accountID, id int64, deletedAt time.Time) error {
+	db := GetTx(ctx, t.db)
+	result := db.Model(&model.Template{}).
+		Where("id = ? AND account_id = ? AND deleted_at IS NULL", id, accountID).
+		Update("deleted_at", deletedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrTemplateNotFound
+	}
+
+	return nil
+}
+
+func (t *Template) GetByID(accountID, id int64) (*model.Template, error) {
+	return t.getByID(t.db, accountID, id)
+}
+
+// GetByIDForUpdate locks the template row until the surrounding transaction ends, serialising version bumps.
+func (t *Template) GetByIDForUpdate(ctx context.Context, accountID, id int64) (*model.Template, error) {
+	db := GetTx(ctx, t.db)
+	return t.getByID(db.Clauses(clause.Locking{Strength: "UPDATE"}), accountID, id)
+}
+
+func (t *Template) getByID(db *gorm.DB, accountID, id int64) (*model.Template, error) {
+	var template model.Template
+
+	err := db.Where("id = ? AND account_id = ? AND deleted_at IS NULL", id, accountID).First(&template).Error
+	if err == nil {
+		return &template, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTemplateNotFound
+	}
+
+	return nil, err
+}
+
+func (t *Template) ListByAccount(accountID int64) ([]model.Template, error) {
+	var templates []model.Template
+
+	err := t.db.Where("account_id = ? AND deleted_at IS NULL", accountID).
+		Order("name ASC, id ASC").
+		Find(&templates).Error
+
+	return templates, err
+}
+
+func (t *Template) CreateVersion(ctx context.Context, version *model.TemplateVersion) error {
+	db := GetTx(ctx, t.db)
+	return db.Create(version).Error
+}
+
+func (t *Template) GetVersion(templateID int64, version int) (*model.TemplateVersion, error) {
+	var templateVersion model.TemplateVersion
+
+	err := t.db.Where("template_id = ? AND version = ?", templateID, version).First(&templateVersion).Error
+	if err == nil {
+		return &templateVersion, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTemplateNotFound
+	}
+
+	return nil, err
+}
@@ -3,21 +3,41 @@ package repository
 import (
 	"context"
 	"errors"
+	"slices"
+	"time"
 
 	"github.com/Behyna/sms-services/smsgateway/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var ErrTxLogNotFound = errors.New("TXLOG_NOT_FOUND")
 
+// claimable matches logs no publisher holds a live claim on. Leases are compared against the database clock so that
+// instances with skewed clocks agree on when a claim has expired.
+const claimable = "tx_logs.claimed_until IS NULL OR tx_logs.claimed_until <= NOW()"
+
+// Claim identifies the publisher instance taking a batch of outbox logs and how long it may hold them before another
+// instance can take them over. Lease should comfortably exceed the time it takes to publish a batch.
+type Claim struct {
+	Owner string
+	Lease time.Duration
+}
+
 type TxLogRepository interface {
 	Create(ctx context.Context, log *model.TxLog) error
+	CreateBatch(ctx context.Context, logs []model.TxLog) error
 	Update(log *model.TxLog) error
 	UpdateByMessageID(ctx context.Context, log *model.TxLog) error
 	UpdateForPermFailed(ctx context.Context, log *model.TxLog) error
-	FindUnpublishedFailed(limit int) ([]model.TxLog, error)
-	FindUnpublishedCreated(limit int) ([]model.TxLog, error)
+	UpdateForPublished(ctx context.Context, log *model.TxLog, owner string) error
+	UpdateRefundForPublished(ctx context.Context, log *model.TxLog, owner string) error
+	ReleaseClaim(ctx context.Context, log *model.TxLog, owner string) error
+	ClaimUnpublishedFailed(claim Claim, limit int) ([]model.TxLog, error)
+	UpdateForCancel(ctx context.Context, log *model.TxLog) error
+	ClaimUnpublishedCreated(claim Claim, now time.Time, limit int) ([]model.TxLog, error)
 	GetByID(id int64) (*model.TxLog, error)
+	GetByMessageID(messageID int64) (*model.TxLog, error)
 }
 
 type TxLog struct {
@@ -37,6 +57,11 @@ func (r *TxLog) Create(ctx context.Context, log *model.TxLog) error {
 	return nil
 }
 
+func (r *TxLog) CreateBatch(ctx context.Context, logs []model.TxLog) error {
+	db := GetTx(ctx, r.db)
+	return db.CreateInBatches(logs, batchInsertSize).Error
+}
+
 func (r *TxLog) Update(log *model.TxLog) error {
 	return r.db.Model(log).Where("id = ?", log.ID).Updates(log).Error
 }
@@ -46,31 +71,158 @@ func (r *TxLog) UpdateByMessageID(ctx context.Context, log *model.TxLog) error {
 	return db.Model(log).Where("message_id = ?", log.MessageID).Updates(log).Error
 }
 
+// UpdateForPermFailed fails a log and releases any publisher claim on it, handing it to the refund publisher.
 func (r *TxLog) UpdateForPermFailed(ctx context.Context, log *model.TxLog) error {
 	db := GetTx(ctx, r.db)
 	return db.Model(log).Where("message_id = ?", log.MessageID).
-		Select("state", "published", "published_at", "last_error", "updated_at").Updates(log).Error
+		Select("state", "published", "published_at", "claimed_by", "claimed_until", "last_error", "updated_at").
+		Updates(log).Error
+}
+
+// UpdateForPublished marks a log owner still holds as published and releases the claim, so that a later refund of
+// the same log can be claimed straight away. It returns ErrNoRowsAffected when the claim was lost, because the lease
+// ran out and another instance took the log over.
+func (r *TxLog) UpdateForPublished(ctx context.Context, log *model.TxLog, owner string) error {
+	db := GetTx(ctx, r.db)
+	result := db.Model(log).Where("message_id = ? AND claimed_by = ?", log.MessageID, owner).
+		Select("state", "published", "published_at", "claimed_by", "claimed_until", "updated_at").Updates(log)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNoRowsAffected
+	}
+
+	return nil
+}
+
+// UpdateRefundForPublished marks a failed log owner still holds as having its refund published and releases the
+// claim. Unlike UpdateForPublished it matches the log by ID and leaves its state alone. It returns ErrNoRowsAffected
+// when the claim was lost.
+func (r *TxLog) UpdateRefundForPublished(ctx context.Context, log *model.TxLog, owner string) error {
+	db := GetTx(ctx, r.db)
+	result := db.Model(log).Where("id = ? AND claimed_by = ?", log.ID, owner).
+		Select("published", "published_at", "claimed_by", "claimed_until", "updated_at").Updates(log)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNoRowsAffected
+	}
+
+	return nil
+}
+
+// ReleaseClaim drops owner's claim on a log it could not publish, so the next poll retries it instead of waiting for
+// the lease to run out. The log is matched by ID, or by MessageID when ID is unset.
+func (r *TxLog) ReleaseClaim(ctx context.Context, log *model.TxLog, owner string) error {
+	db := GetTx(ctx, r.db).Model(&model.TxLog{}).Where("claimed_by = ?", owner)
+	if log.ID != 0 {
+		db = db.Where("id = ?", log.ID)
+	} else {
+		db = db.Where("message_id = ?", log.MessageID)
+	}
+
+	return db.Updates(map[string]any{"claimed_by": nil, "claimed_until": nil}).Error
+}
+
+// ClaimUnpublishedFailed claims failed logs whose refund has not been published yet.
+func (r *TxLog) ClaimUnpublishedFailed(claim Claim, limit int) ([]model.TxLog, error) {
+	return r.claim(claim, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("tx_logs.state = ? AND tx_logs.published = ?", model.TxLogStateFailed, false).
+			Order("tx_logs.id ASC")
+	}, limit)
 }
 
-func (r *TxLog) FindUnpublishedFailed(limit int) ([]model.TxLog, error) {
+// UpdateForCancel claims an unpublished CREATED log for cancellation, returning ErrNoRowsAffected once a publisher
+// has already picked it up.
+func (r *TxLog) UpdateForCancel(ctx context.Context, log *model.TxLog) error {
+	db := GetTx(ctx, r.db)
+	result := db.Model(log).
+		Where("message_id = ? AND state = ? AND published = ?", log.MessageID, model.TxLogStateCreated, false).
+		Where(claimable).
+		Select("state", "published", "last_error", "updated_at").Updates(log)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNoRowsAffected
+	}
+
+	return nil
+}
+
+// ClaimUnpublishedCreated claims logs ready to publish, skipping scheduled messages whose send time is after now. OTPs
+// come first and bulk messages last, so a large campaign cannot hold back urgent messages behind it. Each lane is
+// claimed with its own query, which walks idx_tx_logs_lane in ready_at order and stops once it has enough logs
+// instead of sorting the whole backlog.
+func (r *TxLog) ClaimUnpublishedCreated(claim Claim, now time.Time, limit int) ([]model.TxLog, error) {
 	var txLogs []model.TxLog
+	for _, priority := range model.MessagePriorities {
+		if len(txLogs) >= limit {
+			break
+		}
 
-	err := r.db.Preload("Message").Where("state = ? AND published = ?",
-		model.TxLogStateFailed, false).Limit(limit).Find(&txLogs).Error
+		claimed, err := r.claim(claim, func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("tx_logs.state = ? AND tx_logs.published = ? AND tx_logs.priority = ?",
+				model.TxLogStateCreated, false, priority).
+				Where("tx_logs.ready_at <= ?", now).
+				Order("tx_logs.ready_at ASC")
+		}, limit-len(txLogs))
+		// Logs already claimed from a more urgent lane are handed back rather than left held until their lease runs
+		// out; the next poll retries the lane that failed.
+		if err != nil && len(txLogs) == 0 {
+			return nil, err
+		}
 
-	if err != nil {
-		return nil, err
+		if err != nil {
+			return txLogs, nil
+		}
+
+		txLogs = append(txLogs, claimed...)
 	}
 
 	return txLogs, nil
 }
 
-func (r *TxLog) FindUnpublishedCreated(limit int) ([]model.TxLog, error) {
+// claim locks up to limit unclaimed logs picked by pending, skipping rows another instance has locked, and stamps
+// them with claim in the same transaction. Concurrent publishers therefore never get the same log, and the lease
+// hands a log to another instance if its claimer dies before publishing it. Logs come back in pending's order.
+func (r *TxLog) claim(claim Claim, pending func(tx *gorm.DB) *gorm.DB, limit int) ([]model.TxLog, error) {
 	var txLogs []model.TxLog
 
-	err := r.db.Preload("Message").
-		Where("state = ? AND published = ?",
-			model.TxLogStateCreated, false).Order("created_at ASC").Limit(limit).Find(&txLogs).Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []int64
+		err := pending(tx.Model(&model.TxLog{})).Where(claimable).Limit(limit).
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "tx_logs"}, Options: "SKIP LOCKED"}).
+			Pluck("tx_logs.id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		err = tx.Model(&model.TxLog{}).Where("id IN ?", ids).Updates(map[string]any{
+			"claimed_by":    claim.Owner,
+			"claimed_until": gorm.Expr("NOW() + INTERVAL ? SECOND", max(int64(claim.Lease.Seconds()), 1)),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Preload("Message").Where("id IN ?", ids).Find(&txLogs).Error; err != nil {
+			return err
+		}
+
+		slices.SortFunc(txLogs, func(a, b model.TxLog) int {
+			return slices.Index(ids, a.ID) - slices.Index(ids, b.ID)
+		})
+		return nil
+	})
 
 	if err != nil {
 		return nil, err
@@ -93,3 +245,18 @@ func (r *TxLog) GetByID(id int64) (*model.TxLog, error) {
 
 	return nil, err
 }
+
+func (r *TxLog) GetByMessageID(messageID int64) (*model.TxLog, error) {
+	var txLog model.TxLog
+
+	err := r.db.Where("message_id = ?", messageID).First(&txLog).Error
+	if err == nil {
+		return &txLog, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTxLogNotFound
+	}
+
+	return nil, err
+}
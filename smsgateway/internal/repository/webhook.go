@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrWebhookNotFound = errors.New("WEBHOOK_NOT_FOUND")
+
+type WebhookRepository interface {
+	Upsert(ctx context.Context, webhook *model.Webhook) error
+	Delete(ctx context.Context, userID string) error
+	GetByUserID(userID string) (*model.Webhook, error)
+}
+
+type Webhook struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &Webhook{db: db}
+}
+
+func (w *Webhook) Upsert(ctx context.Context, webhook *model.Webhook) error {
+	db := GetTx(ctx, w.db)
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"url", "secret", "enabled", "updated_at"}),
+	}).Create(webhook).Error
+}
+
+func (w *Webhook) Delete(ctx context.Context, userID string) error {
+	db := GetTx(ctx, w.db)
+	result := db.Where("user_id = ?", userID).Delete(&model.Webhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (w *Webhook) GetByUserID(userID string) (*model.Webhook, error) {
+	var webhook model.Webhook
+
+	err := w.db.Where("user_id = ?", userID).First(&webhook).Error
+	if err == nil {
+		return &webhook, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+
+	return nil, err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// claimableEvent matches events no worker holds a live claim on, against the database clock like claimable.
+const claimableEvent = "webhook_events.claimed_until IS NULL OR webhook_events.claimed_until <= NOW()"
+
+type WebhookEventRepository interface {
+	Create(ctx context.Context, event *model.WebhookEvent) error
+	Update(ctx context.Context, event *model.WebhookEvent, owner string) error
+	CreateAttempt(ctx context.Context, attempt *model.WebhookAttempt) error
+	ClaimDue(claim Claim, now time.Time, limit int) ([]model.WebhookEvent, error)
+}
+
+type WebhookEvent struct {
+	db *gorm.DB
+}
+
+func NewWebhookEventRepository(db *gorm.DB) WebhookEventRepository {
+	return &WebhookEvent{db: db}
+}
+
+func (r *WebhookEvent) Create(ctx context.Context, event *model.WebhookEvent) error {
+	db := GetTx(ctx, r.db)
+	return db.Create(event).Error
+}
+
+// Update records the outcome of an attempt on an event owner still holds and releases the claim. It returns
+// ErrNoRowsAffected when the claim was lost, because the lease ran out and another instance took the event over.
+func (r *WebhookEvent) Update(ctx context.Context, event *model.WebhookEvent, owner string) error {
+	event.ClaimedBy = nil
+	event.ClaimedUntil = nil
+
+	db := GetTx(ctx, r.db)
+	result := db.Model(event).Where("id = ? AND claimed_by = ?", event.ID, owner).
+		Select("state", "attempt_count", "next_attempt_at", "last_error", "delivered_at", "claimed_by",
+			"claimed_until", "updated_at").
+		Updates(event)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNoRowsAffected
+	}
+
+	return nil
+}
+
+func (r *WebhookEvent) CreateAttempt(ctx context.Context, attempt *model.WebhookAttempt) error {
+	db := GetTx(ctx, r.db)
+	return db.Create(attempt).Error
+}
+
+// ClaimDue locks up to limit pending events due by now, skipping rows another worker has locked, and stamps them
+// with claim in the same transaction, so concurrent workers never deliver the same event. Events come back oldest
+// due first.
+func (r *WebhookEvent) ClaimDue(claim Claim, now time.Time, limit int) ([]model.WebhookEvent, error) {
+	var events []model.WebhookEvent
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []int64
+		err := tx.Model(&model.WebhookEvent{}).
+			Where("state = ? AND next_attempt_at <= ?", model.WebhookEventStatePending, now).
+			Where(claimableEvent).Order("next_attempt_at ASC").Limit(limit).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		err = tx.Model(&model.WebhookEvent{}).Where("id IN ?", ids).Updates(map[string]any{
+			"claimed_by":    claim.Owner,
+			"claimed_until": gorm.Expr("NOW() + INTERVAL ? SECOND", max(int64(claim.Lease.Seconds()), 1)),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Preload("Webhook").Where("id IN ?", ids).Find(&events).Error; err != nil {
+			return err
+		}
+
+		slices.SortFunc(events, func(a, b model.WebhookEvent) int {
+			return slices.Index(ids, a.ID) - slices.Index(ids, b.ID)
+		})
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
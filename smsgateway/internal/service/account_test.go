@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAccount_Authenticate(t *testing.T) {
+	logger := zap.NewNop()
+
+	activeKey := func() *model.APIKey {
+		return &model.APIKey{ID: 3, AccountID: 7, Account: model.Account{ID: 7, Name: "acme", Enabled: true,
+			Senders: []model.AccountSender{{MSISDN: "1234567890"}, {MSISDN: "1234567891"}}}}
+	}
+
+	t.Run("resolves account by key hash", func(t *testing.T) {
+		mockAccountRepo := &mocks.AccountRepository{}
+		svc := service.NewAccountService(mockAccountRepo, logger)
+
+		mockAccountRepo.On("GetAPIKeyByHash", sha256Hex("sgw_secret")).Return(activeKey(), nil)
+
+		account, err := svc.Authenticate(context.Background(), "sgw_secret")
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.Account{ID: 7, Name: "acme", Senders: []string{"1234567890", "1234567891"}}, account)
+		assert.True(t, account.OwnsSender("1234567891"))
+		assert.False(t, account.OwnsSender("5555555555"))
+	})
+
+	t.Run("carries the otp entitlement", func(t *testing.T) {
+		mockAccountRepo := &mocks.AccountRepository{}
+		svc := service.NewAccountService(mockAccountRepo, logger)
+
+		key := activeKey()
+		key.Account.OTPEnabled = true
+		mockAccountRepo.On("GetAPIKeyByHash", sha256Hex("sgw_secret")).Return(key, nil)
+
+		account, err := svc.Authenticate(context.Background(), "sgw_secret")
+
+		assert.NoError(t, err)
+		assert.True(t, account.OTPEnabled)
+		assert.True(t, account.AllowsPriority("otp"))
+	})
+
+	t.Run("rejects missing key without a lookup", func(t *testing.T) {
+		mockAccountRepo := &mocks.AccountRepository{}
+		svc := service.NewAccountService(mockAccountRepo, logger)
+
+		_, err := svc.Authenticate(context.Background(), "")
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeUnauthorized, serviceErr.Code)
+		mockAccountRepo.AssertNotCalled(t, "GetAPIKeyByHash")
+	})
+
+	t.Run("rejects unknown key", func(t *testing.T) {
+		mockAccountRepo := &mocks.AccountRepository{}
+		svc := service.NewAccountService(mockAccountRepo, logger)
+
+		mockAccountRepo.On("GetAPIKeyByHash", mock.Anything).
+			Return((*model.APIKey)(nil), repository.ErrAPIKeyNotFound)
+
+		_, err := svc.Authenticate(context.Background(), "sgw_unknown")
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeUnauthorized, serviceErr.Code)
+	})
+
+	t.Run("rejects revoked key and disabled account", func(t *testing.T) {
+		revoked := activeKey()
+		revokedAt := time.Now()
+		revoked.RevokedAt = &revokedAt
+
+		disabled := activeKey()
+		disabled.Account.Enabled = false
+
+		for _, key := range []*model.APIKey{revoked, disabled} {
+			mockAccountRepo := &mocks.AccountRepository{}
+			svc := service.NewAccountService(mockAccountRepo, logger)
+
+			mockAccountRepo.On("GetAPIKeyByHash", mock.Anything).Return(key, nil)
+
+			_, err := svc.Authenticate(context.Background(), "sgw_secret")
+
+			var serviceErr service.Error
+			assert.True(t, errors.As(err, &serviceErr))
+			assert.Equal(t, constants.ErrCodeUnauthorized, serviceErr.Code)
+		}
+	})
+}
+
+func TestAccount_IssueAPIKey(t *testing.T) {
+	logger := zap.NewNop()
+
+	t.Run("stores only the hash of the issued key", func(t *testing.T) {
+		mockAccountRepo := &mocks.AccountRepository{}
+		svc := service.NewAccountService(mockAccountRepo, logger)
+
+		var stored *model.APIKey
+		mockAccountRepo.On("GetByID", int64(7)).Return(&model.Account{ID: 7}, nil)
+		mockAccountRepo.On("CreateAPIKey", context.Background(), mock.AnythingOfType("*model.APIKey")).
+			Run(func(args mock.Arguments) {
+				stored = args.Get(1).(*model.APIKey)
+				stored.ID = 11
+			}).Return(nil)
+
+		details, err := svc.IssueAPIKey(context.Background(), service.IssueAPIKeyCommand{AccountID: 7, Name: "prod"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), details.ID)
+		assert.True(t, strings.HasPrefix(details.Key, "sgw_"))
+		assert.True(t, strings.HasPrefix(details.Key, details.Prefix))
+		assert.Equal(t, sha256Hex(details.Key), stored.KeyHash)
+	})
+
+	t.Run("returns not found for unknown account", func(t *testing.T) {
+		mockAccountRepo := &mocks.AccountRepository{}
+		svc := service.NewAccountService(mockAccountRepo, logger)
+
+		mockAccountRepo.On("GetByID", int64(404)).Return((*model.Account)(nil), repository.ErrAccountNotFound)
+
+		_, err := svc.IssueAPIKey(context.Background(), service.IssueAPIKeyCommand{AccountID: 404, Name: "prod"})
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeAccountNotFound, serviceErr.Code)
+		mockAccountRepo.AssertNotCalled(t, "CreateAPIKey")
+	})
+}
+
+func TestAccount_CreateAccount(t *testing.T) {
+	logger := zap.NewNop()
+
+	t.Run("creates account with senders", func(t *testing.T) {
+		mockAccountRepo := &mocks.AccountRepository{}
+		svc := service.NewAccountService(mockAccountRepo, logger)
+
+		mockAccountRepo.On("Create", context.Background(),
+			mock.MatchedBy(func(account *model.Account) bool {
+				return account.Name == "acme" && len(account.Senders) == 1 && account.Senders[0].MSISDN == "1234567890"
+			})).Run(func(args mock.Arguments) {
+			args.Get(1).(*model.Account).ID = 7
+		}).Return(nil)
+
+		details, err := svc.CreateAccount(context.Background(),
+			service.CreateAccountCommand{Name: "acme", Senders: []string{"1234567890"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.AccountDetails{ID: 7, Name: "acme", Senders: []string{"1234567890"}, Enabled: true},
+			details)
+	})
+
+	t.Run("rejects sender owned by another account", func(t *testing.T) {
+		mockAccountRepo := &mocks.AccountRepository{}
+		svc := service.NewAccountService(mockAccountRepo, logger)
+
+		mockAccountRepo.On("Create", context.Background(), mock.AnythingOfType("*model.Account")).
+			Return(repository.ErrSenderTaken)
+
+		_, err := svc.CreateAccount(context.Background(),
+			service.CreateAccountCommand{Name: "acme", Senders: []string{"1234567890"}})
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeSenderTaken, serviceErr.Code)
+	})
+}
+
+func TestAccount_AllowsPriority(t *testing.T) {
+	account := service.Account{ID: 7}
+
+	assert.True(t, account.AllowsPriority(""))
+	assert.True(t, account.AllowsPriority("transactional"))
+	assert.True(t, account.AllowsPriority("bulk"))
+	assert.False(t, account.AllowsPriority("otp"))
+
+	account.OTPEnabled = true
+	assert.True(t, account.AllowsPriority("otp"))
+}
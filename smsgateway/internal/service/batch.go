@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/pkg/segmentation"
+	"go.uber.org/zap"
+)
+
+const (
+	BatchItemStatusCreated   = "CREATED"
+	BatchItemStatusDuplicate = "DUPLICATE"
+	BatchItemStatusRejected  = "REJECTED"
+
+	// idempotencyKeyLength is the longest key the payment service stores.
+	idempotencyKeyLength = 36
+)
+
+func (m *message) CreateMessageBatch(ctx context.Context, cmd CreateMessageBatchCommand) (
+	CreateMessageBatchResponse, error) {
+
+	existing, err := m.findExistingMessages(cmd)
+	if err != nil {
+		return CreateMessageBatchResponse{}, err
+	}
+
+	suppressed, err := m.findSuppressedRecipients(ctx, cmd)
+	if err != nil {
+		return CreateMessageBatchResponse{}, err
+	}
+
+	results := make([]BatchItemResult, len(cmd.Items))
+	firstSeen := make(map[string]int, len(cmd.Items))
+	messages := make([]model.Message, 0, len(cmd.Items))
+	txLogs := make([]model.TxLog, 0, len(cmd.Items))
+
+	// Batches are usually campaigns, so they ride the bulk lane unless the caller says otherwise.
+	priority := cmd.Priority
+	if priority == "" {
+		priority = string(model.MessagePriorityBulk)
+	}
+
+	var amount int64
+	for i, item := range cmd.Items {
+		results[i] = BatchItemResult{Index: item.Index, ClientMessageID: item.ClientMessageID}
+
+		if msg, ok := existing[item.ClientMessageID]; ok {
+			retry := CreateMessageCommand{ToMSISDN: item.ToMSISDN, Text: item.Text}
+			if !samePayload(&msg, retry) {
+				results[i].Status = BatchItemStatusRejected
+				results[i].ErrorCode = constants.ErrCodeIdempotencyMismatch
+				continue
+			}
+
+			results[i].MessageID = msg.ID
+			results[i].Status = BatchItemStatusDuplicate
+			continue
+		}
+
+		if _, ok := firstSeen[item.ClientMessageID]; ok {
+			results[i].Status = BatchItemStatusDuplicate
+			continue
+		}
+
+		// The id is taken even by an item that is not created, so a later item reusing it is a duplicate rather than
+		// a message of its own.
+		firstSeen[item.ClientMessageID] = i
+
+		if suppressed[item.ToMSISDN] {
+			results[i].Status = BatchItemStatusRejected
+			results[i].ErrorCode = constants.ErrCodeRecipientSuppressed
+			continue
+		}
+
+		segments := segmentation.Calculate(item.Text)
+		results[i].Status = BatchItemStatusCreated
+		results[i].Segments = segments.Segments
+
+		msgCmd := CreateMessageCommand{
+			AccountID:       cmd.AccountID,
+			ClientMessageID: item.ClientMessageID,
+			FromMSISDN:      cmd.FromMSISDN,
+			ToMSISDN:        item.ToMSISDN,
+			Text:            item.Text,
+			ValidityPeriod:  m.defaultValidity,
+			Priority:        priority,
+		}
+
+		msg := newMessage(msgCmd, segments)
+		msg.BatchID = &cmd.BatchID
+		messages = append(messages, msg)
+		txLogs = append(txLogs, newTxLog(msg))
+		amount += int64(segments.Segments)
+	}
+
+	response := CreateMessageBatchResponse{BatchID: cmd.BatchID, Amount: amount, Results: results}
+	if len(messages) == 0 {
+		m.logger.Info("Batch contained no new messages", zap.String("batchID", cmd.BatchID))
+		return response, nil
+	}
+
+	idempotencyKey := batchIdempotencyKey("charge-batch-", cmd, messages)
+	request := ChargePaymentCommand{UserID: cmd.FromMSISDN, Amount: amount, IdempotencyKey: idempotencyKey}
+
+	if err := m.payment.Charge(ctx, request); err != nil {
+		m.logger.Debug("Batch creation aborted due to payment failure", zap.String("batchID", cmd.BatchID))
+		return CreateMessageBatchResponse{}, err
+	}
+
+	if err := m.createMessageBatchTx(ctx, messages, txLogs); err != nil {
+		m.logger.Error("Critical: Payment succeeded but batch creation failed, initiating refund",
+			zap.String("batchID", cmd.BatchID))
+
+		idempotencyKey = batchIdempotencyKey("refund-batch-", cmd, messages)
+		refundReq := RefundPaymentCommand{UserID: cmd.FromMSISDN, Amount: amount, IdempotencyKey: idempotencyKey}
+
+		if refundErr := m.payment.Refund(ctx, refundReq); refundErr != nil {
+			m.logger.Error("CRITICAL: User charged without service - manual intervention required",
+				zap.String("batchID", cmd.BatchID))
+		}
+
+		return CreateMessageBatchResponse{}, err
+	}
+
+	for _, msg := range messages {
+		results[firstSeen[msg.ClientMessageID]].MessageID = msg.ID
+	}
+
+	for i := range results {
+		if results[i].Status == BatchItemStatusDuplicate && results[i].MessageID == 0 {
+			results[i].MessageID = results[firstSeen[results[i].ClientMessageID]].MessageID
+		}
+	}
+
+	m.logger.Info("Message batch created successfully",
+		zap.String("batchID", cmd.BatchID),
+		zap.Int("created", len(messages)),
+		zap.Int("total", len(cmd.Items)),
+		zap.Int64("amount", amount))
+
+	return response, nil
+}
+
+// batchIdempotencyKey hashes the account, batch id and the messages being charged into a key of fixed length. Since
+// it covers the items, a batch_id reused for other items is charged for them, and a retry of a batch whose earlier
+// attempt created some of its items is charged only for the rest.
+func batchIdempotencyKey(prefix string, cmd CreateMessageBatchCommand, messages []model.Message) string {
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%d\x00%s\x00%s", cmd.AccountID, cmd.FromMSISDN, cmd.BatchID)
+	for _, msg := range messages {
+		_, _ = fmt.Fprintf(hash, "\x00%s\x00%s\x00%s", msg.ClientMessageID, msg.ToMSISDN, msg.Text)
+	}
+
+	return prefix + hex.EncodeToString(hash.Sum(nil))[:idempotencyKeyLength-len(prefix)]
+}
+
+func (m *message) findExistingMessages(cmd CreateMessageBatchCommand) (map[string]model.Message, error) {
+	clientMessageIDs := make([]string, 0, len(cmd.Items))
+	for _, item := range cmd.Items {
+		clientMessageIDs = append(clientMessageIDs, item.ClientMessageID)
+	}
+
+	existing := make(map[string]model.Message)
+	if len(clientMessageIDs) == 0 {
+		return existing, nil
+	}
+
+	messages, err := m.messageRepo.GetByClientMessageIDs(cmd.FromMSISDN, clientMessageIDs)
+	if err != nil {
+		m.logger.Error("Failed to look up existing batch messages",
+			zap.String("batchID", cmd.BatchID),
+			zap.Error(err))
+		return nil, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	for _, msg := range messages {
+		existing[msg.ClientMessageID] = msg
+	}
+
+	return existing, nil
+}
+
+// findSuppressedRecipients looks up the recipients of every item in one query so suppressed items are dropped before
+// the batch is charged.
+func (m *message) findSuppressedRecipients(ctx context.Context, cmd CreateMessageBatchCommand) (map[string]bool,
+	error) {
+	recipients := make([]string, 0, len(cmd.Items))
+	for _, item := range cmd.Items {
+		recipients = append(recipients, item.ToMSISDN)
+	}
+
+	return m.suppression.SuppressedRecipients(ctx, cmd.AccountID, recipients)
+}
+
+func (m *message) createMessageBatchTx(ctx context.Context, messages []model.Message, txLogs []model.TxLog) error {
+	return m.txManager.WithTx(ctx, func(ctx context.Context) error {
+		err := m.messageRepo.CreateBatch(ctx, messages)
+		if err != nil && errors.Is(err, repository.ErrMessageDuplicate) {
+			m.logger.Warn("Duplicate message detected while creating batch", zap.Error(err))
+			return NewServiceError(constants.ErrCodeDuplicateMessage, err)
+		}
+
+		if err != nil {
+			m.logger.Warn("Failed to create batch messages", zap.Error(err))
+			return NewServiceError(ErrCodeDatabase, err)
+		}
+
+		for i := range txLogs {
+			txLogs[i].MessageID = messages[i].ID
+		}
+
+		if err := m.txLogRepo.CreateBatch(ctx, txLogs); err != nil {
+			m.logger.Warn("Failed to create batch transaction logs", zap.Error(err))
+			return NewServiceError(ErrCodeDatabase, err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,283 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestMessage_CreateMessageBatch(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.Config{}
+
+	cmd := service.CreateMessageBatchCommand{
+		BatchID:    "batch-1",
+		FromMSISDN: "1234567890",
+		Items: []service.BatchItem{
+			{Index: 0, ClientMessageID: "m-0", ToMSISDN: "0987654321", Text: "Hello"},
+			{Index: 1, ClientMessageID: "m-1", ToMSISDN: "0987654322", Text: strings.Repeat("س", 71)},
+			{Index: 2, ClientMessageID: "m-0", ToMSISDN: "0987654323", Text: "Hello again"},
+			{Index: 3, ClientMessageID: "m-old", ToMSISDN: "0987654324", Text: "Hello"},
+		},
+	}
+
+	t.Run("charges once and creates only new messages", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+
+		mockMessageRepo.On("GetByClientMessageIDs", cmd.FromMSISDN, []string{"m-0", "m-1", "m-0", "m-old"}).
+			Return([]model.Message{{ID: 7, ClientMessageID: "m-old", ToMSISDN: "0987654324", Text: "Hello"}}, nil)
+
+		mockPayment.On("Charge", context.Background(),
+			mock.MatchedBy(func(req service.ChargePaymentCommand) bool {
+				return req.Amount == 3 && strings.HasPrefix(req.IdempotencyKey, "charge-batch-") &&
+					len(req.IdempotencyKey) == 36
+			})).Return(nil)
+
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+
+		mockMessageRepo.On("CreateBatch", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(messages []model.Message) bool {
+				return len(messages) == 2 && *messages[0].BatchID == cmd.BatchID &&
+					messages[0].Priority == model.MessagePriorityBulk
+			})).Run(func(args mock.Arguments) {
+			messages := args.Get(1).([]model.Message)
+			messages[0].ID = 100
+			messages[1].ID = 101
+		}).Return(nil)
+
+		mockTxLogRepo.On("CreateBatch", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(logs []model.TxLog) bool {
+				return len(logs) == 2 &&
+					logs[0].MessageID == 100 && logs[0].Amount == 1 &&
+					logs[1].MessageID == 101 && logs[1].Amount == 2
+			})).Return(nil)
+
+		resp, err := svc.CreateMessageBatch(context.Background(), cmd)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), resp.Amount)
+		assert.Equal(t, []service.BatchItemResult{
+			{Index: 0, ClientMessageID: "m-0", MessageID: 100, Status: service.BatchItemStatusCreated, Segments: 1},
+			{Index: 1, ClientMessageID: "m-1", MessageID: 101, Status: service.BatchItemStatusCreated, Segments: 2},
+			{Index: 2, ClientMessageID: "m-0", MessageID: 100, Status: service.BatchItemStatusDuplicate},
+			{Index: 3, ClientMessageID: "m-old", MessageID: 7, Status: service.BatchItemStatusDuplicate},
+		}, resp.Results)
+
+		mockPayment.AssertNumberOfCalls(t, "Charge", 1)
+		mockMessageRepo.AssertExpectations(t)
+		mockTxLogRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects suppressed recipients and charges only for the rest", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+		mockSuppression := &mocks.SuppressionService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, mockSuppression,
+			cfg, logger)
+
+		suppressedCmd := service.CreateMessageBatchCommand{AccountID: 7, BatchID: "batch-2", FromMSISDN: "1234567890",
+			Items: []service.BatchItem{
+				{Index: 0, ClientMessageID: "m-0", ToMSISDN: "0987654321", Text: "Hello"},
+				{Index: 1, ClientMessageID: "m-1", ToMSISDN: "0987654322", Text: "Hello"},
+			}}
+
+		mockMessageRepo.On("GetByClientMessageIDs", suppressedCmd.FromMSISDN, []string{"m-0", "m-1"}).
+			Return([]model.Message{}, nil)
+		mockSuppression.On("SuppressedRecipients", context.Background(), int64(7),
+			[]string{"0987654321", "0987654322"}).Return(map[string]bool{"0987654322": true}, nil)
+		mockPayment.On("Charge", context.Background(),
+			mock.MatchedBy(func(req service.ChargePaymentCommand) bool { return req.Amount == 1 })).Return(nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("CreateBatch", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(messages []model.Message) bool {
+				return len(messages) == 1 && messages[0].ToMSISDN == "0987654321"
+			})).Run(func(args mock.Arguments) {
+			args.Get(1).([]model.Message)[0].ID = 100
+		}).Return(nil)
+		mockTxLogRepo.On("CreateBatch", mock.AnythingOfType("*context.valueCtx"),
+			mock.AnythingOfType("[]model.TxLog")).Return(nil)
+
+		resp, err := svc.CreateMessageBatch(context.Background(), suppressedCmd)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), resp.Amount)
+		assert.Equal(t, service.BatchItemResult{Index: 1, ClientMessageID: "m-1", Status: service.BatchItemStatusRejected,
+			ErrorCode: constants.ErrCodeRecipientSuppressed}, resp.Results[1])
+		mockPayment.AssertExpectations(t)
+	})
+
+	t.Run("does not charge when every item is a duplicate", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+
+		dupCmd := service.CreateMessageBatchCommand{BatchID: "batch-1", FromMSISDN: "1234567890",
+			Items: []service.BatchItem{{Index: 0, ClientMessageID: "m-old", ToMSISDN: "0987654321", Text: "Hi"}}}
+
+		mockMessageRepo.On("GetByClientMessageIDs", dupCmd.FromMSISDN, []string{"m-old"}).
+			Return([]model.Message{{ID: 7, ClientMessageID: "m-old", ToMSISDN: "0987654321", Text: "Hi"}}, nil)
+
+		resp, err := svc.CreateMessageBatch(context.Background(), dupCmd)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), resp.Amount)
+		assert.Equal(t, service.BatchItemStatusDuplicate, resp.Results[0].Status)
+		mockPayment.AssertNotCalled(t, "Charge")
+		mockMessageRepo.AssertNotCalled(t, "CreateBatch")
+	})
+
+	t.Run("rejects an existing id reused with a different payload", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{}, mockPayment,
+			newSuppressionService(), cfg, logger)
+
+		reuseCmd := service.CreateMessageBatchCommand{BatchID: "batch-1", FromMSISDN: "1234567890",
+			Items: []service.BatchItem{{Index: 0, ClientMessageID: "m-old", ToMSISDN: "0987654321", Text: "Bye"}}}
+
+		mockMessageRepo.On("GetByClientMessageIDs", reuseCmd.FromMSISDN, []string{"m-old"}).
+			Return([]model.Message{{ID: 7, ClientMessageID: "m-old", ToMSISDN: "0987654321", Text: "Hi"}}, nil)
+
+		resp, err := svc.CreateMessageBatch(context.Background(), reuseCmd)
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.BatchItemResult{Index: 0, ClientMessageID: "m-old", Status: service.BatchItemStatusRejected,
+			ErrorCode: constants.ErrCodeIdempotencyMismatch}, resp.Results[0])
+		mockPayment.AssertNotCalled(t, "Charge")
+	})
+
+	t.Run("treats an id reused after a suppressed item as a duplicate", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockPayment := &mocks.PaymentService{}
+		mockSuppression := &mocks.SuppressionService{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{}, mockPayment,
+			mockSuppression, cfg, logger)
+
+		reuseCmd := service.CreateMessageBatchCommand{AccountID: 7, BatchID: "batch-3", FromMSISDN: "1234567890",
+			Items: []service.BatchItem{
+				{Index: 0, ClientMessageID: "m-0", ToMSISDN: "0987654321", Text: "Hello"},
+				{Index: 1, ClientMessageID: "m-0", ToMSISDN: "0987654322", Text: "Hello"},
+			}}
+
+		mockMessageRepo.On("GetByClientMessageIDs", reuseCmd.FromMSISDN, []string{"m-0", "m-0"}).
+			Return([]model.Message{}, nil)
+		mockSuppression.On("SuppressedRecipients", context.Background(), int64(7), mock.Anything).
+			Return(map[string]bool{"0987654321": true}, nil)
+
+		resp, err := svc.CreateMessageBatch(context.Background(), reuseCmd)
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.BatchItemStatusRejected, resp.Results[0].Status)
+		assert.Equal(t, service.BatchItemStatusDuplicate, resp.Results[1].Status)
+		mockPayment.AssertNotCalled(t, "Charge")
+		mockMessageRepo.AssertNotCalled(t, "CreateBatch")
+	})
+
+	t.Run("returns error when payment charge fails", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+
+		chargeError := service.NewServiceError(constants.ErrCodeInsufficientBalance, errors.New("insufficient balance"))
+
+		mockMessageRepo.On("GetByClientMessageIDs", cmd.FromMSISDN, mock.Anything).
+			Return([]model.Message{}, nil)
+		mockPayment.On("Charge", context.Background(), mock.AnythingOfType("service.ChargePaymentCommand")).
+			Return(chargeError)
+
+		_, err := svc.CreateMessageBatch(context.Background(), cmd)
+
+		assert.Equal(t, chargeError, err)
+		mockMessageRepo.AssertNotCalled(t, "CreateBatch")
+	})
+
+	t.Run("refunds the total when batch insert fails", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+
+		mockMessageRepo.On("GetByClientMessageIDs", cmd.FromMSISDN, mock.Anything).
+			Return([]model.Message{}, nil)
+		mockPayment.On("Charge", context.Background(), mock.AnythingOfType("service.ChargePaymentCommand")).
+			Return(nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("CreateBatch", mock.AnythingOfType("*context.valueCtx"),
+			mock.AnythingOfType("[]model.Message")).Return(errors.New("insert failed"))
+		mockPayment.On("Refund", context.Background(),
+			mock.MatchedBy(func(req service.RefundPaymentCommand) bool {
+				return req.Amount == 4 && strings.HasPrefix(req.IdempotencyKey, "refund-batch-") &&
+					len(req.IdempotencyKey) == 36
+			})).Return(nil)
+
+		_, err := svc.CreateMessageBatch(context.Background(), cmd)
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, service.ErrCodeDatabase, serviceErr.Code)
+		mockPayment.AssertExpectations(t)
+		mockTxLogRepo.AssertNotCalled(t, "CreateBatch")
+	})
+
+	t.Run("charges a reused batch id again for different items", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, mockTxManager, mockPayment,
+			newSuppressionService(), cfg, logger)
+
+		mockMessageRepo.On("GetByClientMessageIDs", cmd.FromMSISDN, mock.Anything).Return([]model.Message{}, nil)
+		var keys []string
+		mockPayment.On("Charge", context.Background(), mock.AnythingOfType("service.ChargePaymentCommand")).
+			Run(func(args mock.Arguments) {
+				keys = append(keys, args.Get(1).(service.ChargePaymentCommand).IdempotencyKey)
+			}).Return(errors.New("stop"))
+
+		reused := cmd
+		reused.Items = []service.BatchItem{{ClientMessageID: "m-9", ToMSISDN: "0987654329", Text: "Other"}}
+
+		_, _ = svc.CreateMessageBatch(context.Background(), cmd)
+		_, _ = svc.CreateMessageBatch(context.Background(), reused)
+
+		if assert.Len(t, keys, 2) {
+			assert.NotEqual(t, keys[0], keys[1])
+		}
+		mockTxManager.AssertNotCalled(t, "WithTx")
+	})
+}
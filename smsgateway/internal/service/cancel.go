@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"go.uber.org/zap"
+)
+
+const cancelledError = "cancelled by client"
+
+var ErrMessageNotCancellable = errors.New("MESSAGE_NOT_CANCELLABLE")
+
+// CancelMessage cancels a message that has not been published to the send queue yet and refunds its charge with the
+// same idempotency key the refund worker uses, so a retried refund can never pay out twice.
+func (m *message) CancelMessage(ctx context.Context, id int64) (CancelMessageResponse, error) {
+	msg, err := m.messageRepo.GetByID(id)
+	if err != nil {
+		return CancelMessageResponse{}, m.lookupError(err, zap.Int64("messageID", id))
+	}
+
+	if msg.Status != model.MessageStatusCreated {
+		m.logger.Info("Message is not cancellable",
+			zap.Int64("messageID", id),
+			zap.String("status", string(msg.Status)))
+		return CancelMessageResponse{}, NewServiceError(constants.ErrCodeMessageNotCancellable, ErrMessageNotCancellable)
+	}
+
+	txLog, err := m.txLogRepo.GetByMessageID(id)
+	if err != nil {
+		m.logger.Error("Failed to get transaction log for cancellation",
+			zap.Int64("messageID", id),
+			zap.Error(err))
+		return CancelMessageResponse{}, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	if err := m.cancelMessageTx(ctx, id); err != nil {
+		return CancelMessageResponse{}, err
+	}
+
+	response := CancelMessageResponse{MessageID: id, Status: string(model.MessageStatusCancelled)}
+
+	idempotencyKey := messageIdempotencyKey("refund-", msg.FromMSISDN, msg.ClientMessageID)
+	refundReq := RefundPaymentCommand{UserID: msg.FromMSISDN, Amount: int64(txLog.Amount), IdempotencyKey: idempotencyKey}
+
+	if err := m.payment.Refund(ctx, refundReq); err != nil {
+		m.logger.Warn("Refund for cancelled message failed, handing over to refund worker",
+			zap.Int64("messageID", id),
+			zap.Error(err))
+
+		lastError := cancelledError
+		handover := model.TxLog{
+			MessageID:   id,
+			State:       model.TxLogStateFailed,
+			Published:   false,
+			PublishedAt: nil,
+			LastError:   &lastError,
+			UpdatedAt:   time.Now(),
+		}
+
+		if err := m.txLogRepo.UpdateForPermFailed(ctx, &handover); err != nil {
+			m.logger.Error("CRITICAL: Cancelled message left without refund - manual intervention required",
+				zap.Int64("messageID", id),
+				zap.Error(err))
+		}
+
+		response.RefundState = RefundStatePending
+		return response, nil
+	}
+
+	refunded := model.TxLog{MessageID: id, State: model.TxLogStateRefunded, UpdatedAt: time.Now()}
+	if err := m.txLogRepo.UpdateByMessageID(ctx, &refunded); err != nil {
+		m.logger.Error("Refund succeeded but transaction log update failed",
+			zap.Int64("messageID", id),
+			zap.Error(err))
+	}
+
+	m.logger.Info("Message cancelled and refunded", zap.Int64("messageID", id))
+
+	response.RefundState = RefundStateRefunded
+	return response, nil
+}
+
+// cancelMessageTx claims the tx_log away from the send publisher. It is left FAILED but published so the refund
+// publisher ignores it while the refund is attempted inline.
+func (m *message) cancelMessageTx(ctx context.Context, id int64) error {
+	msg := model.Message{ID: id, Status: model.MessageStatusCancelled, UpdatedAt: time.Now()}
+
+	lastError := cancelledError
+	txLog := model.TxLog{
+		MessageID: id,
+		State:     model.TxLogStateFailed,
+		Published: true,
+		LastError: &lastError,
+		UpdatedAt: time.Now(),
+	}
+
+	err := m.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := m.messageRepo.UpdateForCancel(ctx, &msg); err != nil {
+			return err
+		}
+
+		return m.txLogRepo.UpdateForCancel(ctx, &txLog)
+	})
+
+	if errors.Is(err, repository.ErrNoRowsAffected) {
+		m.logger.Info("Message was queued before it could be cancelled", zap.Int64("messageID", id))
+		return NewServiceError(constants.ErrCodeMessageNotCancellable, ErrMessageNotCancellable)
+	}
+
+	if err != nil {
+		m.logger.Error("Failed to cancel message",
+			zap.Int64("messageID", id),
+			zap.Error(err))
+		return NewServiceError(ErrCodeDatabase, err)
+	}
+
+	return nil
+}
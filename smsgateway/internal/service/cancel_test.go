@@ -0,0 +1,135 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestMessage_CancelMessage(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.Config{}
+
+	created := func() *model.Message {
+		return &model.Message{ID: 123, ClientMessageID: "m-1", FromMSISDN: "1234567890",
+			Status: model.MessageStatusCreated}
+	}
+
+	t.Run("cancels unpublished message and refunds inline", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+
+		mockMessageRepo.On("GetByID", int64(123)).Return(created(), nil)
+		mockTxLogRepo.On("GetByMessageID", int64(123)).Return(&model.TxLog{MessageID: 123, Amount: 2}, nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("UpdateForCancel", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(msg *model.Message) bool {
+				return msg.ID == 123 && msg.Status == model.MessageStatusCancelled
+			})).Return(nil)
+		mockTxLogRepo.On("UpdateForCancel", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(txLog *model.TxLog) bool {
+				return txLog.State == model.TxLogStateFailed && txLog.Published
+			})).Return(nil)
+		mockPayment.On("Refund", context.Background(), service.RefundPaymentCommand{
+			UserID: "1234567890", Amount: 2, IdempotencyKey: paymentKey("refund-", "1234567890", "m-1")}).Return(nil)
+		mockTxLogRepo.On("UpdateByMessageID", context.Background(),
+			mock.MatchedBy(func(txLog *model.TxLog) bool {
+				return txLog.MessageID == 123 && txLog.State == model.TxLogStateRefunded
+			})).Return(nil)
+
+		resp, err := svc.CancelMessage(context.Background(), 123)
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.CancelMessageResponse{MessageID: 123, Status: "CANCELLED",
+			RefundState: service.RefundStateRefunded}, resp)
+		mockPayment.AssertExpectations(t)
+		mockTxLogRepo.AssertExpectations(t)
+	})
+
+	t.Run("hands refund to the refund worker when payment fails", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+
+		mockMessageRepo.On("GetByID", int64(123)).Return(created(), nil)
+		mockTxLogRepo.On("GetByMessageID", int64(123)).Return(&model.TxLog{MessageID: 123, Amount: 1}, nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("UpdateForCancel", mock.Anything, mock.Anything).Return(nil)
+		mockTxLogRepo.On("UpdateForCancel", mock.Anything, mock.Anything).Return(nil)
+		mockPayment.On("Refund", context.Background(), mock.AnythingOfType("service.RefundPaymentCommand")).
+			Return(service.NewServiceError(service.ErrCodeRefundTimeout, errors.New("timeout")))
+		mockTxLogRepo.On("UpdateForPermFailed", context.Background(),
+			mock.MatchedBy(func(txLog *model.TxLog) bool {
+				return txLog.State == model.TxLogStateFailed && !txLog.Published
+			})).Return(nil)
+
+		resp, err := svc.CancelMessage(context.Background(), 123)
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.RefundStatePending, resp.RefundState)
+		mockTxLogRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects message that is already sending", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{}, mockPayment,
+			newSuppressionService(), cfg, logger)
+
+		mockMessageRepo.On("GetByID", int64(123)).
+			Return(&model.Message{ID: 123, Status: model.MessageStatusSending}, nil)
+
+		_, err := svc.CancelMessage(context.Background(), 123)
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeMessageNotCancellable, serviceErr.Code)
+		mockPayment.AssertNotCalled(t, "Refund")
+	})
+
+	t.Run("rejects message published concurrently", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+
+		mockMessageRepo.On("GetByID", int64(123)).Return(created(), nil)
+		mockTxLogRepo.On("GetByMessageID", int64(123)).Return(&model.TxLog{MessageID: 123, Amount: 1}, nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("UpdateForCancel", mock.Anything, mock.Anything).Return(nil)
+		mockTxLogRepo.On("UpdateForCancel", mock.Anything, mock.Anything).Return(repository.ErrNoRowsAffected)
+
+		_, err := svc.CancelMessage(context.Background(), 123)
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeMessageNotCancellable, serviceErr.Code)
+		mockPayment.AssertNotCalled(t, "Refund")
+	})
+}
@@ -1,23 +1,67 @@
 package service
 
+import (
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+)
+
 type CreateMessageCommand struct {
+	AccountID       int64
 	ClientMessageID string
 	FromMSISDN      string
 	ToMSISDN        string
 	Text            string
+	SendAt          *time.Time
+	ValidityPeriod  time.Duration
+	TemplateID      *int64
+	TemplateVersion *int
+	// TemplateVariables are the variables a template send was rendered with. Only their hash is stored, to tell a
+	// retry from a reuse of the client message id.
+	TemplateVariables map[string]string
+	Priority          string
+}
+
+type CreateMessageBatchCommand struct {
+	AccountID  int64
+	BatchID    string
+	FromMSISDN string
+	Priority   string
+	Items      []BatchItem
+}
+
+type BatchItem struct {
+	Index           int
+	ClientMessageID string
+	ToMSISDN        string
+	Text            string
 }
 
 type SendMessageCommand struct {
-	MessageID  int64  `json:"message_id"`
-	FromMSISDN string `json:"from_msisdn"`
-	ToMSISDN   string `json:"to_msisdn"`
-	Text       string `json:"text"`
+	MessageID  int64      `json:"message_id"`
+	FromMSISDN string     `json:"from_msisdn"`
+	ToMSISDN   string     `json:"to_msisdn"`
+	Text       string     `json:"text"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Priority   string     `json:"priority,omitempty"`
+	Retry      int        `json:"retry,omitempty"`
 }
 
 type GetMessagesQuery struct {
-	UserID string
-	Limit  int
-	Offset int
+	UserID                string
+	Limit                 int
+	Cursor                string
+	Status                string
+	ToMSISDN              string
+	CreatedFrom           *time.Time
+	CreatedTo             *time.Time
+	ClientMessageIDPrefix string
+	IncludeTotal          bool
+}
+
+type GetMessageQuery struct {
+	FromMSISDN      string
+	ClientMessageID string
 }
 
 type UpdateMessageToSendingCommand struct {
@@ -54,4 +98,110 @@ type ProcessRefundCommand struct {
 	ClientMessageID string `json:"client_message_id"`
 	FromMSISDN      string `json:"from_msisdn"`
 	Amount          int    `json:"amount"`
+	Retry           int    `json:"retry,omitempty"`
+}
+
+type DeliveryReportCommand struct {
+	Provider      string
+	ProviderMsgID string
+	Status        string
+	ErrorCode     string
+	ReportedAt    *time.Time
+}
+
+type RegisterWebhookCommand struct {
+	UserID string
+	URL    string
+	Secret string
+}
+
+type NotifyWebhookCommand struct {
+	MessageID int64
+	Event     string
+	Status    model.MessageStatus
+	Error     string
+}
+
+type CreateAccountCommand struct {
+	Name       string
+	Senders    []string
+	OTPEnabled bool
+}
+
+type IssueAPIKeyCommand struct {
+	AccountID int64
+	Name      string
+}
+
+// CheckRateLimitCommand describes one send API request with a destination per message it creates, so a batch costs
+// the account and sender as many tokens as it has messages and each of its destinations one per message to it.
+type CheckRateLimitCommand struct {
+	AccountID  int64
+	FromMSISDN string
+	ToMSISDNs  []string
+}
+
+type CreateTemplateCommand struct {
+	AccountID int64
+	Name      string
+	Body      string
+}
+
+// UpdateTemplateCommand stores Body as a new version. An empty Name keeps the current name.
+type UpdateTemplateCommand struct {
+	AccountID  int64
+	TemplateID int64
+	Name       string
+	Body       string
+}
+
+type RenderTemplateCommand struct {
+	AccountID  int64
+	TemplateID int64
+	Variables  map[string]string
+}
+
+// AddSuppressionCommand adds MSISDN to an account's list, or to the global list when AccountID is nil.
+type AddSuppressionCommand struct {
+	AccountID *int64
+	MSISDN    string
+	Reason    string
+	AddedBy   string
+}
+
+type ImportSuppressionsCommand struct {
+	AccountID *int64
+	AddedBy   string
+	Entries   []SuppressionEntry
+}
+
+type SuppressionEntry struct {
+	MSISDN string
+	Reason string
+}
+
+type RemoveSuppressionCommand struct {
+	AccountID *int64
+	MSISDN    string
+}
+
+type ListSuppressionsQuery struct {
+	AccountID *int64
+	AfterID   int64
+	Limit     int
+}
+
+type ReceiveInboundCommand struct {
+	Provider    string
+	ContentType string
+	Body        []byte
+	// Header reads a request header, for the provider's credential.
+	Header func(name string) string
+}
+
+type ListInboundQuery struct {
+	AccountID int64
+	ToMSISDN  string
+	BeforeID  int64
+	Limit     int
 }
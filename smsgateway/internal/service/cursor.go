@@ -0,0 +1,39 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+)
+
+// cursorPayload is the opaque page token handed to clients; its layout is not part of the API.
+type cursorPayload struct {
+	CreatedAt time.Time `json:"c"`
+	ID        int64     `json:"i"`
+}
+
+func encodeCursor(msg model.Message) string {
+	data, _ := json.Marshal(cursorPayload{CreatedAt: msg.CreatedAt, ID: msg.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (*repository.MessageCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &repository.MessageCursor{CreatedAt: payload.CreatedAt, ID: payload.ID}, nil
+}
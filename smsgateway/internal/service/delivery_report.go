@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"go.uber.org/zap"
+)
+
+// deliveryStatuses maps provider receipt statuses, including the SMPP short forms, to terminal message statuses.
+var deliveryStatuses = map[string]model.MessageStatus{
+	"DELIVERED":   model.MessageStatusDelivered,
+	"DELIVRD":     model.MessageStatusDelivered,
+	"UNDELIVERED": model.MessageStatusUndelivered,
+	"UNDELIV":     model.MessageStatusUndelivered,
+	"EXPIRED":     model.MessageStatusExpired,
+	"REJECTED":    model.MessageStatusRejected,
+	"REJECTD":     model.MessageStatusRejected,
+}
+
+type DeliveryReportService interface {
+	// AuthenticateReport checks that a receipt posted for provider carries that provider's credential, read from
+	// the request headers with header.
+	AuthenticateReport(provider string, header func(name string) string, body []byte) error
+	ProcessDeliveryReport(ctx context.Context, cmd DeliveryReportCommand) (DeliveryReportResult, error)
+}
+
+type deliveryReport struct {
+	messageRepo    repository.MessageRepository
+	txLogRepo      repository.TxLogRepository
+	txManager      repository.TxManager
+	webhook        WebhookService
+	receiptAuth    map[string]smsprovider.CallbackAuth
+	refundRejected bool
+	logger         *zap.Logger
+}
+
+func NewDeliveryReportService(messageRepo repository.MessageRepository, txLogRepo repository.TxLogRepository,
+	txManager repository.TxManager, webhook WebhookService, config *config.Config,
+	logger *zap.Logger) DeliveryReportService {
+	receiptAuth := make(map[string]smsprovider.CallbackAuth)
+	for _, route := range config.ProviderRoutes() {
+		receiptAuth[strings.ToLower(route.Name)] = route.Receipts
+	}
+
+	return &deliveryReport{messageRepo: messageRepo, txLogRepo: txLogRepo, txManager: txManager, webhook: webhook,
+		receiptAuth: receiptAuth, refundRejected: config.DeliveryReport.RefundRejected, logger: logger}
+}
+
+// AuthenticateReport refuses receipts for unknown providers the same way as badly signed ones, so callers cannot
+// probe which providers exist.
+func (d *deliveryReport) AuthenticateReport(provider string, header func(name string) string, body []byte) error {
+	auth, ok := d.receiptAuth[strings.ToLower(provider)]
+	if !ok {
+		return NewServiceError(constants.ErrCodeInvalidCallbackSignature, smsprovider.ErrInvalidCallbackSignature)
+	}
+
+	if err := auth.Verify(header(auth.HeaderName()), body); err != nil {
+		d.logger.Warn("Delivery report failed authentication", zap.String("provider", provider))
+		return NewServiceError(constants.ErrCodeInvalidCallbackSignature, err)
+	}
+
+	return nil
+}
+
+func (d *deliveryReport) ProcessDeliveryReport(ctx context.Context, cmd DeliveryReportCommand) (
+	DeliveryReportResult, error) {
+
+	status, ok := deliveryStatuses[strings.ToUpper(cmd.Status)]
+	if !ok {
+		d.logger.Debug("Ignoring non-terminal delivery report",
+			zap.String("providerMsgID", cmd.ProviderMsgID),
+			zap.String("status", cmd.Status))
+		return DeliveryReportResult{Status: cmd.Status}, nil
+	}
+
+	if cmd.ProviderMsgID == "" {
+		d.logger.Warn("Ignoring delivery report without a provider message id", zap.String("provider", cmd.Provider),
+			zap.String("status", cmd.Status))
+		return DeliveryReportResult{Status: cmd.Status}, nil
+	}
+
+	msg, err := d.messageRepo.GetByProviderMsgID(cmd.Provider, cmd.ProviderMsgID)
+	if err != nil {
+		if errors.Is(err, repository.ErrMessageNotFound) {
+			d.logger.Warn("Delivery report for unknown message", zap.String("provider", cmd.Provider),
+				zap.String("providerMsgID", cmd.ProviderMsgID))
+			return DeliveryReportResult{}, NewServiceError(constants.ErrCodeMessageNotFound, err)
+		}
+
+		d.logger.Error("Failed to get message for delivery report",
+			zap.String("providerMsgID", cmd.ProviderMsgID),
+			zap.Error(err))
+		return DeliveryReportResult{}, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	result := DeliveryReportResult{MessageID: msg.ID, Status: string(msg.Status)}
+	if msg.Status != model.MessageStatusSubmitted {
+		d.logger.Info("Ignoring duplicate or out-of-order delivery report",
+			zap.Int64("messageID", msg.ID),
+			zap.String("currentStatus", string(msg.Status)),
+			zap.String("reportedStatus", string(status)))
+		return result, nil
+	}
+
+	err = d.applyDeliveryStatus(ctx, msg.ID, status, cmd)
+	if errors.Is(err, repository.ErrNoRowsAffected) {
+		d.logger.Info("Message status changed concurrently, delivery report ignored", zap.Int64("messageID", msg.ID))
+		return result, nil
+	}
+
+	if err != nil {
+		d.logger.Error("Failed to apply delivery report",
+			zap.Int64("messageID", msg.ID),
+			zap.String("status", string(status)),
+			zap.Error(err))
+		return DeliveryReportResult{}, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	d.logger.Info("Delivery report applied",
+		zap.Int64("messageID", msg.ID),
+		zap.String("status", string(status)))
+
+	return DeliveryReportResult{MessageID: msg.ID, Status: string(status), Applied: true}, nil
+}
+
+func (d *deliveryReport) applyDeliveryStatus(ctx context.Context, messageID int64, status model.MessageStatus,
+	cmd DeliveryReportCommand) error {
+	reportedAt := time.Now()
+	if cmd.ReportedAt != nil {
+		reportedAt = *cmd.ReportedAt
+	}
+
+	msg := model.Message{
+		ID:         messageID,
+		Status:     status,
+		ReportedAt: &reportedAt,
+		UpdatedAt:  time.Now(),
+	}
+
+	return d.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := d.messageRepo.UpdateDeliveryStatus(ctx, &msg); err != nil {
+			return err
+		}
+
+		// The event is queued in the same transaction, so a report is applied together with its event or, to be
+		// retried, not at all.
+		if status == model.MessageStatusDelivered {
+			notifyCmd := NotifyWebhookCommand{MessageID: messageID, Event: model.WebhookEventDelivered, Status: status}
+			if err := d.webhook.Notify(ctx, notifyCmd); err != nil {
+				return err
+			}
+		}
+
+		if status != model.MessageStatusRejected || !d.refundRejected {
+			return nil
+		}
+
+		lastError := "delivery rejected"
+		if cmd.ErrorCode != "" {
+			lastError = "delivery rejected: " + cmd.ErrorCode
+		}
+
+		txLog := model.TxLog{
+			MessageID:   messageID,
+			State:       model.TxLogStateFailed,
+			Published:   false,
+			PublishedAt: nil,
+			LastError:   &lastError,
+			UpdatedAt:   time.Now(),
+		}
+
+		return d.txLogRepo.UpdateForPermFailed(ctx, &txLog)
+	})
+}
@@ -0,0 +1,235 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestDeliveryReport_ProcessDeliveryReport(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.Config{DeliveryReport: config.DeliveryReport{RefundRejected: true}}
+
+	submitted := func() *model.Message {
+		return &model.Message{ID: 123, Status: model.MessageStatusSubmitted}
+	}
+
+	t.Run("marks submitted message as delivered", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+
+		svc := service.NewDeliveryReportService(mockMessageRepo, mockTxLogRepo, mockTxManager,
+			newWebhookService(), cfg, logger)
+
+		mockMessageRepo.On("GetByProviderMsgID", "primary", "p-1").Return(submitted(), nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("UpdateDeliveryStatus", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(msg *model.Message) bool {
+				return msg.ID == 123 && msg.Status == model.MessageStatusDelivered && msg.ReportedAt != nil
+			})).Return(nil)
+
+		result, err := svc.ProcessDeliveryReport(context.Background(),
+			service.DeliveryReportCommand{Provider: "primary", ProviderMsgID: "p-1", Status: "DELIVRD"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.DeliveryReportResult{MessageID: 123, Status: "DELIVERED", Applied: true}, result)
+		mockMessageRepo.AssertExpectations(t)
+		mockTxLogRepo.AssertNotCalled(t, "UpdateForPermFailed")
+	})
+
+	t.Run("fails the report when its webhook event cannot be queued", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxManager := &mocks.TxManager{}
+		webhookSvc := &mocks.WebhookService{}
+
+		svc := service.NewDeliveryReportService(mockMessageRepo, &mocks.TxLogRepository{}, mockTxManager,
+			webhookSvc, cfg, logger)
+
+		mockMessageRepo.On("GetByProviderMsgID", "primary", "p-1").Return(submitted(), nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("UpdateDeliveryStatus", mock.AnythingOfType("*context.valueCtx"),
+			mock.AnythingOfType("*model.Message")).Return(nil)
+		webhookSvc.On("Notify", mock.Anything, mock.Anything).Return(errors.New("db down"))
+
+		_, err := svc.ProcessDeliveryReport(context.Background(),
+			service.DeliveryReportCommand{Provider: "primary", ProviderMsgID: "p-1", Status: "DELIVRD"})
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, service.ErrCodeDatabase, serviceErr.Code)
+	})
+
+	t.Run("marks rejected message for refund", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+
+		svc := service.NewDeliveryReportService(mockMessageRepo, mockTxLogRepo, mockTxManager,
+			newWebhookService(), cfg, logger)
+
+		mockMessageRepo.On("GetByProviderMsgID", "primary", "p-1").Return(submitted(), nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("UpdateDeliveryStatus", mock.AnythingOfType("*context.valueCtx"),
+			mock.AnythingOfType("*model.Message")).Return(nil)
+		mockTxLogRepo.On("UpdateForPermFailed", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(txLog *model.TxLog) bool {
+				return txLog.MessageID == 123 &&
+					txLog.State == model.TxLogStateFailed &&
+					!txLog.Published &&
+					*txLog.LastError == "delivery rejected: 0x0B"
+			})).Return(nil)
+
+		result, err := svc.ProcessDeliveryReport(context.Background(),
+			service.DeliveryReportCommand{Provider: "primary", ProviderMsgID: "p-1", Status: "rejected", ErrorCode: "0x0B"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "REJECTED", result.Status)
+		mockTxLogRepo.AssertExpectations(t)
+	})
+
+	t.Run("ignores duplicate report for terminal message", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxManager := &mocks.TxManager{}
+
+		svc := service.NewDeliveryReportService(mockMessageRepo, &mocks.TxLogRepository{}, mockTxManager,
+			newWebhookService(), cfg, logger)
+
+		mockMessageRepo.On("GetByProviderMsgID", "primary", "p-1").
+			Return(&model.Message{ID: 123, Status: model.MessageStatusDelivered}, nil)
+
+		result, err := svc.ProcessDeliveryReport(context.Background(),
+			service.DeliveryReportCommand{Provider: "primary", ProviderMsgID: "p-1", Status: "UNDELIVERED"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.DeliveryReportResult{MessageID: 123, Status: "DELIVERED"}, result)
+		mockTxManager.AssertNotCalled(t, "WithTx")
+	})
+
+	t.Run("ignores report when status changed concurrently", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxManager := &mocks.TxManager{}
+
+		svc := service.NewDeliveryReportService(mockMessageRepo, &mocks.TxLogRepository{}, mockTxManager,
+			newWebhookService(), cfg, logger)
+
+		mockMessageRepo.On("GetByProviderMsgID", "primary", "p-1").Return(submitted(), nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("UpdateDeliveryStatus", mock.AnythingOfType("*context.valueCtx"),
+			mock.AnythingOfType("*model.Message")).Return(repository.ErrNoRowsAffected)
+
+		result, err := svc.ProcessDeliveryReport(context.Background(),
+			service.DeliveryReportCommand{Provider: "primary", ProviderMsgID: "p-1", Status: "EXPIRED"})
+
+		assert.NoError(t, err)
+		assert.False(t, result.Applied)
+	})
+
+	t.Run("ignores intermediate statuses", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+
+		svc := service.NewDeliveryReportService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			newWebhookService(), cfg, logger)
+
+		result, err := svc.ProcessDeliveryReport(context.Background(),
+			service.DeliveryReportCommand{Provider: "primary", ProviderMsgID: "p-1", Status: "ENROUTE"})
+
+		assert.NoError(t, err)
+		assert.False(t, result.Applied)
+		mockMessageRepo.AssertNotCalled(t, "GetByProviderMsgID")
+	})
+
+	t.Run("ignores reports without a provider message id", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+
+		svc := service.NewDeliveryReportService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			newWebhookService(), cfg, logger)
+
+		result, err := svc.ProcessDeliveryReport(context.Background(),
+			service.DeliveryReportCommand{Provider: "primary", Status: "DELIVERED"})
+
+		assert.NoError(t, err)
+		assert.False(t, result.Applied)
+		mockMessageRepo.AssertNotCalled(t, "GetByProviderMsgID")
+	})
+
+	t.Run("returns not found for unknown provider message id", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+
+		svc := service.NewDeliveryReportService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			newWebhookService(), cfg, logger)
+
+		mockMessageRepo.On("GetByProviderMsgID", "primary", "p-404").
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
+
+		_, err := svc.ProcessDeliveryReport(context.Background(),
+			service.DeliveryReportCommand{Provider: "primary", ProviderMsgID: "p-404", Status: "DELIVERED"})
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeMessageNotFound, serviceErr.Code)
+	})
+}
+
+func TestDeliveryReport_AuthenticateReport(t *testing.T) {
+	logger := zap.NewNop()
+	auth := smsprovider.CallbackAuth{Type: smsprovider.CallbackAuthHMAC, Secret: "s3cret"}
+	forged := smsprovider.CallbackAuth{Type: smsprovider.CallbackAuthHMAC, Secret: "guess"}
+	cfg := &config.Config{Providers: []smsprovider.RouteConfig{
+		{Name: "primary", Config: smsprovider.Config{Receipts: auth}},
+		{Name: "open"},
+	}}
+	body := []byte(`{"provider_msg_id":"p-1","status":"REJECTED"}`)
+
+	svc := service.NewDeliveryReportService(&mocks.MessageRepository{}, &mocks.TxLogRepository{},
+		&mocks.TxManager{}, newWebhookService(), cfg, logger)
+
+	headers := func(values map[string]string) func(string) string {
+		return func(name string) string { return values[name] }
+	}
+
+	t.Run("accepts a receipt signed with the provider's secret", func(t *testing.T) {
+		err := svc.AuthenticateReport("primary", headers(map[string]string{"X-Signature": auth.Sign(body)}), body)
+
+		assert.NoError(t, err)
+	})
+
+	invalid := []struct {
+		name     string
+		provider string
+		headers  map[string]string
+	}{
+		{name: "rejects an unsigned receipt", provider: "primary", headers: map[string]string{}},
+		{name: "rejects a forged signature", provider: "primary",
+			headers: map[string]string{"X-Signature": forged.Sign(body)}},
+		{name: "rejects an unknown provider", provider: "other",
+			headers: map[string]string{"X-Signature": auth.Sign(body)}},
+		{name: "rejects a provider without a secret", provider: "open",
+			headers: map[string]string{"X-Callback-Token": ""}},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.AuthenticateReport(tt.provider, headers(tt.headers), body)
+
+			var serviceErr service.Error
+			assert.True(t, errors.As(err, &serviceErr))
+			assert.Equal(t, constants.ErrCodeInvalidCallbackSignature, serviceErr.Code)
+		})
+	}
+}
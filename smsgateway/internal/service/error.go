@@ -13,12 +13,21 @@ var (
 	ErrMessageNotFound         = errors.New("MESSAGE_NOT_FOUND")
 	ErrMessageBeingProcessed   = errors.New("MESSAGE_BEING_PROCESSED")
 	ErrMessageAlreadyProcessed = errors.New("MESSAGE_ALREADY_PROCESSED")
+	ErrMessageExpired          = errors.New("MESSAGE_EXPIRED")
 	ErrUnknownMessageStatus    = errors.New("UNKNOWN_MESSAGE_STATUS")
 	ErrTxLogNotFound           = errors.New("TX_LOG_NOT_FOUND")
 	ErrTxInvalidState          = errors.New("REFUND_INVALID_STATE")
 	ErrRefundAlreadyProcessed  = errors.New("REFUND_ALREADY_PROCESSED")
 	ErrUnknownTxState          = errors.New("UNKNOWN_TX_STATE")
 	ErrDatabase                = errors.New("DATABASE_ERROR")
+	ErrInvalidCursor           = errors.New("INVALID_CURSOR")
+	ErrInvalidAPIKey           = errors.New("INVALID_API_KEY")
+	ErrSenderNotAllowed        = errors.New("SENDER_NOT_ALLOWED")
+	ErrPriorityNotAllowed      = errors.New("PRIORITY_NOT_ALLOWED")
+	ErrIdempotencyMismatch     = errors.New("IDEMPOTENCY_KEY_MISMATCH")
+	ErrRenderedTextLength      = errors.New("RENDERED_TEXT_LENGTH")
+	ErrRecipientSuppressed     = errors.New("RECIPIENT_SUPPRESSED")
+	ErrUnknownInboundProvider  = errors.New("UNKNOWN_INBOUND_PROVIDER")
 )
 
 type Error struct {
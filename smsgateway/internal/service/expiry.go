@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+)
+
+func isExpired(expiresAt *time.Time) bool {
+	return expiresAt != nil && time.Now().After(*expiresAt)
+}
+
+// expireMessage moves a message that never reached the provider to EXPIRED and fails its tx_log, which hands the
+// charge over to the refund publisher. The carrier reports EXPIRED too, for messages it accepted and could not
+// deliver in time; those keep their charge and a successful tx_log, so the VALIDITY_PERIOD_EXPIRED last_error set here
+// is what tells a local expiry apart.
+func expireMessage(ctx context.Context, txManager repository.TxManager, messageRepo repository.MessageRepository,
+	txLogRepo repository.TxLogRepository, messageID int64) error {
+	msg := model.Message{
+		ID:        messageID,
+		Status:    model.MessageStatusExpired,
+		UpdatedAt: time.Now(),
+	}
+
+	lastError := constants.ErrCodeValidityPeriodExpired
+	txLog := model.TxLog{
+		MessageID:   messageID,
+		State:       model.TxLogStateFailed,
+		Published:   false,
+		PublishedAt: nil,
+		LastError:   &lastError,
+		UpdatedAt:   time.Now(),
+	}
+
+	return txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := messageRepo.UpdateForExpiry(ctx, &msg); err != nil {
+			return err
+		}
+
+		return txLogRepo.UpdateForPermFailed(ctx, &txLog)
+	})
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"go.uber.org/zap"
+)
+
+const (
+	InboundKeywordStop  = "STOP"
+	InboundKeywordStart = "START"
+)
+
+type InboundService interface {
+	ReceiveInbound(ctx context.Context, cmd ReceiveInboundCommand) (ReceiveInboundResponse, error)
+	ListInbound(ctx context.Context, query ListInboundQuery) (ListInboundResponse, error)
+}
+
+type inbound struct {
+	inboundRepo repository.InboundMessageRepository
+	accountRepo repository.AccountRepository
+	suppression SuppressionService
+	txManager   repository.TxManager
+	parsers     smsprovider.InboundParsers
+	auth        map[string]smsprovider.CallbackAuth
+	keywords    map[string]string
+	logger      *zap.Logger
+}
+
+func NewInboundService(inboundRepo repository.InboundMessageRepository, accountRepo repository.AccountRepository,
+	suppression SuppressionService, txManager repository.TxManager, parsers smsprovider.InboundParsers,
+	config *config.Config, logger *zap.Logger) InboundService {
+
+	keywords := make(map[string]string)
+	for _, keyword := range config.Inbound.StopKeywords {
+		keywords[strings.ToUpper(keyword)] = InboundKeywordStop
+	}
+	for _, keyword := range config.Inbound.StartKeywords {
+		keywords[strings.ToUpper(keyword)] = InboundKeywordStart
+	}
+
+	auth := make(map[string]smsprovider.CallbackAuth)
+	for name, provider := range config.Inbound.Providers {
+		auth[strings.ToLower(name)] = provider.Auth
+	}
+
+	return &inbound{inboundRepo: inboundRepo, accountRepo: accountRepo, suppression: suppression,
+		txManager: txManager, parsers: parsers, auth: auth, keywords: keywords, logger: logger}
+}
+
+// ReceiveInbound stores every message of a provider push. Messages the provider already pushed are counted as
+// duplicates and not processed again, so providers can safely retry a push that timed out. The push must carry the
+// provider's credential, since STOP and START keywords change who may be messaged.
+func (i *inbound) ReceiveInbound(ctx context.Context, cmd ReceiveInboundCommand) (ReceiveInboundResponse, error) {
+	parser, ok := i.parsers[cmd.Provider]
+	if !ok {
+		return ReceiveInboundResponse{}, NewServiceError(constants.ErrCodeInboundProviderNotFound,
+			ErrUnknownInboundProvider)
+	}
+
+	auth := i.auth[cmd.Provider]
+	credential := ""
+	if cmd.Header != nil {
+		credential = cmd.Header(auth.HeaderName())
+	}
+
+	if err := auth.Verify(credential, cmd.Body); err != nil {
+		i.logger.Warn("Inbound push failed authentication", zap.String("provider", cmd.Provider))
+		return ReceiveInboundResponse{}, NewServiceError(constants.ErrCodeInvalidCallbackSignature, err)
+	}
+
+	messages, err := parser.Parse(cmd.ContentType, cmd.Body)
+	if err != nil {
+		i.logger.Warn("Failed to parse inbound payload", zap.String("provider", cmd.Provider), zap.Error(err))
+		return ReceiveInboundResponse{}, NewServiceError(constants.ErrCodeInvalidInboundPayload, err)
+	}
+
+	accounts := make(map[string]*int64)
+
+	var response ReceiveInboundResponse
+	for _, msg := range messages {
+		accountID, cached := accounts[msg.To]
+		if !cached {
+			if accountID, err = i.receivingAccount(msg.To); err != nil {
+				return response, err
+			}
+			accounts[msg.To] = accountID
+		}
+
+		err := i.storeInbound(ctx, cmd.Provider, msg, accountID)
+		if errors.Is(err, repository.ErrInboundDuplicate) {
+			i.logger.Info("Ignoring duplicate inbound message",
+				zap.String("provider", cmd.Provider),
+				zap.String("providerMsgID", msg.ProviderMsgID))
+			response.Duplicates++
+			continue
+		}
+
+		if err != nil {
+			i.logger.Error("Failed to store inbound message",
+				zap.String("provider", cmd.Provider),
+				zap.String("from", msg.From),
+				zap.String("to", msg.To),
+				zap.Error(err))
+
+			var serviceErr Error
+			if !errors.As(err, &serviceErr) {
+				err = NewServiceError(ErrCodeDatabase, err)
+			}
+			return response, err
+		}
+
+		response.Received++
+	}
+
+	return response, nil
+}
+
+func (i *inbound) ListInbound(ctx context.Context, query ListInboundQuery) (ListInboundResponse, error) {
+	filter := repository.InboundFilter{AccountID: query.AccountID, ToMSISDN: query.ToMSISDN}
+
+	messages, err := i.inboundRepo.FindByFilter(filter, query.BeforeID, query.Limit+1)
+	if err != nil {
+		i.logger.Error("Failed to list inbound messages", zap.Int64("accountID", query.AccountID), zap.Error(err))
+		return ListInboundResponse{}, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	var response ListInboundResponse
+	if len(messages) > query.Limit {
+		messages = messages[:query.Limit]
+		response.NextBeforeID = messages[len(messages)-1].ID
+	}
+
+	response.Messages = make([]InboundMessageDetails, len(messages))
+	for n, msg := range messages {
+		response.Messages[n] = InboundMessageDetails{
+			ID:            msg.ID,
+			Provider:      msg.Provider,
+			ProviderMsgID: msg.ProviderMsgID,
+			From:          msg.FromMSISDN,
+			To:            msg.ToMSISDN,
+			Text:          msg.Text,
+			Keyword:       msg.Keyword,
+			ReceivedAt:    msg.ReceivedAt.Format(timeFormat),
+		}
+	}
+
+	return response, nil
+}
+
+// receivingAccount finds the account that owns the receiving number, accepting it with or without the leading '+'.
+// Numbers no account owns are still stored, without an account.
+func (i *inbound) receivingAccount(to string) (*int64, error) {
+	normalized := normalizeMSISDN(to)
+
+	account, err := i.accountRepo.GetBySender([]string{normalized, "+" + normalized})
+	if errors.Is(err, repository.ErrAccountNotFound) {
+		i.logger.Warn("Inbound message for a number no account owns", zap.String("to", to))
+		return nil, nil
+	}
+
+	if err != nil {
+		i.logger.Error("Failed to resolve inbound account", zap.String("to", to), zap.Error(err))
+		return nil, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	return &account.ID, nil
+}
+
+// storeInbound saves the message and applies its opt-out keyword in one transaction, so a push that fails halfway
+// is retried as a whole rather than leaving the message stored but the opt-out unapplied.
+func (i *inbound) storeInbound(ctx context.Context, provider string, msg smsprovider.InboundMessage,
+	accountID *int64) error {
+
+	record := model.InboundMessage{
+		AccountID:  accountID,
+		Provider:   provider,
+		FromMSISDN: msg.From,
+		ToMSISDN:   msg.To,
+		Text:       msg.Text,
+		ReceivedAt: time.Now(),
+		CreatedAt:  time.Now(),
+	}
+
+	if msg.ProviderMsgID != "" {
+		record.ProviderMsgID = &msg.ProviderMsgID
+	}
+
+	if msg.ReceivedAt != nil {
+		record.ReceivedAt = *msg.ReceivedAt
+	}
+
+	keyword, matched := i.keyword(msg.Text)
+	if matched && accountID != nil {
+		record.Keyword = &keyword
+	}
+
+	return i.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := i.inboundRepo.Create(ctx, &record); err != nil {
+			return err
+		}
+
+		if record.Keyword == nil {
+			return nil
+		}
+
+		return i.applyKeyword(ctx, provider, *accountID, keyword, msg)
+	})
+}
+
+// keyword matches the whole message, ignoring case, surrounding space and trailing punctuation, so a message that
+// merely mentions "stop" in a sentence does not opt the sender out.
+func (i *inbound) keyword(text string) (string, bool) {
+	word := strings.ToUpper(strings.TrimRight(strings.TrimSpace(text), ".!"))
+	keyword, ok := i.keywords[word]
+	return keyword, ok
+}
+
+func (i *inbound) applyKeyword(ctx context.Context, provider string, accountID int64, keyword string,
+	msg smsprovider.InboundMessage) error {
+
+	var err error
+	switch keyword {
+	case InboundKeywordStop:
+		_, err = i.suppression.AddSuppression(ctx, AddSuppressionCommand{
+			AccountID: &accountID,
+			MSISDN:    msg.From,
+			Reason:    fmt.Sprintf("inbound %s to %s", strings.TrimSpace(msg.Text), msg.To),
+			AddedBy:   "inbound:" + provider,
+		})
+		err = ignoreErrorCode(err, constants.ErrCodeSuppressionExists)
+	case InboundKeywordStart:
+		err = i.suppression.RemoveSuppression(ctx, RemoveSuppressionCommand{AccountID: &accountID, MSISDN: msg.From})
+		err = ignoreErrorCode(err, constants.ErrCodeSuppressionNotFound)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	i.logger.Info("Applied inbound opt-out keyword",
+		zap.String("keyword", keyword),
+		zap.String("from", msg.From),
+		zap.Int64("accountID", accountID))
+
+	return nil
+}
+
+// ignoreErrorCode treats a service error with the given code as success, for operations that are already in the
+// requested state.
+func ignoreErrorCode(err error, code string) error {
+	var serviceErr Error
+	if errors.As(err, &serviceErr) && serviceErr.Code == code {
+		return nil
+	}
+	return err
+}
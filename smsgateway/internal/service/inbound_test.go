@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestInbound_ReceiveInbound(t *testing.T) {
+	logger := zap.NewNop()
+	auth := smsprovider.CallbackAuth{Type: smsprovider.CallbackAuthHMAC, Secret: "s3cret"}
+	providers := map[string]smsprovider.InboundConfig{"default": {Auth: auth}}
+	cfg := &config.Config{Inbound: config.Inbound{StopKeywords: []string{"STOP"}, StartKeywords: []string{"START"},
+		Providers: providers}}
+
+	parsers, err := smsprovider.NewInboundParsers(providers)
+	assert.NoError(t, err)
+
+	receive := func(body string) service.ReceiveInboundCommand {
+		signature := auth.Sign([]byte(body))
+		return service.ReceiveInboundCommand{Provider: "default", ContentType: "application/json", Body: []byte(body),
+			Header: func(name string) string {
+				if name == "X-Signature" {
+					return signature
+				}
+				return ""
+			}}
+	}
+
+	t.Run("stores the message against the receiving account", func(t *testing.T) {
+		mockInboundRepo := &mocks.InboundMessageRepository{}
+		mockAccountRepo := &mocks.AccountRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockSuppression := &mocks.SuppressionService{}
+
+		svc := service.NewInboundService(mockInboundRepo, mockAccountRepo, mockSuppression, mockTxManager, parsers,
+			cfg, logger)
+
+		mockAccountRepo.On("GetBySender", []string{"983000", "+983000"}).Return(&model.Account{ID: 7}, nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockInboundRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(msg *model.InboundMessage) bool {
+				return *msg.AccountID == 7 && *msg.ProviderMsgID == "p-1" && msg.Text == "hello" && msg.Keyword == nil
+			})).Return(nil)
+
+		resp, err := svc.ReceiveInbound(context.Background(),
+			receive(`{"message_id": "p-1", "from": "989121111111", "to": "+983000", "text": "hello"}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.ReceiveInboundResponse{Received: 1}, resp)
+		mockInboundRepo.AssertExpectations(t)
+		mockSuppression.AssertNotCalled(t, "AddSuppression")
+	})
+
+	t.Run("suppresses the sender on STOP", func(t *testing.T) {
+		mockInboundRepo := &mocks.InboundMessageRepository{}
+		mockAccountRepo := &mocks.AccountRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockSuppression := &mocks.SuppressionService{}
+
+		svc := service.NewInboundService(mockInboundRepo, mockAccountRepo, mockSuppression, mockTxManager, parsers,
+			cfg, logger)
+
+		mockAccountRepo.On("GetBySender", mock.Anything).Return(&model.Account{ID: 7}, nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockInboundRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(msg *model.InboundMessage) bool {
+				return *msg.Keyword == service.InboundKeywordStop
+			})).Return(nil)
+		mockSuppression.On("AddSuppression", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(cmd service.AddSuppressionCommand) bool {
+				return *cmd.AccountID == 7 && cmd.MSISDN == "989121111111" && cmd.AddedBy == "inbound:default"
+			})).Return(service.SuppressionDetails{},
+			service.NewServiceError(constants.ErrCodeSuppressionExists, repository.ErrSuppressionExists))
+
+		resp, err := svc.ReceiveInbound(context.Background(),
+			receive(`{"from": "989121111111", "to": "3000", "text": " stop! "}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, resp.Received)
+		mockSuppression.AssertExpectations(t)
+	})
+
+	t.Run("lifts the account suppression on START", func(t *testing.T) {
+		mockInboundRepo := &mocks.InboundMessageRepository{}
+		mockAccountRepo := &mocks.AccountRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockSuppression := &mocks.SuppressionService{}
+
+		svc := service.NewInboundService(mockInboundRepo, mockAccountRepo, mockSuppression, mockTxManager, parsers,
+			cfg, logger)
+
+		accountID := int64(7)
+		mockAccountRepo.On("GetBySender", mock.Anything).Return(&model.Account{ID: 7}, nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockInboundRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.AnythingOfType("*model.InboundMessage")).Return(nil)
+		mockSuppression.On("RemoveSuppression", mock.AnythingOfType("*context.valueCtx"),
+			service.RemoveSuppressionCommand{AccountID: &accountID, MSISDN: "989121111111"}).Return(nil)
+
+		_, err := svc.ReceiveInbound(context.Background(),
+			receive(`{"from": "989121111111", "to": "3000", "text": "Start"}`))
+
+		assert.NoError(t, err)
+		mockSuppression.AssertExpectations(t)
+	})
+
+	t.Run("does not reprocess a duplicate push", func(t *testing.T) {
+		mockInboundRepo := &mocks.InboundMessageRepository{}
+		mockAccountRepo := &mocks.AccountRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockSuppression := &mocks.SuppressionService{}
+
+		svc := service.NewInboundService(mockInboundRepo, mockAccountRepo, mockSuppression, mockTxManager, parsers,
+			cfg, logger)
+
+		mockAccountRepo.On("GetBySender", mock.Anything).Return(&model.Account{ID: 7}, nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockInboundRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.AnythingOfType("*model.InboundMessage")).Return(repository.ErrInboundDuplicate)
+
+		resp, err := svc.ReceiveInbound(context.Background(),
+			receive(`{"message_id": "p-1", "from": "989121111111", "to": "3000", "text": "STOP"}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.ReceiveInboundResponse{Duplicates: 1}, resp)
+		mockSuppression.AssertNotCalled(t, "AddSuppression")
+	})
+
+	t.Run("stores without keyword handling when no account owns the number", func(t *testing.T) {
+		mockInboundRepo := &mocks.InboundMessageRepository{}
+		mockAccountRepo := &mocks.AccountRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockSuppression := &mocks.SuppressionService{}
+
+		svc := service.NewInboundService(mockInboundRepo, mockAccountRepo, mockSuppression, mockTxManager, parsers,
+			cfg, logger)
+
+		mockAccountRepo.On("GetBySender", mock.Anything).Return((*model.Account)(nil), repository.ErrAccountNotFound)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockInboundRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(msg *model.InboundMessage) bool {
+				return msg.AccountID == nil && msg.Keyword == nil
+			})).Return(nil)
+
+		resp, err := svc.ReceiveInbound(context.Background(),
+			receive(`{"from": "989121111111", "to": "3000", "text": "STOP"}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, resp.Received)
+		mockSuppression.AssertNotCalled(t, "AddSuppression")
+	})
+
+	t.Run("rejects unknown providers and malformed payloads", func(t *testing.T) {
+		svc := service.NewInboundService(&mocks.InboundMessageRepository{}, &mocks.AccountRepository{},
+			&mocks.SuppressionService{}, &mocks.TxManager{}, parsers, cfg, logger)
+
+		_, err := svc.ReceiveInbound(context.Background(), service.ReceiveInboundCommand{Provider: "acme"})
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeInboundProviderNotFound, serviceErr.Code)
+
+		_, err = svc.ReceiveInbound(context.Background(), receive(`{"text": "no numbers"}`))
+
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeInvalidInboundPayload, serviceErr.Code)
+	})
+	t.Run("rejects pushes without a valid signature before parsing them", func(t *testing.T) {
+		mockSuppression := &mocks.SuppressionService{}
+		svc := service.NewInboundService(&mocks.InboundMessageRepository{}, &mocks.AccountRepository{},
+			mockSuppression, &mocks.TxManager{}, parsers, cfg, logger)
+
+		forged := receive(`{"message_id": "p-9", "from": "989121111111", "to": "+983000", "text": "START"}`)
+		forged.Header = func(string) string { return "" }
+
+		_, err := svc.ReceiveInbound(context.Background(), forged)
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeInvalidCallbackSignature, serviceErr.Code)
+		mockSuppression.AssertNotCalled(t, "RemoveSuppression")
+	})
+}
@@ -2,48 +2,91 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
-	"fmt"
 	"time"
 
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
 	"github.com/Behyna/sms-services/smsgateway/internal/constants"
 	"github.com/Behyna/sms-services/smsgateway/internal/model"
 	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/pkg/segmentation"
 	"go.uber.org/zap"
 )
 
+const timeFormat = "2006-01-02T15:04:05Z"
+
+const (
+	RefundStateNone     = "NONE"
+	RefundStatePending  = "PENDING"
+	RefundStateRefunded = "REFUNDED"
+)
+
 type MessageService interface {
 	CreateMessage(ctx context.Context, cmd CreateMessageCommand) (CreateMessageResponse, error)
+	ReplayMessage(ctx context.Context, cmd CreateMessageCommand) (CreateMessageResponse, bool, error)
+	CreateMessageBatch(ctx context.Context, cmd CreateMessageBatchCommand) (CreateMessageBatchResponse, error)
 	GetMessagesByUserID(ctx context.Context, cmd GetMessagesQuery) (GetMessagesResponse, error)
+	GetMessageByID(ctx context.Context, id int64) (MessageDetails, error)
+	GetMessageByClientMessageID(ctx context.Context, query GetMessageQuery) (MessageDetails, error)
+	CancelMessage(ctx context.Context, id int64) (CancelMessageResponse, error)
 }
 
 type message struct {
-	messageRepo repository.MessageRepository
-	txLogRepo   repository.TxLogRepository
-	txManager   repository.TxManager
-	payment     PaymentService
-	logger      *zap.Logger
+	messageRepo     repository.MessageRepository
+	txLogRepo       repository.TxLogRepository
+	txManager       repository.TxManager
+	payment         PaymentService
+	suppression     SuppressionService
+	defaultValidity time.Duration
+	logger          *zap.Logger
 }
 
 func NewMessageService(messageRepo repository.MessageRepository, txLogRepo repository.TxLogRepository,
-	txManager repository.TxManager, payment PaymentService, logger *zap.Logger) MessageService {
-	return &message{messageRepo: messageRepo, txLogRepo: txLogRepo, txManager: txManager, payment: payment, logger: logger}
+	txManager repository.TxManager, payment PaymentService, suppression SuppressionService, config *config.Config,
+	logger *zap.Logger) MessageService {
+	return &message{messageRepo: messageRepo, txLogRepo: txLogRepo, txManager: txManager, payment: payment,
+		suppression: suppression, defaultValidity: config.Message.DefaultValidityPeriod, logger: logger}
 }
 
 func (m *message) CreateMessage(ctx context.Context, cmd CreateMessageCommand) (
 	CreateMessageResponse, error) {
 
-	idempotencyKey := fmt.Sprintf("charge-%s-%s", cmd.FromMSISDN, cmd.ClientMessageID)
-	request := ChargePaymentCommand{UserID: cmd.FromMSISDN, Amount: 1, IdempotencyKey: idempotencyKey}
+	if resp, found, err := m.replayMessage(cmd); found || err != nil {
+		return resp, err
+	}
 
-	err := m.payment.Charge(ctx, request)
+	suppressed, err := m.suppression.SuppressedRecipients(ctx, cmd.AccountID, []string{cmd.ToMSISDN})
+	if err != nil {
+		return CreateMessageResponse{}, err
+	}
+
+	if suppressed[cmd.ToMSISDN] {
+		m.logger.Info("Message rejected for suppressed recipient",
+			zap.String("clientMessageID", cmd.ClientMessageID),
+			zap.String("to", cmd.ToMSISDN))
+		return CreateMessageResponse{}, NewServiceError(constants.ErrCodeRecipientSuppressed, ErrRecipientSuppressed)
+	}
+
+	segments := segmentation.Calculate(cmd.Text)
+
+	if cmd.ValidityPeriod == 0 {
+		cmd.ValidityPeriod = m.defaultValidity
+	}
+
+	idempotencyKey := messageIdempotencyKey("charge-", cmd.FromMSISDN, cmd.ClientMessageID)
+	request := ChargePaymentCommand{UserID: cmd.FromMSISDN, Amount: int64(segments.Segments), IdempotencyKey: idempotencyKey}
+
+	err = m.payment.Charge(ctx, request)
 	if err != nil {
 		m.logger.Debug("Message creation aborted due to payment failure",
 			zap.String("clientMessageID", cmd.ClientMessageID))
 		return CreateMessageResponse{}, err
 	}
 
-	resp, err := m.createMessageTx(ctx, cmd)
+	resp, err := m.createMessageTx(ctx, cmd, segments)
 	if err == nil {
 		m.logger.Info("Message created successfully",
 			zap.Int64("messageID", resp.MessageID),
@@ -51,10 +94,18 @@ func (m *message) CreateMessage(ctx context.Context, cmd CreateMessageCommand) (
 		return resp, nil
 	}
 
+	// A concurrent request with the same client message id won the insert. Both shared the charge idempotency key, so
+	// there is exactly one charge and it belongs to the stored message: refunding here would undo it.
+	if errors.Is(err, repository.ErrMessageDuplicate) {
+		if resp, found, replayErr := m.replayMessage(cmd); found || replayErr != nil {
+			return resp, replayErr
+		}
+	}
+
 	m.logger.Error("Critical: Payment succeeded but message creation failed, initiating refund",
 		zap.String("clientMessageID", cmd.ClientMessageID))
 
-	idempotencyKey = fmt.Sprintf("refund-%s-%s", cmd.FromMSISDN, cmd.ClientMessageID)
+	idempotencyKey = messageIdempotencyKey("refund-", cmd.FromMSISDN, cmd.ClientMessageID)
 	refundReq := RefundPaymentCommand{UserID: cmd.FromMSISDN, Amount: request.Amount, IdempotencyKey: idempotencyKey}
 
 	refundErr := m.payment.Refund(ctx, refundReq)
@@ -71,70 +122,173 @@ func (m *message) CreateMessage(ctx context.Context, cmd CreateMessageCommand) (
 }
 
 func (m *message) GetMessagesByUserID(ctx context.Context, cmd GetMessagesQuery) (GetMessagesResponse, error) {
-	messages, err := m.messageRepo.GetByUserID(cmd.UserID, cmd.Limit, cmd.Offset)
+	cursor, err := decodeCursor(cmd.Cursor)
 	if err != nil {
-		m.logger.Error("Failed to get messages by user ID",
-			zap.String("user_id", cmd.UserID),
-			zap.Error(err))
-		return GetMessagesResponse{}, ErrDatabase
+		return GetMessagesResponse{}, NewServiceError(constants.ErrCodeInvalidCursor, err)
 	}
 
-	if len(messages) == 0 {
-		return GetMessagesResponse{}, NewServiceError(constants.ErrCodeUserNotFound, err)
+	filter := repository.MessageFilter{
+		FromMSISDN:            cmd.UserID,
+		Status:                cmd.Status,
+		ToMSISDN:              cmd.ToMSISDN,
+		CreatedFrom:           cmd.CreatedFrom,
+		CreatedTo:             cmd.CreatedTo,
+		ClientMessageIDPrefix: cmd.ClientMessageIDPrefix,
 	}
 
-	total, err := m.messageRepo.CountByUserID(cmd.UserID)
+	// One extra row tells whether another page exists without a count query.
+	messages, err := m.messageRepo.FindByFilter(filter, cursor, cmd.Limit+1)
 	if err != nil {
-		m.logger.Error("Failed to count messages by user ID",
+		m.logger.Error("Failed to get messages by user ID",
 			zap.String("user_id", cmd.UserID),
 			zap.Error(err))
 		return GetMessagesResponse{}, ErrDatabase
 	}
 
-	responseMessages := make([]Message, len(messages))
+	if len(messages) == 0 && cursor == nil && filter == (repository.MessageFilter{FromMSISDN: cmd.UserID}) {
+		return GetMessagesResponse{}, NewServiceError(constants.ErrCodeUserNotFound, ErrMessageNotFound)
+	}
+
+	var response GetMessagesResponse
+	if len(messages) > cmd.Limit {
+		messages = messages[:cmd.Limit]
+		response.NextCursor = encodeCursor(messages[len(messages)-1])
+	}
+
+	if cmd.IncludeTotal {
+		total, err := m.messageRepo.CountByFilter(filter)
+		if err != nil {
+			m.logger.Error("Failed to count messages by user ID",
+				zap.String("user_id", cmd.UserID),
+				zap.Error(err))
+			return GetMessagesResponse{}, ErrDatabase
+		}
+
+		response.Total = &total
+	}
+
+	response.Messages = make([]Message, len(messages))
 	for i, msg := range messages {
-		responseMessages[i] = Message{
+		response.Messages[i] = Message{
 			MessageID: msg.ClientMessageID,
 			From:      msg.FromMSISDN,
 			To:        msg.ToMSISDN,
 			Text:      msg.Text,
 			Status:    string(msg.Status),
-			CreatedAt: msg.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			CreatedAt: msg.CreatedAt.Format(timeFormat),
 		}
 	}
 
-	return GetMessagesResponse{
-		Messages: responseMessages,
-		Total:    int64(total),
-	}, nil
+	return response, nil
 }
 
-func (m *message) createMessageTx(ctx context.Context, cmd CreateMessageCommand) (CreateMessageResponse, error) {
-	message := model.Message{
-		ClientMessageID: cmd.ClientMessageID,
-		FromMSISDN:      cmd.FromMSISDN,
-		ToMSISDN:        cmd.ToMSISDN,
-		Text:            cmd.Text,
-		Status:          model.MessageStatusCreated,
-		AttemptCount:    0,
-		LastAttemptAt:   nil,
-		Provider:        nil,
-		ProviderMsgID:   nil,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
+func (m *message) GetMessageByID(ctx context.Context, id int64) (MessageDetails, error) {
+	msg, err := m.messageRepo.GetByID(id)
+	if err != nil {
+		return MessageDetails{}, m.lookupError(err, zap.Int64("messageID", id))
 	}
 
-	txLog := model.TxLog{
-		FromMSISDN:  cmd.FromMSISDN,
-		Amount:      1,
-		State:       model.TxLogStateCreated,
-		Published:   false,
-		PublishedAt: nil,
-		LastError:   nil,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	return m.messageDetails(msg)
+}
+
+func (m *message) GetMessageByClientMessageID(ctx context.Context, query GetMessageQuery) (MessageDetails, error) {
+	msg, err := m.messageRepo.GetByClientMessageID(query.FromMSISDN, query.ClientMessageID)
+	if err != nil {
+		return MessageDetails{}, m.lookupError(err,
+			zap.String("fromMSISDN", query.FromMSISDN),
+			zap.String("clientMessageID", query.ClientMessageID))
+	}
+
+	return m.messageDetails(msg)
+}
+
+func (m *message) lookupError(err error, fields ...zap.Field) error {
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return NewServiceError(constants.ErrCodeMessageNotFound, err)
+	}
+
+	m.logger.Error("Failed to get message", append(fields, zap.Error(err))...)
+	return NewServiceError(ErrCodeDatabase, err)
+}
+
+func (m *message) messageDetails(msg *model.Message) (MessageDetails, error) {
+	details := MessageDetails{
+		ID:              msg.ID,
+		ClientMessageID: msg.ClientMessageID,
+		BatchID:         msg.BatchID,
+		TemplateID:      msg.TemplateID,
+		TemplateVersion: msg.TemplateVersion,
+		From:            msg.FromMSISDN,
+		To:              msg.ToMSISDN,
+		Text:            msg.Text,
+		Status:          string(msg.Status),
+		SegmentCount:    msg.SegmentCount,
+		Encoding:        msg.Encoding,
+		AttemptCount:    msg.AttemptCount,
+		Provider:        msg.Provider,
+		ProviderMsgID:   msg.ProviderMsgID,
+		CreatedAt:       msg.CreatedAt.Format(timeFormat),
+		UpdatedAt:       msg.UpdatedAt.Format(timeFormat),
+	}
+
+	if msg.LastAttemptAt != nil {
+		lastAttemptAt := msg.LastAttemptAt.Format(timeFormat)
+		details.LastAttemptAt = &lastAttemptAt
+	}
+
+	if msg.ReportedAt != nil {
+		reportedAt := msg.ReportedAt.Format(timeFormat)
+		details.ReportedAt = &reportedAt
+	}
+
+	if msg.SendAt != nil {
+		sendAt := msg.SendAt.Format(timeFormat)
+		details.SendAt = &sendAt
+	}
+
+	if msg.ExpiresAt != nil {
+		expiresAt := msg.ExpiresAt.Format(timeFormat)
+		details.ExpiresAt = &expiresAt
+	}
+
+	txLog, err := m.txLogRepo.GetByMessageID(msg.ID)
+	if errors.Is(err, repository.ErrTxLogNotFound) {
+		return details, nil
+	}
+
+	if err != nil {
+		m.logger.Error("Failed to get transaction log for message",
+			zap.Int64("messageID", msg.ID),
+			zap.Error(err))
+		return MessageDetails{}, NewServiceError(ErrCodeDatabase, err)
 	}
 
+	details.LastError = txLog.LastError
+	details.Charge = &ChargeDetails{
+		Amount:      txLog.Amount,
+		State:       txLog.State,
+		RefundState: refundState(txLog.State),
+	}
+
+	return details, nil
+}
+
+func refundState(txLogState string) string {
+	switch txLogState {
+	case model.TxLogStateFailed:
+		return RefundStatePending
+	case model.TxLogStateRefunded:
+		return RefundStateRefunded
+	default:
+		return RefundStateNone
+	}
+}
+
+func (m *message) createMessageTx(ctx context.Context, cmd CreateMessageCommand, segments segmentation.Result) (
+	CreateMessageResponse, error) {
+	message := newMessage(cmd, segments)
+	txLog := newTxLog(message)
+
 	err := m.txManager.WithTx(ctx, func(ctx context.Context) error {
 		err := m.messageRepo.Create(ctx, &message)
 		if err != nil && errors.Is(err, repository.ErrMessageDuplicate) {
@@ -166,5 +320,154 @@ func (m *message) createMessageTx(ctx context.Context, cmd CreateMessageCommand)
 		return CreateMessageResponse{}, err
 	}
 
-	return CreateMessageResponse{MessageID: message.ID}, nil
+	return CreateMessageResponse{MessageID: message.ID, Status: string(message.Status)}, nil
+}
+
+// ReplayMessage answers a resubmission of an already stored message without creating anything, so callers can skip
+// work, such as spending rate limit tokens, that only a new message needs. It reports false when no message is stored
+// under the command's client message id.
+func (m *message) ReplayMessage(ctx context.Context, cmd CreateMessageCommand) (CreateMessageResponse, bool, error) {
+	return m.replayMessage(cmd)
+}
+
+// replayMessage looks up a message already stored under the command's client message id. A resubmission of the same
+// payload is answered with the stored message; any other payload is an idempotency key reuse.
+func (m *message) replayMessage(cmd CreateMessageCommand) (CreateMessageResponse, bool, error) {
+	msg, err := m.messageRepo.GetByClientMessageID(cmd.FromMSISDN, cmd.ClientMessageID)
+	if errors.Is(err, repository.ErrMessageNotFound) {
+		return CreateMessageResponse{}, false, nil
+	}
+
+	if err != nil {
+		m.logger.Error("Failed to look up message for idempotent replay",
+			zap.String("fromMSISDN", cmd.FromMSISDN),
+			zap.String("clientMessageID", cmd.ClientMessageID),
+			zap.Error(err))
+		return CreateMessageResponse{}, false, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	if !samePayload(msg, cmd) {
+		m.logger.Warn("Client message id reused with a different payload",
+			zap.Int64("messageID", msg.ID),
+			zap.String("clientMessageID", cmd.ClientMessageID))
+		return CreateMessageResponse{}, false, NewServiceError(constants.ErrCodeIdempotencyMismatch,
+			ErrIdempotencyMismatch)
+	}
+
+	m.logger.Info("Duplicate submission answered with existing message",
+		zap.Int64("messageID", msg.ID),
+		zap.String("clientMessageID", cmd.ClientMessageID))
+
+	return CreateMessageResponse{MessageID: msg.ID, Status: string(msg.Status), Duplicate: true}, true, nil
+}
+
+// messageIdempotencyKey hashes the sender and client message id, which identify a message, into a key that fits the
+// payment service however long the id is. Every path that charges or refunds one message derives its key here, so a
+// refund retried from another path is still recognised.
+func messageIdempotencyKey(prefix, fromMSISDN, clientMessageID string) string {
+	sum := sha256.Sum256([]byte(fromMSISDN + "\x00" + clientMessageID))
+	return prefix + hex.EncodeToString(sum[:])[:idempotencyKeyLength-len(prefix)]
+}
+
+// samePayload reports whether cmd resubmits msg. A template send is compared on the template, its version and the
+// variables it was rendered with rather than on the rendered text, which different variables can render to.
+func samePayload(msg *model.Message, cmd CreateMessageCommand) bool {
+	if msg.ToMSISDN != cmd.ToMSISDN {
+		return false
+	}
+
+	if msg.TemplateID == nil && cmd.TemplateID == nil {
+		return msg.Text == cmd.Text
+	}
+
+	if msg.TemplateID == nil || cmd.TemplateID == nil || *msg.TemplateID != *cmd.TemplateID {
+		return false
+	}
+
+	if msg.TemplateVersion == nil || cmd.TemplateVersion == nil || *msg.TemplateVersion != *cmd.TemplateVersion {
+		return false
+	}
+
+	return msg.TemplateVarHash != nil && *msg.TemplateVarHash == templateVariablesHash(cmd.TemplateVariables)
+}
+
+// templateVariablesHash digests variables independently of map order; encoding/json writes map keys sorted.
+func templateVariablesHash(variables map[string]string) string {
+	encoded, _ := json.Marshal(variables)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+func newMessage(cmd CreateMessageCommand, segments segmentation.Result) model.Message {
+	var expiresAt *time.Time
+	if cmd.ValidityPeriod > 0 {
+		validFrom := time.Now()
+		if cmd.SendAt != nil {
+			validFrom = *cmd.SendAt
+		}
+
+		expiry := validFrom.Add(cmd.ValidityPeriod)
+		expiresAt = &expiry
+	}
+
+	var accountID *int64
+	if cmd.AccountID != 0 {
+		accountID = &cmd.AccountID
+	}
+
+	priority := model.MessagePriority(cmd.Priority)
+	if priority == "" {
+		priority = model.MessagePriorityTransactional
+	}
+
+	var variablesHash *string
+	if cmd.TemplateID != nil {
+		hash := templateVariablesHash(cmd.TemplateVariables)
+		variablesHash = &hash
+	}
+
+	return model.Message{
+		AccountID:       accountID,
+		ClientMessageID: cmd.ClientMessageID,
+		FromMSISDN:      cmd.FromMSISDN,
+		ToMSISDN:        cmd.ToMSISDN,
+		Text:            cmd.Text,
+		TemplateID:      cmd.TemplateID,
+		TemplateVersion: cmd.TemplateVersion,
+		TemplateVarHash: variablesHash,
+		SegmentCount:    segments.Segments,
+		Encoding:        string(segments.Encoding),
+		Priority:        priority,
+		SendAt:          cmd.SendAt,
+		ExpiresAt:       expiresAt,
+		Status:          model.MessageStatusCreated,
+		AttemptCount:    0,
+		LastAttemptAt:   nil,
+		Provider:        nil,
+		ProviderMsgID:   nil,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+}
+
+// newTxLog builds the outbox log for msg. It copies the message's lane and the time it may be sent onto the log, so
+// publishers can claim ready logs lane by lane without reading the messages table.
+func newTxLog(msg model.Message) model.TxLog {
+	readyAt := time.Now()
+	if msg.SendAt != nil {
+		readyAt = *msg.SendAt
+	}
+
+	return model.TxLog{
+		FromMSISDN:  msg.FromMSISDN,
+		Amount:      msg.SegmentCount,
+		Priority:    msg.Priority,
+		ReadyAt:     readyAt,
+		State:       model.TxLogStateCreated,
+		Published:   false,
+		PublishedAt: nil,
+		LastError:   nil,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
 }
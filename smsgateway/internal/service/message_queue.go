@@ -2,33 +2,97 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
 	"time"
 
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
 	"github.com/Behyna/sms-services/smsgateway/internal/model"
 	"github.com/Behyna/sms-services/smsgateway/internal/repository"
 	"go.uber.org/zap"
 )
 
+const RefundQueue = "sms.refund"
+
+const defaultClaimLease = 2 * time.Minute
+
+const (
+	sendQueue     = "sms.send"
+	sendQueueOTP  = "sms.send.otp"
+	sendQueueBulk = "sms.send.bulk"
+)
+
+// SendQueue returns the queue for a priority lane. Transactional traffic keeps the original sms.send queue, so
+// messages queued before lanes existed are still consumed.
+func SendQueue(priority string) string {
+	switch model.MessagePriority(priority) {
+	case model.MessagePriorityOTP:
+		return sendQueueOTP
+	case model.MessagePriorityBulk:
+		return sendQueueBulk
+	default:
+		return sendQueue
+	}
+}
+
+// SendQueues returns every send lane's queue, most urgent first.
+func SendQueues() []string {
+	queues := make([]string, 0, len(model.MessagePriorities))
+	for _, priority := range model.MessagePriorities {
+		queues = append(queues, SendQueue(string(priority)))
+	}
+
+	return queues
+}
+
+// MessageQueueService drains the tx_log outbox. The Find methods claim what they return for this instance, so any
+// number of publisher replicas can run side by side without publishing the same log twice.
 type MessageQueueService interface {
 	FindMessagesToQueue(ctx context.Context, limit int) ([]SendMessageCommand, error)
 	MarkMessageAsQueued(ctx context.Context, messageID int64) error
+	MarkMessageAsExpired(ctx context.Context, messageID int64) error
 	FindRefundsToQueue(ctx context.Context, limit int) ([]ProcessRefundCommand, error)
 	MarkRefundAsQueued(ctx context.Context, txLogID int64) error
+	// ReleaseMessage and ReleaseRefund hand back a log that could not be published so the next poll retries it. A
+	// failed release is only logged: the claim then lapses when its lease runs out.
+	ReleaseMessage(ctx context.Context, messageID int64)
+	ReleaseRefund(ctx context.Context, txLogID int64)
 }
 
 type messageQueue struct {
-	txLog  repository.TxLogRepository
-	logger *zap.Logger
+	txLog     repository.TxLogRepository
+	message   repository.MessageRepository
+	txManager repository.TxManager
+	claim     repository.Claim
+	logger    *zap.Logger
 }
 
-func NewMessageQueueService(txLogRepo repository.TxLogRepository, logger *zap.Logger) MessageQueueService {
-	return &messageQueue{txLog: txLogRepo, logger: logger}
+func NewMessageQueueService(txLogRepo repository.TxLogRepository, messageRepo repository.MessageRepository,
+	txManager repository.TxManager, config *config.Config, logger *zap.Logger) MessageQueueService {
+	lease := config.Outbox.ClaimLease
+	if lease <= 0 {
+		lease = defaultClaimLease
+	}
+
+	return &messageQueue{txLog: txLogRepo, message: messageRepo, txManager: txManager,
+		claim: repository.Claim{Owner: instanceName(), Lease: lease}, logger: logger}
+}
+
+// instanceName names this publisher in the claims it takes, which tells operators which replica holds a log.
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
 }
 
 func (m *messageQueue) FindMessagesToQueue(ctx context.Context, limit int) ([]SendMessageCommand, error) {
 	m.logger.Debug("Finding messages to publish", zap.Int("batchSize", limit))
 
-	txLogs, err := m.txLog.FindUnpublishedCreated(limit)
+	txLogs, err := m.txLog.ClaimUnpublishedCreated(m.claim, time.Now(), limit)
 	if err != nil {
 		m.logger.Error("Failed to find unpublished messages", zap.Error(err))
 		return nil, err
@@ -46,6 +110,8 @@ func (m *messageQueue) FindMessagesToQueue(ctx context.Context, limit int) ([]Se
 			FromMSISDN: log.FromMSISDN,
 			ToMSISDN:   log.Message.ToMSISDN,
 			Text:       log.Message.Text,
+			ExpiresAt:  log.Message.ExpiresAt,
+			Priority:   string(log.Message.Priority),
 		}
 		messages = append(messages, msg)
 	}
@@ -63,7 +129,14 @@ func (m *messageQueue) MarkMessageAsQueued(ctx context.Context, messageID int64)
 		UpdatedAt:   time.Now(),
 	}
 
-	if err := m.txLog.UpdateByMessageID(ctx, &txLog); err != nil {
+	err := m.txLog.UpdateForPublished(ctx, &txLog, m.claim.Owner)
+	if errors.Is(err, repository.ErrNoRowsAffected) {
+		m.logger.Warn("Claim lapsed before the message was marked published; another publisher may publish it again",
+			zap.Int64("messageID", messageID))
+		return err
+	}
+
+	if err != nil {
 		m.logger.Error("Failed to update tx_log to published",
 			zap.Error(err),
 			zap.Int64("messageID", messageID))
@@ -76,10 +149,41 @@ func (m *messageQueue) MarkMessageAsQueued(ctx context.Context, messageID int64)
 	return nil
 }
 
+func (m *messageQueue) ReleaseMessage(ctx context.Context, messageID int64) {
+	if err := m.txLog.ReleaseClaim(ctx, &model.TxLog{MessageID: messageID}, m.claim.Owner); err != nil {
+		m.logger.Warn("Failed to release claim on message", zap.Int64("messageID", messageID), zap.Error(err))
+	}
+}
+
+func (m *messageQueue) ReleaseRefund(ctx context.Context, txLogID int64) {
+	if err := m.txLog.ReleaseClaim(ctx, &model.TxLog{ID: txLogID}, m.claim.Owner); err != nil {
+		m.logger.Warn("Failed to release claim on refund", zap.Int64("txLogID", txLogID), zap.Error(err))
+	}
+}
+
+func (m *messageQueue) MarkMessageAsExpired(ctx context.Context, messageID int64) error {
+	err := expireMessage(ctx, m.txManager, m.message, m.txLog, messageID)
+	if errors.Is(err, repository.ErrNoRowsAffected) {
+		m.logger.Info("Message changed state before it could be expired", zap.Int64("messageID", messageID))
+		return nil
+	}
+
+	if err != nil {
+		m.logger.Error("Failed to mark message as expired",
+			zap.Error(err),
+			zap.Int64("messageID", messageID))
+		return err
+	}
+
+	m.logger.Info("Expired message before publishing", zap.Int64("messageID", messageID))
+
+	return nil
+}
+
 func (m *messageQueue) FindRefundsToQueue(ctx context.Context, limit int) ([]ProcessRefundCommand, error) {
 	m.logger.Debug("Finding refunds to publish", zap.Int("batchSize", limit))
 
-	failedTxLogs, err := m.txLog.FindUnpublishedFailed(limit)
+	failedTxLogs, err := m.txLog.ClaimUnpublishedFailed(m.claim, limit)
 	if err != nil {
 		m.logger.Error("Failed to find unpublished failed transactions", zap.Error(err))
 		return nil, err
@@ -112,9 +216,17 @@ func (m *messageQueue) MarkRefundAsQueued(ctx context.Context, txLogID int64) er
 		ID:          txLogID,
 		Published:   true,
 		PublishedAt: &publishedAt,
+		UpdatedAt:   time.Now(),
 	}
 
-	if err := m.txLog.Update(&txLog); err != nil {
+	err := m.txLog.UpdateRefundForPublished(ctx, &txLog, m.claim.Owner)
+	if errors.Is(err, repository.ErrNoRowsAffected) {
+		m.logger.Warn("Claim lapsed before the refund was marked published; another publisher may publish it again",
+			zap.Int64("txLogID", txLogID))
+		return err
+	}
+
+	if err != nil {
 		m.logger.Error("Failed to mark refund tx as published",
 			zap.Error(err), zap.Int64("txLogID", txLogID))
 		return err
@@ -6,21 +6,50 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
 	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
 	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
 	"github.com/Behyna/sms-services/smsgateway/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 )
 
+var anyClaim = mock.AnythingOfType("repository.Claim")
+
+func TestSendQueue(t *testing.T) {
+	tests := []struct {
+		priority string
+		queue    string
+	}{
+		{priority: "otp", queue: "sms.send.otp"},
+		{priority: "transactional", queue: "sms.send"},
+		{priority: "bulk", queue: "sms.send.bulk"},
+		{priority: "", queue: "sms.send"},
+	}
+
+	for _, tt := range tests {
+		t.Run("routes "+tt.priority+" to "+tt.queue, func(t *testing.T) {
+			assert.Equal(t, tt.queue, service.SendQueue(tt.priority))
+		})
+	}
+
+	t.Run("lists every lane most urgent first", func(t *testing.T) {
+		assert.Equal(t, []string{"sms.send.otp", "sms.send", "sms.send.bulk"}, service.SendQueues())
+	})
+}
+
 func TestMessageQueue_FindMessagesToQueue(t *testing.T) {
 	logger := zap.NewNop()
+	cfg := &config.Config{}
 
 	t.Run("returns messages successfully", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
 		txLogs := []model.TxLog{
 			{
@@ -35,6 +64,7 @@ func TestMessageQueue_FindMessagesToQueue(t *testing.T) {
 					ToMSISDN:   "0987654321",
 					Text:       "Hello World",
 					FromMSISDN: "1234567890",
+					Priority:   model.MessagePriorityOTP,
 				},
 			},
 			{
@@ -53,7 +83,7 @@ func TestMessageQueue_FindMessagesToQueue(t *testing.T) {
 			},
 		}
 
-		mockTxLogRepo.On("FindUnpublishedCreated", 100).Return(txLogs, nil)
+		mockTxLogRepo.On("ClaimUnpublishedCreated", anyClaim, mock.AnythingOfType("time.Time"), 100).Return(txLogs, nil)
 
 		messages, err := svc.FindMessagesToQueue(context.Background(), 100)
 
@@ -64,6 +94,7 @@ func TestMessageQueue_FindMessagesToQueue(t *testing.T) {
 		assert.Equal(t, "1234567890", messages[0].FromMSISDN)
 		assert.Equal(t, "0987654321", messages[0].ToMSISDN)
 		assert.Equal(t, "Hello World", messages[0].Text)
+		assert.Equal(t, "otp", messages[0].Priority)
 
 		assert.Equal(t, int64(102), messages[1].MessageID)
 		assert.Equal(t, "1111111111", messages[1].FromMSISDN)
@@ -76,9 +107,11 @@ func TestMessageQueue_FindMessagesToQueue(t *testing.T) {
 	t.Run("returns empty slice when no messages found", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
-		mockTxLogRepo.On("FindUnpublishedCreated", 100).Return([]model.TxLog{}, nil)
+		mockTxLogRepo.On("ClaimUnpublishedCreated", anyClaim, mock.AnythingOfType("time.Time"), 100).
+			Return([]model.TxLog{}, nil)
 
 		messages, err := svc.FindMessagesToQueue(context.Background(), 100)
 
@@ -91,10 +124,12 @@ func TestMessageQueue_FindMessagesToQueue(t *testing.T) {
 	t.Run("returns error when repository fails", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
 		dbError := errors.New("database connection failed")
-		mockTxLogRepo.On("FindUnpublishedCreated", 100).Return([]model.TxLog{}, dbError)
+		mockTxLogRepo.On("ClaimUnpublishedCreated", anyClaim, mock.AnythingOfType("time.Time"), 100).
+			Return([]model.TxLog{}, dbError)
 
 		messages, err := svc.FindMessagesToQueue(context.Background(), 100)
 
@@ -108,33 +143,118 @@ func TestMessageQueue_FindMessagesToQueue(t *testing.T) {
 	t.Run("respects batch size limit", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
-		mockTxLogRepo.On("FindUnpublishedCreated", 50).Return([]model.TxLog{}, nil)
+		mockTxLogRepo.On("ClaimUnpublishedCreated", anyClaim, mock.AnythingOfType("time.Time"), 50).
+			Return([]model.TxLog{}, nil)
 
 		_, err := svc.FindMessagesToQueue(context.Background(), 50)
 
 		assert.NoError(t, err)
 		mockTxLogRepo.AssertExpectations(t)
-		mockTxLogRepo.AssertCalled(t, "FindUnpublishedCreated", 50)
+		mockTxLogRepo.AssertCalled(t, "ClaimUnpublishedCreated", anyClaim, mock.AnythingOfType("time.Time"), 50)
+	})
+
+	t.Run("claims for this instance with the configured lease", func(t *testing.T) {
+		mockTxLogRepo := &mocks.TxLogRepository{}
+
+		leaseCfg := &config.Config{Outbox: config.Outbox{ClaimLease: 30 * time.Second}}
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			leaseCfg, logger)
+
+		mockTxLogRepo.On("ClaimUnpublishedCreated", mock.MatchedBy(func(claim repository.Claim) bool {
+			return claim.Owner != "" && claim.Lease == 30*time.Second
+		}), mock.AnythingOfType("time.Time"), 100).Return([]model.TxLog{}, nil)
+
+		_, err := svc.FindMessagesToQueue(context.Background(), 100)
+
+		assert.NoError(t, err)
+		mockTxLogRepo.AssertExpectations(t)
+	})
+
+	t.Run("falls back to the default lease", func(t *testing.T) {
+		mockTxLogRepo := &mocks.TxLogRepository{}
+
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
+
+		mockTxLogRepo.On("ClaimUnpublishedCreated", mock.MatchedBy(func(claim repository.Claim) bool {
+			return claim.Lease == 2*time.Minute
+		}), mock.AnythingOfType("time.Time"), 100).Return([]model.TxLog{}, nil)
+
+		_, err := svc.FindMessagesToQueue(context.Background(), 100)
+
+		assert.NoError(t, err)
+		mockTxLogRepo.AssertExpectations(t)
+	})
+}
+
+func TestMessageQueue_MarkMessageAsExpired(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.Config{}
+
+	t.Run("expires message and fails its tx_log", func(t *testing.T) {
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxManager := &mocks.TxManager{}
+
+		svc := service.NewMessageQueueService(mockTxLogRepo, mockMessageRepo, mockTxManager, cfg, logger)
+
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("UpdateForExpiry", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(msg *model.Message) bool {
+				return msg.ID == 101 && msg.Status == model.MessageStatusExpired
+			})).Return(nil)
+		mockTxLogRepo.On("UpdateForPermFailed", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(txLog *model.TxLog) bool {
+				return txLog.MessageID == 101 && txLog.State == model.TxLogStateFailed &&
+					*txLog.LastError == constants.ErrCodeValidityPeriodExpired
+			})).Return(nil)
+
+		err := svc.MarkMessageAsExpired(context.Background(), 101)
+
+		assert.NoError(t, err)
+		mockMessageRepo.AssertExpectations(t)
+		mockTxLogRepo.AssertExpectations(t)
+	})
+
+	t.Run("ignores message that already moved on", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxManager := &mocks.TxManager{}
+
+		svc := service.NewMessageQueueService(&mocks.TxLogRepository{}, mockMessageRepo, mockTxManager, cfg, logger)
+
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("UpdateForExpiry", mock.Anything, mock.Anything).Return(repository.ErrNoRowsAffected)
+
+		err := svc.MarkMessageAsExpired(context.Background(), 101)
+
+		assert.NoError(t, err)
 	})
 }
 
 func TestMessageQueue_MarkMessageAsQueued(t *testing.T) {
 	logger := zap.NewNop()
+	cfg := &config.Config{}
 
 	t.Run("marks message as queued successfully", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
-		mockTxLogRepo.On("UpdateByMessageID", context.Background(),
+		mockTxLogRepo.On("UpdateForPublished", context.Background(),
 			mock.MatchedBy(func(txLog *model.TxLog) bool {
 				return txLog.MessageID == 123 &&
 					txLog.State == model.TxLogStatePending &&
 					txLog.Published == true &&
-					txLog.PublishedAt != nil
-			})).Return(nil)
+					txLog.PublishedAt != nil &&
+					txLog.ClaimedBy == nil &&
+					txLog.ClaimedUntil == nil
+			}), mock.AnythingOfType("string")).Return(nil)
 
 		err := svc.MarkMessageAsQueued(context.Background(), 123)
 
@@ -145,13 +265,14 @@ func TestMessageQueue_MarkMessageAsQueued(t *testing.T) {
 	t.Run("returns error when repository update fails", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
 		dbError := errors.New("database update failed")
-		mockTxLogRepo.On("UpdateByMessageID", context.Background(),
+		mockTxLogRepo.On("UpdateForPublished", context.Background(),
 			mock.MatchedBy(func(txLog *model.TxLog) bool {
 				return txLog.MessageID == 123
-			})).Return(dbError)
+			}), mock.AnythingOfType("string")).Return(dbError)
 
 		err := svc.MarkMessageAsQueued(context.Background(), 123)
 
@@ -162,13 +283,33 @@ func TestMessageQueue_MarkMessageAsQueued(t *testing.T) {
 	})
 }
 
+func TestMessageQueue_ReleaseMessage(t *testing.T) {
+	t.Run("releases this instance's claim on the message's log", func(t *testing.T) {
+		mockTxLogRepo := &mocks.TxLogRepository{}
+
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			&config.Config{}, zap.NewNop())
+
+		mockTxLogRepo.On("ReleaseClaim", context.Background(),
+			mock.MatchedBy(func(txLog *model.TxLog) bool {
+				return txLog.MessageID == 123 && txLog.ID == 0
+			}), mock.AnythingOfType("string")).Return(errors.New("connection lost"))
+
+		svc.ReleaseMessage(context.Background(), 123)
+
+		mockTxLogRepo.AssertExpectations(t)
+	})
+}
+
 func TestMessageQueue_FindRefundsToQueue(t *testing.T) {
 	logger := zap.NewNop()
+	cfg := &config.Config{}
 
 	t.Run("returns refunds successfully", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
 		txLogs := []model.TxLog{
 			{
@@ -189,7 +330,7 @@ func TestMessageQueue_FindRefundsToQueue(t *testing.T) {
 			},
 		}
 
-		mockTxLogRepo.On("FindUnpublishedFailed", 100).Return(txLogs, nil)
+		mockTxLogRepo.On("ClaimUnpublishedFailed", anyClaim, 100).Return(txLogs, nil)
 
 		refunds, err := svc.FindRefundsToQueue(context.Background(), 100)
 
@@ -212,9 +353,10 @@ func TestMessageQueue_FindRefundsToQueue(t *testing.T) {
 	t.Run("returns empty slice when no refunds found", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
-		mockTxLogRepo.On("FindUnpublishedFailed", 100).Return([]model.TxLog{}, nil)
+		mockTxLogRepo.On("ClaimUnpublishedFailed", anyClaim, 100).Return([]model.TxLog{}, nil)
 
 		refunds, err := svc.FindRefundsToQueue(context.Background(), 100)
 
@@ -227,10 +369,11 @@ func TestMessageQueue_FindRefundsToQueue(t *testing.T) {
 	t.Run("returns error when repository fails", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
 		dbError := errors.New("database connection failed")
-		mockTxLogRepo.On("FindUnpublishedFailed", 100).Return([]model.TxLog{}, dbError)
+		mockTxLogRepo.On("ClaimUnpublishedFailed", anyClaim, 100).Return([]model.TxLog{}, dbError)
 
 		refunds, err := svc.FindRefundsToQueue(context.Background(), 100)
 
@@ -244,32 +387,38 @@ func TestMessageQueue_FindRefundsToQueue(t *testing.T) {
 	t.Run("respects batch size limit", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
-		mockTxLogRepo.On("FindUnpublishedFailed", 50).Return([]model.TxLog{}, nil)
+		mockTxLogRepo.On("ClaimUnpublishedFailed", anyClaim, 50).Return([]model.TxLog{}, nil)
 
 		_, err := svc.FindRefundsToQueue(context.Background(), 50)
 
 		assert.NoError(t, err)
 		mockTxLogRepo.AssertExpectations(t)
-		mockTxLogRepo.AssertCalled(t, "FindUnpublishedFailed", 50)
+		mockTxLogRepo.AssertCalled(t, "ClaimUnpublishedFailed", anyClaim, 50)
 	})
 }
 
 func TestMessageQueue_MarkRefundAsQueued(t *testing.T) {
 	logger := zap.NewNop()
+	cfg := &config.Config{}
 
 	t.Run("marks refund as queued successfully", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
-		mockTxLogRepo.On("Update",
+		mockTxLogRepo.On("UpdateRefundForPublished", context.Background(),
 			mock.MatchedBy(func(txLog *model.TxLog) bool {
 				return txLog.ID == 123 &&
+					txLog.State == "" &&
 					txLog.Published == true &&
-					txLog.PublishedAt != nil
-			})).Return(nil)
+					txLog.PublishedAt != nil &&
+					txLog.ClaimedBy == nil &&
+					txLog.ClaimedUntil == nil
+			}), mock.AnythingOfType("string")).Return(nil)
 
 		err := svc.MarkRefundAsQueued(context.Background(), 123)
 
@@ -280,13 +429,14 @@ func TestMessageQueue_MarkRefundAsQueued(t *testing.T) {
 	t.Run("returns error when repository update fails", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
 		dbError := errors.New("database update failed")
-		mockTxLogRepo.On("Update",
+		mockTxLogRepo.On("UpdateRefundForPublished", context.Background(),
 			mock.MatchedBy(func(txLog *model.TxLog) bool {
 				return txLog.ID == 123
-			})).Return(dbError)
+			}), mock.AnythingOfType("string")).Return(dbError)
 
 		err := svc.MarkRefundAsQueued(context.Background(), 123)
 
@@ -296,21 +446,37 @@ func TestMessageQueue_MarkRefundAsQueued(t *testing.T) {
 		mockTxLogRepo.AssertExpectations(t)
 	})
 
+	t.Run("returns ErrNoRowsAffected when the claim has lapsed", func(t *testing.T) {
+		mockTxLogRepo := &mocks.TxLogRepository{}
+
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
+
+		mockTxLogRepo.On("UpdateRefundForPublished", context.Background(), mock.Anything,
+			mock.AnythingOfType("string")).Return(repository.ErrNoRowsAffected)
+
+		err := svc.MarkRefundAsQueued(context.Background(), 123)
+
+		assert.ErrorIs(t, err, repository.ErrNoRowsAffected)
+		mockTxLogRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
 	t.Run("sets published_at timestamp", func(t *testing.T) {
 		mockTxLogRepo := &mocks.TxLogRepository{}
 
-		svc := service.NewMessageQueueService(mockTxLogRepo, logger)
+		svc := service.NewMessageQueueService(mockTxLogRepo, &mocks.MessageRepository{}, &mocks.TxManager{},
+			cfg, logger)
 
 		before := time.Now()
 
-		mockTxLogRepo.On("Update",
+		mockTxLogRepo.On("UpdateRefundForPublished", context.Background(),
 			mock.MatchedBy(func(txLog *model.TxLog) bool {
 				if txLog.PublishedAt == nil {
 					return false
 				}
 				after := time.Now()
 				return !txLog.PublishedAt.Before(before) && !txLog.PublishedAt.After(after)
-			})).Return(nil)
+			}), mock.AnythingOfType("string")).Return(nil)
 
 		err := svc.MarkRefundAsQueued(context.Background(), 123)
 
@@ -3,9 +3,11 @@ package service_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
 	"github.com/Behyna/sms-services/smsgateway/internal/constants"
 	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
 	"github.com/Behyna/sms-services/smsgateway/internal/model"
@@ -16,8 +18,14 @@ import (
 	"go.uber.org/zap"
 )
 
+// paymentKey derives the charge or refund key of a single message, which must fit the payment service's 36 characters.
+func paymentKey(prefix, from, clientMessageID string) string {
+	return prefix + sha256Hex(from + "\x00" + clientMessageID)[:36-len(prefix)]
+}
+
 func TestMessage_CreateMessage(t *testing.T) {
 	logger := zap.NewNop()
+	cfg := &config.Config{}
 
 	cmd := service.CreateMessageCommand{
 		ClientMessageID: "test-msg-123",
@@ -32,12 +40,15 @@ func TestMessage_CreateMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
 
 		expectedChargeRequest := service.ChargePaymentCommand{
 			UserID:         cmd.FromMSISDN,
 			Amount:         1,
-			IdempotencyKey: "charge-" + cmd.FromMSISDN + "-" + cmd.ClientMessageID,
+			IdempotencyKey: paymentKey("charge-", cmd.FromMSISDN, cmd.ClientMessageID),
 		}
 
 		mockPayment.On("Charge", context.Background(),
@@ -57,6 +68,7 @@ func TestMessage_CreateMessage(t *testing.T) {
 					msg.ToMSISDN == cmd.ToMSISDN &&
 					msg.Text == cmd.Text &&
 					msg.Status == model.MessageStatusCreated &&
+					msg.Priority == model.MessagePriorityTransactional &&
 					msg.AttemptCount == 0
 			})).Run(func(args mock.Arguments) {
 			msg := args.Get(1).(*model.Message)
@@ -77,6 +89,8 @@ func TestMessage_CreateMessage(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, int64(123), resp.MessageID)
+		assert.Equal(t, string(model.MessageStatusCreated), resp.Status)
+		assert.False(t, resp.Duplicate)
 
 		mockPayment.AssertExpectations(t)
 		mockTxManager.AssertExpectations(t)
@@ -90,7 +104,10 @@ func TestMessage_CreateMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
 
 		chargeError := service.NewServiceError(
 			constants.ErrCodeInsufficientBalance,
@@ -111,20 +128,144 @@ func TestMessage_CreateMessage(t *testing.T) {
 		mockTxLogRepo.AssertNotCalled(t, "Create")
 	})
 
-	t.Run("refunds payment when message creation fails due to duplicate", func(t *testing.T) {
+	t.Run("rejects a suppressed recipient without charging", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockPayment := &mocks.PaymentService{}
+		mockSuppression := &mocks.SuppressionService{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{}, mockPayment,
+			mockSuppression, cfg, logger)
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
+		mockSuppression.On("SuppressedRecipients", context.Background(), int64(7), []string{cmd.ToMSISDN}).
+			Return(map[string]bool{cmd.ToMSISDN: true}, nil)
+
+		suppressedCmd := cmd
+		suppressedCmd.AccountID = 7
+
+		_, err := svc.CreateMessage(context.Background(), suppressedCmd)
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeRecipientSuppressed, serviceErr.Code)
+		mockPayment.AssertNotCalled(t, "Charge")
+		mockMessageRepo.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("returns existing message for a duplicate submission without charging", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{}, mockPayment,
+			newSuppressionService(), cfg, logger)
+
+		mockMessageRepo.On("GetByClientMessageID", cmd.FromMSISDN, cmd.ClientMessageID).
+			Return(&model.Message{ID: 123, ToMSISDN: cmd.ToMSISDN, Text: cmd.Text,
+				Status: model.MessageStatusSubmitted}, nil)
+
+		resp, err := svc.CreateMessage(context.Background(), cmd)
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.CreateMessageResponse{MessageID: 123, Status: "SUBMITTED", Duplicate: true}, resp)
+		mockPayment.AssertNotCalled(t, "Charge")
+		mockMessageRepo.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("rejects client message id reused with a different payload", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{}, mockPayment,
+			newSuppressionService(), cfg, logger)
+
+		mockMessageRepo.On("GetByClientMessageID", cmd.FromMSISDN, cmd.ClientMessageID).
+			Return(&model.Message{ID: 123, ToMSISDN: cmd.ToMSISDN, Text: "Other text",
+				Status: model.MessageStatusCreated}, nil)
+
+		resp, err := svc.CreateMessage(context.Background(), cmd)
+
+		assert.Equal(t, service.CreateMessageResponse{}, resp)
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeIdempotencyMismatch, serviceErr.Code)
+		mockPayment.AssertNotCalled(t, "Charge")
+	})
+
+	t.Run("compares a template send on its template, version and variables", func(t *testing.T) {
+		templateID, version, nextVersion := int64(5), 2, 3
+		templateCmd := cmd
+		templateCmd.Text = "Your code is 1234"
+		templateCmd.TemplateID = &templateID
+		templateCmd.TemplateVersion = &version
+		templateCmd.TemplateVariables = map[string]string{"code": "1234", "name": ""}
+
 		mockMessageRepo := &mocks.MessageRepository{}
 		mockTxLogRepo := &mocks.TxLogRepository{}
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newSuppressionService(), cfg, logger)
 
-		expectedRefundRequest := service.RefundPaymentCommand{
-			UserID:         cmd.FromMSISDN,
-			Amount:         1,
-			IdempotencyKey: "refund-" + cmd.FromMSISDN + "-" + cmd.ClientMessageID,
+		var stored *model.Message
+		mockMessageRepo.On("GetByClientMessageID", cmd.FromMSISDN, cmd.ClientMessageID).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound).Once()
+		mockPayment.On("Charge", context.Background(), mock.AnythingOfType("service.ChargePaymentCommand")).
+			Return(nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("Create", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("*model.Message")).
+			Run(func(args mock.Arguments) {
+				stored = args.Get(1).(*model.Message)
+				stored.ID = 123
+			}).Return(nil)
+		mockTxLogRepo.On("Create", mock.AnythingOfType("*context.valueCtx"), mock.AnythingOfType("*model.TxLog")).
+			Return(nil)
+
+		_, err := svc.CreateMessage(context.Background(), templateCmd)
+		assert.NoError(t, err)
+		if !assert.NotNil(t, stored) || !assert.NotNil(t, stored.TemplateVarHash) {
+			return
 		}
 
+		mockMessageRepo.On("GetByClientMessageID", cmd.FromMSISDN, cmd.ClientMessageID).Return(stored, nil)
+
+		resp, err := svc.CreateMessage(context.Background(), templateCmd)
+		assert.NoError(t, err)
+		assert.True(t, resp.Duplicate)
+
+		sameText := templateCmd
+		sameText.TemplateVariables = map[string]string{"code": "", "name": "1234"}
+		_, err = svc.CreateMessage(context.Background(), sameText)
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeIdempotencyMismatch, serviceErr.Code)
+
+		newVersion := templateCmd
+		newVersion.TemplateVersion = &nextVersion
+		_, err = svc.CreateMessage(context.Background(), newVersion)
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeIdempotencyMismatch, serviceErr.Code)
+
+		mockPayment.AssertNumberOfCalls(t, "Charge", 1)
+	})
+
+	t.Run("replays without refund when a concurrent duplicate wins the insert", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+
+		mockMessageRepo.On("GetByClientMessageID", cmd.FromMSISDN, cmd.ClientMessageID).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound).Once()
+		mockMessageRepo.On("GetByClientMessageID", cmd.FromMSISDN, cmd.ClientMessageID).
+			Return(&model.Message{ID: 77, ToMSISDN: cmd.ToMSISDN, Text: cmd.Text,
+				Status: model.MessageStatusCreated}, nil).Once()
+
 		mockPayment.On("Charge", context.Background(), mock.AnythingOfType("service.ChargePaymentCommand")).
 			Return(nil)
 
@@ -134,27 +275,14 @@ func TestMessage_CreateMessage(t *testing.T) {
 		mockMessageRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
 			mock.AnythingOfType("*model.Message")).Return(repository.ErrMessageDuplicate)
 
-		mockPayment.On("Refund", context.Background(),
-			mock.MatchedBy(func(req service.RefundPaymentCommand) bool {
-				return req.UserID == expectedRefundRequest.UserID &&
-					req.Amount == expectedRefundRequest.Amount &&
-					req.IdempotencyKey == expectedRefundRequest.IdempotencyKey
-			})).Return(nil)
-
 		resp, err := svc.CreateMessage(context.Background(), cmd)
 
-		assert.Error(t, err)
-		assert.Equal(t, service.CreateMessageResponse{}, resp)
-
-		var serviceErr service.Error
-		assert.True(t, errors.As(err, &serviceErr))
-		assert.Equal(t, constants.ErrCodeDuplicateMessage, serviceErr.Code)
+		assert.NoError(t, err)
+		assert.Equal(t, service.CreateMessageResponse{MessageID: 77, Status: "CREATED", Duplicate: true}, resp)
 
-		mockPayment.AssertExpectations(t)
-		mockTxManager.AssertExpectations(t)
 		mockMessageRepo.AssertExpectations(t)
 		mockPayment.AssertNumberOfCalls(t, "Charge", 1)
-		mockPayment.AssertNumberOfCalls(t, "Refund", 1)
+		mockPayment.AssertNotCalled(t, "Refund")
 	})
 
 	t.Run("refunds payment when message creation fails due to database error", func(t *testing.T) {
@@ -163,7 +291,10 @@ func TestMessage_CreateMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
 
 		dbError := errors.New("database connection failed")
 
@@ -201,7 +332,10 @@ func TestMessage_CreateMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
 
 		dbError := errors.New("txlog insert failed")
 
@@ -246,7 +380,10 @@ func TestMessage_CreateMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
 
 		dbError := errors.New("database error")
 		refundError := service.NewServiceError(
@@ -287,7 +424,10 @@ func TestMessage_CreateMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
 
 		var capturedChargeKey string
 
@@ -313,7 +453,7 @@ func TestMessage_CreateMessage(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, int64(123), resp.MessageID)
-		assert.Equal(t, "charge-1234567890-test-msg-123", capturedChargeKey)
+		assert.Equal(t, paymentKey("charge-", "1234567890", "test-msg-123"), capturedChargeKey)
 
 		mockPayment.AssertExpectations(t)
 		mockTxManager.AssertExpectations(t)
@@ -321,13 +461,48 @@ func TestMessage_CreateMessage(t *testing.T) {
 		mockTxLogRepo.AssertExpectations(t)
 	})
 
+	t.Run("keeps the charge key within 36 characters for a 64 character id", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newSuppressionService(), cfg, logger)
+
+		longCmd := cmd
+		longCmd.FromMSISDN = "+989121234567890"
+		longCmd.ClientMessageID = strings.Repeat("x", 64)
+
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
+		mockPayment.On("Charge", context.Background(), mock.MatchedBy(func(req service.ChargePaymentCommand) bool {
+			return len(req.IdempotencyKey) <= 36 &&
+				req.IdempotencyKey == paymentKey("charge-", longCmd.FromMSISDN, longCmd.ClientMessageID)
+		})).Return(nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.AnythingOfType("*model.Message")).Return(nil)
+		mockTxLogRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.AnythingOfType("*model.TxLog")).Return(nil)
+
+		_, err := svc.CreateMessage(context.Background(), longCmd)
+
+		assert.NoError(t, err)
+		mockPayment.AssertExpectations(t)
+	})
+
 	t.Run("uses correct refund idempotency key", func(t *testing.T) {
 		mockMessageRepo := &mocks.MessageRepository{}
 		mockTxLogRepo := &mocks.TxLogRepository{}
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
 
 		var capturedRefundKey string
 
@@ -349,233 +524,495 @@ func TestMessage_CreateMessage(t *testing.T) {
 		_, err := svc.CreateMessage(context.Background(), cmd)
 
 		assert.Error(t, err)
-		assert.Equal(t, "refund-1234567890-test-msg-123", capturedRefundKey)
+		assert.Equal(t, paymentKey("refund-", "1234567890", "test-msg-123"), capturedRefundKey)
 
 		mockPayment.AssertExpectations(t)
 		mockMessageRepo.AssertExpectations(t)
 	})
+
+	t.Run("applies default validity period from the send time", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		validityCfg := &config.Config{Message: config.Message{DefaultValidityPeriod: time.Hour}}
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			validityCfg, logger)
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
+
+		sendAt := time.Now().Add(24 * time.Hour)
+		scheduledCmd := cmd
+		scheduledCmd.SendAt = &sendAt
+
+		mockPayment.On("Charge", context.Background(), mock.AnythingOfType("service.ChargePaymentCommand")).
+			Return(nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(msg *model.Message) bool {
+				return msg.ExpiresAt != nil && msg.ExpiresAt.Equal(sendAt.Add(time.Hour))
+			})).Return(nil)
+		mockTxLogRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.AnythingOfType("*model.TxLog")).Return(nil)
+
+		_, err := svc.CreateMessage(context.Background(), scheduledCmd)
+
+		assert.NoError(t, err)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("copies the lane and send time onto the tx log", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
+
+		sendAt := time.Now().Add(24 * time.Hour)
+		scheduledCmd := cmd
+		scheduledCmd.SendAt = &sendAt
+		scheduledCmd.Priority = string(model.MessagePriorityBulk)
+
+		mockPayment.On("Charge", context.Background(), mock.AnythingOfType("service.ChargePaymentCommand")).
+			Return(nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.AnythingOfType("*model.Message")).Return(nil)
+		mockTxLogRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(txLog *model.TxLog) bool {
+				return txLog.Priority == model.MessagePriorityBulk && txLog.ReadyAt.Equal(sendAt)
+			})).Return(nil)
+
+		_, err := svc.CreateMessage(context.Background(), scheduledCmd)
+
+		assert.NoError(t, err)
+		mockTxLogRepo.AssertExpectations(t)
+	})
+
+	t.Run("charges per segment for long unicode text", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
+		mockMessageRepo.On("GetByClientMessageID", mock.Anything, mock.Anything).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
+
+		unicodeCmd := cmd
+		unicodeCmd.Text = strings.Repeat("سلام ", 30)
+
+		mockPayment.On("Charge", context.Background(),
+			mock.MatchedBy(func(req service.ChargePaymentCommand) bool {
+				return req.Amount == 3
+			})).Return(nil)
+
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+
+		mockMessageRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(msg *model.Message) bool {
+				return msg.SegmentCount == 3 && msg.Encoding == "UCS2"
+			})).Run(func(args mock.Arguments) {
+			msg := args.Get(1).(*model.Message)
+			msg.ID = 123
+		}).Return(nil)
+
+		mockTxLogRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(txLog *model.TxLog) bool {
+				return txLog.MessageID == 123 && txLog.Amount == 3
+			})).Return(nil)
+
+		resp, err := svc.CreateMessage(context.Background(), unicodeCmd)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(123), resp.MessageID)
+
+		mockPayment.AssertExpectations(t)
+		mockMessageRepo.AssertExpectations(t)
+		mockTxLogRepo.AssertExpectations(t)
+	})
+}
+
+func TestMessage_ReplayMessage(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.Config{}
+
+	cmd := service.CreateMessageCommand{
+		ClientMessageID: "test-msg-123",
+		FromMSISDN:      "1234567890",
+		ToMSISDN:        "0987654321",
+		Text:            "Hello World",
+	}
+
+	t.Run("answers a resubmission with the stored message", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockPayment := &mocks.PaymentService{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{}, mockPayment,
+			newSuppressionService(), cfg, logger)
+
+		mockMessageRepo.On("GetByClientMessageID", cmd.FromMSISDN, cmd.ClientMessageID).
+			Return(&model.Message{ID: 123, ToMSISDN: cmd.ToMSISDN, Text: cmd.Text,
+				Status: model.MessageStatusSubmitted}, nil)
+
+		resp, found, err := svc.ReplayMessage(context.Background(), cmd)
+
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, service.CreateMessageResponse{MessageID: 123, Status: "SUBMITTED", Duplicate: true}, resp)
+		mockPayment.AssertNotCalled(t, "Charge")
+	})
+
+	t.Run("reports a new client message id as not found", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			&mocks.PaymentService{}, newSuppressionService(), cfg, logger)
+
+		mockMessageRepo.On("GetByClientMessageID", cmd.FromMSISDN, cmd.ClientMessageID).
+			Return((*model.Message)(nil), repository.ErrMessageNotFound)
+
+		resp, found, err := svc.ReplayMessage(context.Background(), cmd)
+
+		assert.NoError(t, err)
+		assert.False(t, found)
+		assert.Equal(t, service.CreateMessageResponse{}, resp)
+		mockMessageRepo.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("rejects a client message id reused with a different payload", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			&mocks.PaymentService{}, newSuppressionService(), cfg, logger)
+
+		mockMessageRepo.On("GetByClientMessageID", cmd.FromMSISDN, cmd.ClientMessageID).
+			Return(&model.Message{ID: 123, ToMSISDN: cmd.ToMSISDN, Text: "Other text"}, nil)
+
+		_, found, err := svc.ReplayMessage(context.Background(), cmd)
+
+		assert.False(t, found)
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeIdempotencyMismatch, serviceErr.Code)
+	})
 }
 
 func TestMessage_GetMessagesByUserID(t *testing.T) {
 	logger := zap.NewNop()
+	cfg := &config.Config{}
 
 	query := service.GetMessagesQuery{
 		UserID: "1234567890",
-		Limit:  10,
-		Offset: 0,
+		Limit:  2,
+	}
+
+	filter := repository.MessageFilter{FromMSISDN: "1234567890"}
+	noCursor := (*repository.MessageCursor)(nil)
+
+	now := time.Now()
+	messages := []model.Message{
+		{
+			ID:              125,
+			ClientMessageID: "msg-1",
+			FromMSISDN:      "1234567890",
+			ToMSISDN:        "0987654321",
+			Text:            "Hello",
+			Status:          model.MessageStatusSubmitted,
+			CreatedAt:       now,
+		},
+		{
+			ID:              124,
+			ClientMessageID: "msg-2",
+			FromMSISDN:      "1234567890",
+			ToMSISDN:        "1111111111",
+			Text:            "World",
+			Status:          model.MessageStatusCreated,
+			CreatedAt:       now.Add(-1 * time.Hour),
+		},
+		{
+			ID:              123,
+			ClientMessageID: "msg-3",
+			FromMSISDN:      "1234567890",
+			ToMSISDN:        "2222222222",
+			Text:            "Again",
+			Status:          model.MessageStatusCreated,
+			CreatedAt:       now.Add(-2 * time.Hour),
+		},
 	}
 
-	t.Run("returns messages successfully", func(t *testing.T) {
+	t.Run("returns a page with next cursor", func(t *testing.T) {
 		mockMessageRepo := &mocks.MessageRepository{}
 		mockTxLogRepo := &mocks.TxLogRepository{}
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
-
-		now := time.Now()
-		messages := []model.Message{
-			{
-				ID:              123,
-				ClientMessageID: "msg-1",
-				FromMSISDN:      "1234567890",
-				ToMSISDN:        "0987654321",
-				Text:            "Hello",
-				Status:          model.MessageStatusSubmitted,
-				CreatedAt:       now,
-			},
-			{
-				ID:              124,
-				ClientMessageID: "msg-2",
-				FromMSISDN:      "1234567890",
-				ToMSISDN:        "1111111111",
-				Text:            "World",
-				Status:          model.MessageStatusCreated,
-				CreatedAt:       now.Add(-1 * time.Hour),
-			},
-		}
-
-		mockMessageRepo.On("GetByUserID", query.UserID, query.Limit, query.Offset).
-			Return(messages, nil)
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, newSuppressionService(),
+			cfg, logger)
 
-		mockMessageRepo.On("CountByUserID", query.UserID).Return(25, nil)
+		mockMessageRepo.On("FindByFilter", filter, noCursor, 3).Return(messages, nil)
 
 		resp, err := svc.GetMessagesByUserID(context.Background(), query)
 
 		assert.NoError(t, err)
 		assert.Len(t, resp.Messages, 2)
-		assert.Equal(t, int64(25), resp.Total)
+		assert.NotEmpty(t, resp.NextCursor)
+		assert.Nil(t, resp.Total)
 
 		assert.Equal(t, "msg-1", resp.Messages[0].MessageID)
 		assert.Equal(t, "1234567890", resp.Messages[0].From)
 		assert.Equal(t, "0987654321", resp.Messages[0].To)
 		assert.Equal(t, "Hello", resp.Messages[0].Text)
 		assert.Equal(t, string(model.MessageStatusSubmitted), resp.Messages[0].Status)
-
 		assert.Equal(t, "msg-2", resp.Messages[1].MessageID)
-		assert.Equal(t, "1234567890", resp.Messages[1].From)
-		assert.Equal(t, "1111111111", resp.Messages[1].To)
-		assert.Equal(t, "World", resp.Messages[1].Text)
-		assert.Equal(t, string(model.MessageStatusCreated), resp.Messages[1].Status)
 
 		mockMessageRepo.AssertExpectations(t)
+		mockMessageRepo.AssertNotCalled(t, "CountByFilter")
 	})
 
-	t.Run("returns empty list when no messages found", func(t *testing.T) {
+	t.Run("resumes after the cursor of the previous page", func(t *testing.T) {
 		mockMessageRepo := &mocks.MessageRepository{}
-		mockTxLogRepo := &mocks.TxLogRepository{}
-		mockTxManager := &mocks.TxManager{}
-		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			&mocks.PaymentService{}, newSuppressionService(), cfg, logger)
+
+		mockMessageRepo.On("FindByFilter", filter, noCursor, 3).Return(messages, nil).Once()
+		mockMessageRepo.On("FindByFilter", filter,
+			mock.MatchedBy(func(cursor *repository.MessageCursor) bool {
+				return cursor != nil && cursor.ID == 124 && cursor.CreatedAt.Equal(messages[1].CreatedAt)
+			}), 3).Return(messages[2:], nil).Once()
+
+		first, err := svc.GetMessagesByUserID(context.Background(), query)
+		assert.NoError(t, err)
+
+		next := query
+		next.Cursor = first.NextCursor
+		second, err := svc.GetMessagesByUserID(context.Background(), next)
+
+		assert.NoError(t, err)
+		assert.Len(t, second.Messages, 1)
+		assert.Equal(t, "msg-3", second.Messages[0].MessageID)
+		assert.Empty(t, second.NextCursor)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("passes filters and counts when total is requested", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			&mocks.PaymentService{}, newSuppressionService(), cfg, logger)
+
+		from := now.Add(-24 * time.Hour)
+		filtered := service.GetMessagesQuery{
+			UserID:                "1234567890",
+			Limit:                 10,
+			Status:                "DELIVERED",
+			ToMSISDN:              "0987654321",
+			CreatedFrom:           &from,
+			ClientMessageIDPrefix: "order-",
+			IncludeTotal:          true,
+		}
+		expectedFilter := repository.MessageFilter{
+			FromMSISDN:            "1234567890",
+			Status:                "DELIVERED",
+			ToMSISDN:              "0987654321",
+			CreatedFrom:           &from,
+			ClientMessageIDPrefix: "order-",
+		}
+
+		mockMessageRepo.On("FindByFilter", expectedFilter, noCursor, 11).Return([]model.Message{}, nil)
+		mockMessageRepo.On("CountByFilter", expectedFilter).Return(int64(0), nil)
+
+		resp, err := svc.GetMessagesByUserID(context.Background(), filtered)
 
-		mockMessageRepo.On("GetByUserID", query.UserID, query.Limit, query.Offset).
-			Return([]model.Message{}, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Messages)
+		assert.Equal(t, int64(0), *resp.Total)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns user not found when user has no messages", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			&mocks.PaymentService{}, newSuppressionService(), cfg, logger)
+
+		mockMessageRepo.On("FindByFilter", filter, noCursor, 3).Return([]model.Message{}, nil)
 
 		resp, err := svc.GetMessagesByUserID(context.Background(), query)
 
-		assert.Error(t, err)
 		assert.Equal(t, service.GetMessagesResponse{}, resp)
 
 		var serviceErr service.Error
 		assert.True(t, errors.As(err, &serviceErr))
 		assert.Equal(t, constants.ErrCodeUserNotFound, serviceErr.Code)
-
-		mockMessageRepo.AssertExpectations(t)
-		mockMessageRepo.AssertNotCalled(t, "CountByUserID")
 	})
 
-	t.Run("returns error when GetByUserID fails", func(t *testing.T) {
+	t.Run("rejects malformed cursor", func(t *testing.T) {
 		mockMessageRepo := &mocks.MessageRepository{}
-		mockTxLogRepo := &mocks.TxLogRepository{}
-		mockTxManager := &mocks.TxManager{}
-		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			&mocks.PaymentService{}, newSuppressionService(), cfg, logger)
 
-		dbError := errors.New("database connection failed")
+		bad := query
+		bad.Cursor = "not-a-cursor!"
 
-		mockMessageRepo.On("GetByUserID", query.UserID, query.Limit, query.Offset).
-			Return([]model.Message{}, dbError)
+		_, err := svc.GetMessagesByUserID(context.Background(), bad)
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeInvalidCursor, serviceErr.Code)
+		mockMessageRepo.AssertNotCalled(t, "FindByFilter")
+	})
+
+	t.Run("returns error when FindByFilter fails", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			&mocks.PaymentService{}, newSuppressionService(), cfg, logger)
+
+		mockMessageRepo.On("FindByFilter", filter, noCursor, 3).
+			Return([]model.Message{}, errors.New("database connection failed"))
 
 		resp, err := svc.GetMessagesByUserID(context.Background(), query)
 
-		assert.Error(t, err)
 		assert.Equal(t, service.GetMessagesResponse{}, resp)
 		assert.Equal(t, service.ErrDatabase, err)
-
-		mockMessageRepo.AssertExpectations(t)
-		mockMessageRepo.AssertNotCalled(t, "CountByUserID")
+		mockMessageRepo.AssertNotCalled(t, "CountByFilter")
 	})
 
-	t.Run("returns error when CountByUserID fails", func(t *testing.T) {
+	t.Run("returns error when CountByFilter fails", func(t *testing.T) {
 		mockMessageRepo := &mocks.MessageRepository{}
-		mockTxLogRepo := &mocks.TxLogRepository{}
-		mockTxManager := &mocks.TxManager{}
-		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
-
-		messages := []model.Message{
-			{
-				ID:              123,
-				ClientMessageID: "msg-1",
-				FromMSISDN:      "1234567890",
-				ToMSISDN:        "0987654321",
-				Text:            "Hello",
-				Status:          model.MessageStatusSubmitted,
-				CreatedAt:       time.Now(),
-			},
-		}
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			&mocks.PaymentService{}, newSuppressionService(), cfg, logger)
 
-		dbError := errors.New("count query failed")
+		withTotal := query
+		withTotal.IncludeTotal = true
 
-		mockMessageRepo.On("GetByUserID", query.UserID, query.Limit, query.Offset).
-			Return(messages, nil)
+		mockMessageRepo.On("FindByFilter", filter, noCursor, 3).Return(messages[:1], nil)
+		mockMessageRepo.On("CountByFilter", filter).Return(int64(0), errors.New("count query failed"))
 
-		mockMessageRepo.On("CountByUserID", query.UserID).Return(0, dbError)
+		resp, err := svc.GetMessagesByUserID(context.Background(), withTotal)
 
-		resp, err := svc.GetMessagesByUserID(context.Background(), query)
-
-		assert.Error(t, err)
 		assert.Equal(t, service.GetMessagesResponse{}, resp)
 		assert.Equal(t, service.ErrDatabase, err)
-
-		mockMessageRepo.AssertExpectations(t)
 	})
 
 	t.Run("formats timestamps correctly", func(t *testing.T) {
 		mockMessageRepo := &mocks.MessageRepository{}
-		mockTxLogRepo := &mocks.TxLogRepository{}
-		mockTxManager := &mocks.TxManager{}
-		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			&mocks.PaymentService{}, newSuppressionService(), cfg, logger)
 
 		createdAt, _ := time.Parse(time.RFC3339, "2023-06-15T10:30:00Z")
-		messages := []model.Message{
-			{
-				ID:              123,
-				ClientMessageID: "msg-1",
-				FromMSISDN:      "1234567890",
-				ToMSISDN:        "0987654321",
-				Text:            "Hello",
-				Status:          model.MessageStatusSubmitted,
-				CreatedAt:       createdAt,
-			},
-		}
-
-		mockMessageRepo.On("GetByUserID", query.UserID, query.Limit, query.Offset).
-			Return(messages, nil)
-
-		mockMessageRepo.On("CountByUserID", query.UserID).Return(1, nil)
+		mockMessageRepo.On("FindByFilter", filter, noCursor, 3).
+			Return([]model.Message{{ID: 123, ClientMessageID: "msg-1", CreatedAt: createdAt}}, nil)
 
 		resp, err := svc.GetMessagesByUserID(context.Background(), query)
 
 		assert.NoError(t, err)
 		assert.Len(t, resp.Messages, 1)
 		assert.Equal(t, "2023-06-15T10:30:00Z", resp.Messages[0].CreatedAt)
-
-		mockMessageRepo.AssertExpectations(t)
 	})
+}
 
-	t.Run("respects limit and offset", func(t *testing.T) {
+func TestMessage_GetMessageByID(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.Config{}
+
+	createdAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	lastAttemptAt := createdAt.Add(time.Minute)
+	provider := "provider-a"
+	lastError := "SERVER_ERROR"
+
+	msg := &model.Message{
+		ID:              42,
+		ClientMessageID: "msg-42",
+		FromMSISDN:      "1234567890",
+		ToMSISDN:        "0987654321",
+		Text:            "Hello",
+		Status:          model.MessageStatusFailedPerm,
+		SegmentCount:    1,
+		Encoding:        "GSM7",
+		AttemptCount:    3,
+		LastAttemptAt:   &lastAttemptAt,
+		Provider:        &provider,
+		CreatedAt:       createdAt,
+		UpdatedAt:       createdAt,
+	}
+
+	t.Run("returns message with charge and refund state", func(t *testing.T) {
 		mockMessageRepo := &mocks.MessageRepository{}
 		mockTxLogRepo := &mocks.TxLogRepository{}
-		mockTxManager := &mocks.TxManager{}
-		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, &mocks.TxManager{}, &mocks.PaymentService{},
+			newSuppressionService(), cfg, logger)
 
-		customQuery := service.GetMessagesQuery{
-			UserID: "1234567890",
-			Limit:  5,
-			Offset: 10,
-		}
+		mockMessageRepo.On("GetByID", int64(42)).Return(msg, nil)
+		mockTxLogRepo.On("GetByMessageID", int64(42)).Return(&model.TxLog{
+			MessageID: 42,
+			Amount:    1,
+			State:     model.TxLogStateFailed,
+			LastError: &lastError,
+		}, nil)
 
-		messages := []model.Message{
-			{
-				ID:              123,
-				ClientMessageID: "msg-1",
-				FromMSISDN:      "1234567890",
-				ToMSISDN:        "0987654321",
-				Text:            "Hello",
-				Status:          model.MessageStatusSubmitted,
-				CreatedAt:       time.Now(),
-			},
-		}
+		details, err := svc.GetMessageByID(context.Background(), 42)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), details.ID)
+		assert.Equal(t, "FAILED_PERM", details.Status)
+		assert.Equal(t, 3, details.AttemptCount)
+		assert.Equal(t, "2024-01-15T10:31:00Z", *details.LastAttemptAt)
+		assert.Equal(t, &provider, details.Provider)
+		assert.Equal(t, &lastError, details.LastError)
+		assert.Equal(t, &service.ChargeDetails{
+			Amount:      1,
+			State:       model.TxLogStateFailed,
+			RefundState: service.RefundStatePending,
+		}, details.Charge)
+	})
 
-		mockMessageRepo.On("GetByUserID", customQuery.UserID, customQuery.Limit, customQuery.Offset).
-			Return(messages, nil)
+	t.Run("returns not found error for unknown message", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
 
-		mockMessageRepo.On("CountByUserID", customQuery.UserID).Return(50, nil)
+		svc := service.NewMessageService(mockMessageRepo, &mocks.TxLogRepository{}, &mocks.TxManager{},
+			&mocks.PaymentService{}, newSuppressionService(), cfg, logger)
 
-		resp, err := svc.GetMessagesByUserID(context.Background(), customQuery)
+		mockMessageRepo.On("GetByID", int64(7)).Return((*model.Message)(nil), repository.ErrMessageNotFound)
 
-		assert.NoError(t, err)
-		assert.Equal(t, int64(50), resp.Total)
+		_, err := svc.GetMessageByID(context.Background(), 7)
 
-		mockMessageRepo.AssertExpectations(t)
-		mockMessageRepo.AssertCalled(t, "GetByUserID", customQuery.UserID, 5, 10)
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeMessageNotFound, serviceErr.Code)
+	})
+
+	t.Run("looks up message by client message id", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+
+		svc := service.NewMessageService(mockMessageRepo, mockTxLogRepo, &mocks.TxManager{}, &mocks.PaymentService{},
+			newSuppressionService(), cfg, logger)
+
+		mockMessageRepo.On("GetByClientMessageID", "1234567890", "msg-42").Return(msg, nil)
+		mockTxLogRepo.On("GetByMessageID", int64(42)).Return((*model.TxLog)(nil), repository.ErrTxLogNotFound)
+
+		details, err := svc.GetMessageByClientMessageID(context.Background(),
+			service.GetMessageQuery{FromMSISDN: "1234567890", ClientMessageID: "msg-42"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "msg-42", details.ClientMessageID)
+		assert.Nil(t, details.Charge)
 	})
 }
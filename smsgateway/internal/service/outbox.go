@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Behyna/common/pkg/mq"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// OutboxWakeQueue carries nudges from the API to the send publisher. Wakes carry no payload; any publisher replica
+// that receives one polls the outbox straight away.
+const OutboxWakeQueue = "sms.outbox.wake"
+
+// wakePublishTimeout bounds one wake publish, so a stuck broker delays only the next wake.
+const wakePublishTimeout = time.Second
+
+// OutboxWaker tells the send publisher that new messages were committed.
+type OutboxWaker interface {
+	// Wake never blocks: it leaves a wake for Run to publish. Wakes that arrive while one is pending are merged into
+	// it, since a single poll picks up everything committed so far.
+	Wake(ctx context.Context)
+	// Run publishes pending wakes until ctx is done.
+	Run(ctx context.Context)
+}
+
+type outboxWaker struct {
+	publisher mq.Publisher
+	pending   chan struct{}
+	logger    *zap.Logger
+}
+
+func NewOutboxWaker(publisher mq.Publisher, logger *zap.Logger) OutboxWaker {
+	return &outboxWaker{publisher: publisher, pending: make(chan struct{}, 1), logger: logger}
+}
+
+func (w *outboxWaker) Wake(context.Context) {
+	select {
+	case w.pending <- struct{}{}:
+	default:
+	}
+}
+
+// Run is best effort: the publisher still polls, so a failed wake only delays the message.
+func (w *outboxWaker) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.pending:
+		}
+
+		publishCtx, cancel := context.WithTimeout(ctx, wakePublishTimeout)
+		if err := w.publisher.Publish(publishCtx, "", OutboxWakeQueue, []byte("{}")); err != nil {
+			w.logger.Warn("Failed to wake outbox publisher", zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// DeclareOutboxWakeQueue declares the wake queue apart from the durable topology. Wakes are worthless once any
+// publisher has polled, so the queue is transient and keeps only the newest wake.
+func DeclareOutboxWakeQueue(url string) error {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	channel, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer channel.Close()
+
+	args := amqp.Table{"x-max-length": int64(1), "x-overflow": "drop-head"}
+	_, err = channel.QueueDeclare(OutboxWakeQueue, false, false, false, false, args)
+	return err
+}
+
+type wakingMessageService struct {
+	MessageService
+	waker OutboxWaker
+}
+
+// NewWakingMessageService wakes the send publisher after every message or batch that messages creates.
+func NewWakingMessageService(messages MessageService, waker OutboxWaker) MessageService {
+	return &wakingMessageService{MessageService: messages, waker: waker}
+}
+
+func (w *wakingMessageService) CreateMessage(ctx context.Context, cmd CreateMessageCommand) (
+	CreateMessageResponse, error) {
+	resp, err := w.MessageService.CreateMessage(ctx, cmd)
+	if err == nil {
+		w.waker.Wake(ctx)
+	}
+
+	return resp, err
+}
+
+func (w *wakingMessageService) CreateMessageBatch(ctx context.Context, cmd CreateMessageBatchCommand) (
+	CreateMessageBatchResponse, error) {
+	resp, err := w.MessageService.CreateMessageBatch(ctx, cmd)
+	if err == nil {
+		w.waker.Wake(ctx)
+	}
+
+	return resp, err
+}
@@ -0,0 +1,110 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeMQPublisher struct {
+	routingKeys []string
+	err         error
+}
+
+func (f *fakeMQPublisher) Publish(_ context.Context, _, routingKey string, _ []byte) error {
+	f.routingKeys = append(f.routingKeys, routingKey)
+	return f.err
+}
+
+type countingWaker struct {
+	wakes int
+}
+
+func (c *countingWaker) Wake(context.Context) {
+	c.wakes++
+}
+
+func (c *countingWaker) Run(context.Context) {}
+
+type stubMessageService struct {
+	service.MessageService
+	err error
+}
+
+func (s stubMessageService) CreateMessage(context.Context, service.CreateMessageCommand) (
+	service.CreateMessageResponse, error) {
+	return service.CreateMessageResponse{}, s.err
+}
+
+func (s stubMessageService) CreateMessageBatch(context.Context, service.CreateMessageBatchCommand) (
+	service.CreateMessageBatchResponse, error) {
+	return service.CreateMessageBatchResponse{}, s.err
+}
+
+func TestOutboxWaker(t *testing.T) {
+	t.Run("merges pending wakes into one publish to the wake queue", func(t *testing.T) {
+		publisher := &fakeMQPublisher{}
+		waker := service.NewOutboxWaker(publisher, zap.NewNop())
+
+		for range 3 {
+			waker.Wake(context.Background())
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			waker.Run(ctx)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+		<-done
+
+		assert.Equal(t, []string{"sms.outbox.wake"}, publisher.routingKeys)
+	})
+
+	t.Run("tolerates a broker failure", func(t *testing.T) {
+		publisher := &fakeMQPublisher{err: errors.New("connection closed")}
+		waker := service.NewOutboxWaker(publisher, zap.NewNop())
+		waker.Wake(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		assert.NotPanics(t, func() { waker.Run(ctx) })
+		assert.Equal(t, []string{"sms.outbox.wake"}, publisher.routingKeys)
+	})
+}
+
+func TestWakingMessageService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("wakes the publisher after creating messages", func(t *testing.T) {
+		waker := &countingWaker{}
+		svc := service.NewWakingMessageService(stubMessageService{}, waker)
+
+		_, err := svc.CreateMessage(ctx, service.CreateMessageCommand{})
+		assert.NoError(t, err)
+		_, err = svc.CreateMessageBatch(ctx, service.CreateMessageBatchCommand{})
+		assert.NoError(t, err)
+
+		assert.Equal(t, 2, waker.wakes)
+	})
+
+	t.Run("does not wake when nothing was created", func(t *testing.T) {
+		waker := &countingWaker{}
+		svc := service.NewWakingMessageService(stubMessageService{err: errors.New("insufficient balance")}, waker)
+
+		_, err := svc.CreateMessage(ctx, service.CreateMessageCommand{})
+		assert.Error(t, err)
+		_, err = svc.CreateMessageBatch(ctx, service.CreateMessageBatchCommand{})
+		assert.Error(t, err)
+
+		assert.Zero(t, waker.wakes)
+	})
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/Behyna/sms-services/smsgateway/internal/config"
@@ -10,35 +11,105 @@ import (
 )
 
 type ProviderService interface {
-	SendWithRetry(ctx context.Context, fromMSISDN, toMSISDN, text string) (smsprovider.Response, error)
+	SendWithRetry(ctx context.Context, accountID int64, fromMSISDN, toMSISDN, text string) (smsprovider.Response,
+		error)
+	CircuitOpen(accountID int64, toMSISDN string) (time.Duration, bool)
+	ProviderHealth() []smsprovider.BreakerStatus
 }
 
 type Provider struct {
-	provider smsprovider.Provider
+	router   *smsprovider.Router
+	breakers map[string]*smsprovider.Breaker
 	logger   *zap.Logger
-	config   smsprovider.Config
 }
 
-func NewProviderService(provider smsprovider.Provider, logger *zap.Logger, config *config.Config) ProviderService {
-	return &Provider{provider: provider, logger: logger, config: config.Provider}
+func NewProviderService(router *smsprovider.Router, logger *zap.Logger, config *config.Config) ProviderService {
+	p := &Provider{router: router, breakers: make(map[string]*smsprovider.Breaker), logger: logger}
+	for _, name := range router.Names() {
+		p.breakers[name] = smsprovider.NewBreaker(name, config.ProviderHealth.Breaker, p.logTransition)
+	}
+
+	return p
+}
+
+// SendWithRetry tries the providers the router selects for the message in order, retrying each one up to its
+// max_retry before failing over to the next. Only provider-side failures and open circuits fail over; a permanent
+// rejection such as an invalid number is returned at once. The accepting provider's name is returned in the response.
+func (p *Provider) SendWithRetry(ctx context.Context, accountID int64, fromMSISDN, toMSISDN, text string) (
+	smsprovider.Response, error) {
+
+	routes := p.router.Routes(accountID, toMSISDN)
+	if len(routes) == 0 {
+		p.logger.Error("No provider accepts the destination",
+			zap.Int64("accountID", accountID),
+			zap.String("to", toMSISDN))
+		return smsprovider.Response{}, errors.New(smsprovider.ErrorCodeNoRoute)
+	}
+
+	var lastErr error
+	for i, route := range routes {
+		response, err := p.sendWithRetry(ctx, route, fromMSISDN, toMSISDN, text)
+		if err == nil {
+			response.Provider = route.Name
+			return response, nil
+		}
+
+		lastErr = err
+		if !smsprovider.IsRetryable(err) && err.Error() != smsprovider.ErrorCodeCircuitOpen {
+			return smsprovider.Response{}, err
+		}
+
+		if i < len(routes)-1 {
+			p.logger.Warn("Failing over to next provider",
+				zap.String("provider", route.Name),
+				zap.String("next", routes[i+1].Name),
+				zap.Error(err),
+				zap.String("to", toMSISDN))
+		}
+	}
+
+	p.logger.Error("All providers failed",
+		zap.Error(lastErr),
+		zap.Int("providers", len(routes)),
+		zap.String("to", toMSISDN))
+
+	return smsprovider.Response{}, lastErr
 }
 
-func (p *Provider) SendWithRetry(ctx context.Context, fromMSISDN, toMSISDN, text string) (smsprovider.Response, error) {
+func (p *Provider) sendWithRetry(ctx context.Context, route smsprovider.Route, fromMSISDN, toMSISDN,
+	text string) (smsprovider.Response, error) {
+
+	maxRetry := max(route.Config.MaxRetry, 1)
+	breaker := p.breakers[route.Name]
+
 	var lastErr error
+	for attempt := 1; attempt <= maxRetry; attempt++ {
+		if !breaker.Allow() {
+			p.logger.Debug("Provider circuit open, skipping",
+				zap.String("provider", route.Name),
+				zap.Int("attempt", attempt))
+
+			if lastErr != nil {
+				return smsprovider.Response{}, lastErr
+			}
+			return smsprovider.Response{}, errors.New(smsprovider.ErrorCodeCircuitOpen)
+		}
 
-	for attempt := 1; attempt <= p.config.MaxRetry; attempt++ {
 		p.logger.Debug("Attempting to send SMS",
+			zap.String("provider", route.Name),
 			zap.Int("attempt", attempt),
 			zap.String("to", toMSISDN),
 			zap.String("from", fromMSISDN))
 
-		providerCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+		providerCtx, cancel := context.WithTimeout(ctx, route.Config.Timeout)
 
-		response, err := p.provider.Send(providerCtx, fromMSISDN, toMSISDN, text)
+		response, err := route.Provider.Send(providerCtx, fromMSISDN, toMSISDN, text)
 		cancel()
+		breaker.Record(err)
 
 		if err == nil {
 			p.logger.Info("SMS sent successfully",
+				zap.String("provider", route.Name),
 				zap.String("messageId", response.MessageID),
 				zap.String("status", response.Status),
 				zap.Int("attempt", attempt))
@@ -47,18 +118,20 @@ func (p *Provider) SendWithRetry(ctx context.Context, fromMSISDN, toMSISDN, text
 
 		lastErr = err
 		p.logger.Warn("SMS send attempt failed",
+			zap.String("provider", route.Name),
 			zap.Error(err),
 			zap.Int("attempt", attempt),
 			zap.String("to", toMSISDN))
 
-		if err.Error() == smsprovider.ErrorCodeInvalidNumber {
+		if smsprovider.IsPermanent(err) {
 			p.logger.Error("Non-retryable error encountered",
+				zap.String("provider", route.Name),
 				zap.Error(err),
 				zap.String("to", toMSISDN))
 			return smsprovider.Response{}, err
 		}
 
-		if attempt < p.config.MaxRetry {
+		if attempt < maxRetry {
 			delay := time.Duration(attempt) * 100 * time.Millisecond
 			p.logger.Debug("Waiting before retry", zap.Duration("delay", delay))
 
@@ -70,10 +143,57 @@ func (p *Provider) SendWithRetry(ctx context.Context, fromMSISDN, toMSISDN, text
 		}
 	}
 
-	p.logger.Error("All retry attempts exhausted",
-		zap.Error(lastErr),
-		zap.Int("maxRetries", p.config.MaxRetry),
-		zap.String("to", toMSISDN))
-
 	return smsprovider.Response{}, lastErr
 }
+
+// CircuitOpen reports whether every provider that would take the message has an open circuit, and if so how long
+// until the first of them lets a probe through.
+func (p *Provider) CircuitOpen(accountID int64, toMSISDN string) (time.Duration, bool) {
+	routes := p.router.Routes(accountID, toMSISDN)
+	if len(routes) == 0 {
+		return 0, false
+	}
+
+	var retryIn time.Duration
+	for i, route := range routes {
+		wait := p.breakers[route.Name].RetryIn()
+		if wait == 0 {
+			return 0, false
+		}
+
+		if i == 0 || wait < retryIn {
+			retryIn = wait
+		}
+	}
+
+	return retryIn, true
+}
+
+func (p *Provider) ProviderHealth() []smsprovider.BreakerStatus {
+	names := p.router.Names()
+
+	statuses := make([]smsprovider.BreakerStatus, len(names))
+	for i, name := range names {
+		statuses[i] = p.breakers[name].Status()
+	}
+
+	return statuses
+}
+
+func (p *Provider) logTransition(name string, from, to smsprovider.BreakerState, err error) {
+	fields := []zap.Field{
+		zap.String("provider", name),
+		zap.String("from", string(from)),
+		zap.String("to", string(to)),
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+
+	if to == smsprovider.BreakerOpen {
+		p.logger.Warn("Provider circuit opened", fields...)
+		return
+	}
+
+	p.logger.Info("Provider circuit state changed", fields...)
+}
@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	pkgmocks "github.com/Behyna/sms-services/smsgateway/pkg/mocks"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func newProviderService(t *testing.T, primary, secondary *pkgmocks.SMSProvider) service.ProviderService {
+	providerCfg := smsprovider.Config{Enable: true, Timeout: time.Second, MaxRetry: 1}
+	routes := []smsprovider.RouteConfig{
+		{Name: "primary", Priority: 1, Config: providerCfg},
+		{Name: "secondary", Priority: 2, Config: providerCfg},
+	}
+
+	router, err := smsprovider.NewRouter(routes, smsprovider.Providers{"primary": primary, "secondary": secondary})
+	assert.NoError(t, err)
+
+	cfg := &config.Config{ProviderHealth: config.ProviderHealth{
+		Breaker: smsprovider.BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}}}
+
+	return service.NewProviderService(router, zap.NewNop(), cfg)
+}
+
+func TestProvider_SendWithRetry(t *testing.T) {
+	t.Run("records the provider that accepted the message", func(t *testing.T) {
+		primary, secondary := &pkgmocks.SMSProvider{}, &pkgmocks.SMSProvider{}
+		svc := newProviderService(t, primary, secondary)
+
+		primary.On("Send", mock.Anything, "1000", "989121234567", "hi").
+			Return(smsprovider.Response{MessageID: "p-1", Provider: "upstream"}, nil)
+
+		response, err := svc.SendWithRetry(context.Background(), 1, "1000", "989121234567", "hi")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "p-1", response.MessageID)
+		assert.Equal(t, "primary", response.Provider)
+		secondary.AssertNotCalled(t, "Send")
+	})
+
+	t.Run("fails over on provider errors", func(t *testing.T) {
+		primary, secondary := &pkgmocks.SMSProvider{}, &pkgmocks.SMSProvider{}
+		svc := newProviderService(t, primary, secondary)
+
+		primary.On("Send", mock.Anything, "1000", "989121234567", "hi").
+			Return(smsprovider.Response{}, errors.New(smsprovider.ErrorCodeTimeout))
+		secondary.On("Send", mock.Anything, "1000", "989121234567", "hi").
+			Return(smsprovider.Response{MessageID: "s-1"}, nil)
+
+		response, err := svc.SendWithRetry(context.Background(), 1, "1000", "989121234567", "hi")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "secondary", response.Provider)
+	})
+
+	t.Run("does not fail over on invalid number", func(t *testing.T) {
+		primary, secondary := &pkgmocks.SMSProvider{}, &pkgmocks.SMSProvider{}
+		svc := newProviderService(t, primary, secondary)
+
+		primary.On("Send", mock.Anything, "1000", "989121234567", "hi").
+			Return(smsprovider.Response{}, errors.New(smsprovider.ErrorCodeInvalidNumber))
+
+		_, err := svc.SendWithRetry(context.Background(), 1, "1000", "989121234567", "hi")
+
+		assert.EqualError(t, err, smsprovider.ErrorCodeInvalidNumber)
+		secondary.AssertNotCalled(t, "Send")
+	})
+
+	t.Run("returns the last error when every provider fails", func(t *testing.T) {
+		primary, secondary := &pkgmocks.SMSProvider{}, &pkgmocks.SMSProvider{}
+		svc := newProviderService(t, primary, secondary)
+
+		primary.On("Send", mock.Anything, "1000", "989121234567", "hi").
+			Return(smsprovider.Response{}, errors.New(smsprovider.ErrorCodeServerError))
+		secondary.On("Send", mock.Anything, "1000", "989121234567", "hi").
+			Return(smsprovider.Response{}, errors.New(smsprovider.ErrorCodeNetworkError))
+
+		_, err := svc.SendWithRetry(context.Background(), 1, "1000", "989121234567", "hi")
+
+		assert.EqualError(t, err, smsprovider.ErrorCodeNetworkError)
+	})
+}
+
+func TestProvider_CircuitBreaker(t *testing.T) {
+	t.Run("skips a provider whose circuit opened", func(t *testing.T) {
+		primary, secondary := &pkgmocks.SMSProvider{}, &pkgmocks.SMSProvider{}
+		svc := newProviderService(t, primary, secondary)
+
+		primary.On("Send", mock.Anything, "1000", "989121234567", "hi").
+			Return(smsprovider.Response{}, errors.New(smsprovider.ErrorCodeServerError)).Times(2)
+		secondary.On("Send", mock.Anything, "1000", "989121234567", "hi").
+			Return(smsprovider.Response{MessageID: "s-1"}, nil)
+
+		for range 3 {
+			response, err := svc.SendWithRetry(context.Background(), 1, "1000", "989121234567", "hi")
+			assert.NoError(t, err)
+			assert.Equal(t, "secondary", response.Provider)
+		}
+
+		primary.AssertNumberOfCalls(t, "Send", 2)
+		assert.Equal(t, smsprovider.BreakerOpen, svc.ProviderHealth()[0].State)
+		assert.Equal(t, smsprovider.BreakerClosed, svc.ProviderHealth()[1].State)
+
+		_, open := svc.CircuitOpen(1, "989121234567")
+		assert.False(t, open)
+	})
+
+	t.Run("reports open when every provider circuit is open", func(t *testing.T) {
+		primary, secondary := &pkgmocks.SMSProvider{}, &pkgmocks.SMSProvider{}
+		svc := newProviderService(t, primary, secondary)
+
+		primary.On("Send", mock.Anything, "1000", "989121234567", "hi").
+			Return(smsprovider.Response{}, errors.New(smsprovider.ErrorCodeTimeout))
+		secondary.On("Send", mock.Anything, "1000", "989121234567", "hi").
+			Return(smsprovider.Response{}, errors.New(smsprovider.ErrorCodeNetworkError))
+
+		for range 2 {
+			_, err := svc.SendWithRetry(context.Background(), 1, "1000", "989121234567", "hi")
+			assert.Error(t, err)
+		}
+
+		retryIn, open := svc.CircuitOpen(1, "989121234567")
+		assert.True(t, open)
+		assert.Greater(t, retryIn, time.Duration(0))
+
+		_, err := svc.SendWithRetry(context.Background(), 1, "1000", "989121234567", "hi")
+		assert.EqualError(t, err, smsprovider.ErrorCodeCircuitOpen)
+	})
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/pkg/ratelimit"
+	"go.uber.org/zap"
+)
+
+const (
+	RateLimitScopeAccount     = "account"
+	RateLimitScopeSender      = "sender"
+	RateLimitScopeDestination = "destination"
+)
+
+// RateLimitError is the cause of a RATE_LIMITED service error and tells the client when to retry.
+type RateLimitError struct {
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded, retry after %s", e.Scope, e.RetryAfter)
+}
+
+type RateLimitService interface {
+	CheckSend(ctx context.Context, cmd CheckRateLimitCommand) error
+}
+
+type rateLimitCheck struct {
+	scope string
+	key   string
+	limit ratelimit.Limit
+	cost  int
+}
+
+type rateLimit struct {
+	limiter ratelimit.Limiter
+	config  ratelimit.Config
+	logger  *zap.Logger
+}
+
+func NewRateLimitService(limiter ratelimit.Limiter, config *config.Config, logger *zap.Logger) RateLimitService {
+	return &rateLimit{limiter: limiter, config: config.RateLimit, logger: logger}
+}
+
+// CheckSend takes tokens from the account, sender and destination buckets in turn and stops at the first one that
+// is exhausted; tokens already taken from earlier buckets are not returned. A request needing more tokens than a
+// bucket holds is always refused. Limiter failures are logged and let the request through so that a limiter outage
+// does not stop sending.
+func (r *rateLimit) CheckSend(ctx context.Context, cmd CheckRateLimitCommand) error {
+	if !r.config.Enabled {
+		return nil
+	}
+
+	cost := max(len(cmd.ToMSISDNs), 1)
+	checks := []rateLimitCheck{
+		{scope: RateLimitScopeAccount, key: strconv.FormatInt(cmd.AccountID, 10), limit: r.config.Account, cost: cost},
+		{scope: RateLimitScopeSender, key: cmd.FromMSISDN, limit: r.config.Sender, cost: cost},
+	}
+
+	destinations := make(map[string]int, len(cmd.ToMSISDNs))
+	for _, to := range cmd.ToMSISDNs {
+		if i, ok := destinations[to]; ok {
+			checks[i].cost++
+			continue
+		}
+
+		destinations[to] = len(checks)
+		checks = append(checks, rateLimitCheck{scope: RateLimitScopeDestination, key: to,
+			limit: r.config.Destination, cost: 1})
+	}
+
+	for _, check := range checks {
+		if check.limit.Disabled() {
+			continue
+		}
+
+		result, err := r.limiter.Allow(ctx, check.scope+":"+check.key, check.limit, check.cost)
+		if err != nil {
+			r.logger.Warn("Rate limiter unavailable, allowing request",
+				zap.String("scope", check.scope),
+				zap.Error(err))
+			continue
+		}
+
+		if !result.Allowed {
+			r.logger.Info("Rate limit exceeded",
+				zap.String("scope", check.scope),
+				zap.String("key", check.key),
+				zap.Duration("retryAfter", result.RetryAfter))
+			return NewServiceError(constants.ErrCodeRateLimited,
+				&RateLimitError{Scope: check.scope, RetryAfter: result.RetryAfter})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestRateLimit_CheckSend(t *testing.T) {
+	logger := zap.NewNop()
+	perSecond := ratelimit.Limit{Requests: 10, Per: time.Second}
+	cfg := &config.Config{RateLimit: ratelimit.Config{
+		Enabled:     true,
+		Account:     perSecond,
+		Sender:      perSecond,
+		Destination: ratelimit.Limit{Requests: 1, Per: time.Minute},
+	}}
+
+	cmd := service.CheckRateLimitCommand{AccountID: 7, FromMSISDN: "1234567890", ToMSISDNs: []string{"0987654321"}}
+	allowed := ratelimit.Result{Allowed: true}
+
+	t.Run("checks account, sender and destination buckets", func(t *testing.T) {
+		mockLimiter := &mocks.RateLimiter{}
+		svc := service.NewRateLimitService(mockLimiter, cfg, logger)
+
+		mockLimiter.On("Allow", context.Background(), "account:7", perSecond, 1).Return(allowed, nil)
+		mockLimiter.On("Allow", context.Background(), "sender:1234567890", perSecond, 1).Return(allowed, nil)
+		mockLimiter.On("Allow", context.Background(), "destination:0987654321", cfg.RateLimit.Destination, 1).
+			Return(allowed, nil)
+
+		assert.NoError(t, svc.CheckSend(context.Background(), cmd))
+		mockLimiter.AssertExpectations(t)
+	})
+
+	t.Run("charges a batch one token per message and checks every destination", func(t *testing.T) {
+		mockLimiter := &mocks.RateLimiter{}
+		svc := service.NewRateLimitService(mockLimiter, cfg, logger)
+
+		batch := service.CheckRateLimitCommand{AccountID: 7, FromMSISDN: "1234567890",
+			ToMSISDNs: []string{"0987654321", "0987654322", "0987654321"}}
+
+		mockLimiter.On("Allow", context.Background(), "account:7", perSecond, 3).Return(allowed, nil)
+		mockLimiter.On("Allow", context.Background(), "sender:1234567890", perSecond, 3).Return(allowed, nil)
+		mockLimiter.On("Allow", context.Background(), "destination:0987654321", cfg.RateLimit.Destination, 2).
+			Return(allowed, nil)
+		mockLimiter.On("Allow", context.Background(), "destination:0987654322", cfg.RateLimit.Destination, 1).
+			Return(allowed, nil)
+
+		assert.NoError(t, svc.CheckSend(context.Background(), batch))
+		mockLimiter.AssertExpectations(t)
+	})
+
+	t.Run("rejects with retry after of the exhausted bucket", func(t *testing.T) {
+		mockLimiter := &mocks.RateLimiter{}
+		svc := service.NewRateLimitService(mockLimiter, cfg, logger)
+
+		mockLimiter.On("Allow", context.Background(), "account:7", perSecond, 1).Return(allowed, nil)
+		mockLimiter.On("Allow", context.Background(), "sender:1234567890", perSecond, 1).
+			Return(ratelimit.Result{RetryAfter: 300 * time.Millisecond}, nil)
+
+		err := svc.CheckSend(context.Background(), cmd)
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeRateLimited, serviceErr.Code)
+
+		var limitErr *service.RateLimitError
+		assert.True(t, errors.As(err, &limitErr))
+		assert.Equal(t, service.RateLimitScopeSender, limitErr.Scope)
+		assert.Equal(t, 300*time.Millisecond, limitErr.RetryAfter)
+		mockLimiter.AssertNotCalled(t, "Allow", mock.Anything, "destination:0987654321", mock.Anything, mock.Anything)
+	})
+
+	t.Run("allows the request when the limiter fails", func(t *testing.T) {
+		mockLimiter := &mocks.RateLimiter{}
+		svc := service.NewRateLimitService(mockLimiter, cfg, logger)
+
+		mockLimiter.On("Allow", mock.Anything, mock.Anything, mock.Anything, 1).
+			Return(ratelimit.Result{}, errors.New("connection refused"))
+
+		assert.NoError(t, svc.CheckSend(context.Background(), cmd))
+	})
+
+	t.Run("skips the limiter when disabled", func(t *testing.T) {
+		mockLimiter := &mocks.RateLimiter{}
+		svc := service.NewRateLimitService(mockLimiter, &config.Config{}, logger)
+
+		assert.NoError(t, svc.CheckSend(context.Background(), cmd))
+		mockLimiter.AssertNotCalled(t, "Allow")
+	})
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"go.uber.org/zap"
+)
+
+// receiptRetryDelays spaces the attempts at a receipt that found no message. A receipt can overtake the commit that
+// records the provider message id it names; after the last delay it is taken to be for a message we never sent, such
+// as a later segment of a concatenated one.
+var receiptRetryDelays = []time.Duration{
+	200 * time.Millisecond, time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second,
+}
+
+// ReceiptQueue applies receipts that arrive on a provider session, off the session's read loop. A fixed number of
+// workers apply them, and at most size receipts are queued or waiting for a retry at once.
+type ReceiptQueue struct {
+	reports DeliveryReportService
+	queue   chan receiptAttempt
+	held    atomic.Int64
+	size    int64
+	logger  *zap.Logger
+}
+
+type receiptAttempt struct {
+	cmd     DeliveryReportCommand
+	attempt int
+}
+
+func NewReceiptQueue(reports DeliveryReportService, size int, logger *zap.Logger) *ReceiptQueue {
+	return &ReceiptQueue{reports: reports, queue: make(chan receiptAttempt, size), size: int64(size),
+		logger: logger}
+}
+
+// Enqueue reports false when the queue is full, so the provider can be asked to deliver the receipt again later.
+func (q *ReceiptQueue) Enqueue(cmd DeliveryReportCommand) bool {
+	if q.held.Add(1) > q.size {
+		q.held.Add(-1)
+		return false
+	}
+
+	q.queue <- receiptAttempt{cmd: cmd}
+	return true
+}
+
+// Run applies receipts on workers goroutines until ctx is done. Receipts still queued or waiting then are dropped;
+// the provider has already been told they were received.
+func (q *ReceiptQueue) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case next := <-q.queue:
+					q.apply(ctx, next)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// apply never waits for a retry itself: the receipt goes back on the queue once its delay has passed, so a run of
+// unmatched receipts does not hold up the workers.
+func (q *ReceiptQueue) apply(ctx context.Context, next receiptAttempt) {
+	_, err := q.reports.ProcessDeliveryReport(ctx, next.cmd)
+	if err == nil {
+		q.held.Add(-1)
+		return
+	}
+
+	if !retryableReceipt(err) || next.attempt == len(receiptRetryDelays) {
+		q.held.Add(-1)
+		q.logger.Warn("Receipt not applied",
+			zap.String("provider", next.cmd.Provider),
+			zap.String("providerMsgID", next.cmd.ProviderMsgID),
+			zap.Int("attempts", next.attempt+1),
+			zap.Error(err))
+		return
+	}
+
+	delay := receiptRetryDelays[next.attempt]
+	next.attempt++
+	time.AfterFunc(delay, func() { q.queue <- next })
+}
+
+// retryableReceipt holds for receipts that may succeed later: the message is not recorded as submitted yet, or the
+// database failed.
+func retryableReceipt(err error) bool {
+	var serviceErr Error
+	if !errors.As(err, &serviceErr) {
+		return false
+	}
+
+	return serviceErr.Code == constants.ErrCodeMessageNotFound || serviceErr.Code == ErrCodeDatabase
+}
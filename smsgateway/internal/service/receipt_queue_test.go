@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestReceiptQueue(t *testing.T) {
+	cmd := service.DeliveryReportCommand{Provider: "primary", ProviderMsgID: "msg-1", Status: "DELIVRD"}
+	notFound := service.NewServiceError(constants.ErrCodeMessageNotFound, errors.New("not found"))
+
+	run := func(t *testing.T, queue *service.ReceiptQueue) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			queue.Run(ctx, 2)
+		}()
+		t.Cleanup(func() { cancel(); <-done })
+	}
+
+	t.Run("retries a receipt that overtook its submit", func(t *testing.T) {
+		applied := make(chan struct{})
+		reports := new(mocks.DeliveryReportService)
+		reports.On("ProcessDeliveryReport", mock.Anything, cmd).
+			Return(service.DeliveryReportResult{}, notFound).Once()
+		reports.On("ProcessDeliveryReport", mock.Anything, cmd).
+			Return(service.DeliveryReportResult{MessageID: 1, Applied: true}, nil).Once().
+			Run(func(mock.Arguments) { close(applied) })
+
+		queue := service.NewReceiptQueue(reports, 10, zap.NewNop())
+		run(t, queue)
+
+		assert.True(t, queue.Enqueue(cmd))
+		select {
+		case <-applied:
+		case <-time.After(2 * time.Second):
+			t.Fatal("receipt not retried")
+		}
+	})
+
+	t.Run("does not retry a receipt the service rejects", func(t *testing.T) {
+		reports := new(mocks.DeliveryReportService)
+		reports.On("ProcessDeliveryReport", mock.Anything, cmd).
+			Return(service.DeliveryReportResult{}, errors.New("invalid")).Once()
+
+		queue := service.NewReceiptQueue(reports, 10, zap.NewNop())
+		run(t, queue)
+
+		assert.True(t, queue.Enqueue(cmd))
+		time.Sleep(400 * time.Millisecond)
+		reports.AssertNumberOfCalls(t, "ProcessDeliveryReport", 1)
+	})
+
+	t.Run("refuses receipts beyond its size", func(t *testing.T) {
+		queue := service.NewReceiptQueue(new(mocks.DeliveryReportService), 1, zap.NewNop())
+
+		assert.True(t, queue.Enqueue(cmd))
+		assert.False(t, queue.Enqueue(cmd))
+	})
+}
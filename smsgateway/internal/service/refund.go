@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/Behyna/common/pkg/mq"
@@ -22,13 +21,14 @@ type Refund struct {
 	txLogRepo   repository.TxLogRepository
 	txManager   repository.TxManager
 	payment     PaymentService
+	webhook     WebhookService
 	logger      *zap.Logger
 }
 
 func NewRefundService(messageRepo repository.MessageRepository, txLogRepo repository.TxLogRepository,
-	txManager repository.TxManager, payment PaymentService, logger *zap.Logger) RefundService {
+	txManager repository.TxManager, payment PaymentService, webhook WebhookService, logger *zap.Logger) RefundService {
 	return &Refund{messageRepo: messageRepo, txLogRepo: txLogRepo, txManager: txManager,
-		payment: payment, logger: logger}
+		payment: payment, webhook: webhook, logger: logger}
 }
 
 func (r *Refund) Refund(ctx context.Context, cmd ProcessRefundCommand) error {
@@ -55,7 +55,7 @@ func (r *Refund) Refund(ctx context.Context, cmd ProcessRefundCommand) error {
 	pgRequest := RefundPaymentCommand{
 		UserID:         cmd.FromMSISDN,
 		Amount:         int64(cmd.Amount),
-		IdempotencyKey: fmt.Sprintf("refund-%s-%s", cmd.FromMSISDN, cmd.ClientMessageID),
+		IdempotencyKey: messageIdempotencyKey("refund-", cmd.FromMSISDN, cmd.ClientMessageID),
 	}
 
 	err = r.payment.Refund(ctx, pgRequest)
@@ -151,6 +151,15 @@ func (r *Refund) updateMessageToRefunded(ctx context.Context, messageID int64) e
 			return err
 		}
 
+		notifyCmd := NotifyWebhookCommand{MessageID: messageID, Event: model.WebhookEventRefunded,
+			Status: model.MessageStatusRefunded}
+		if err := r.webhook.Notify(ctx, notifyCmd); err != nil {
+			r.logger.Error("Failed to queue refunded webhook event",
+				zap.Int64("messageID", messageID),
+				zap.Error(err))
+			return err
+		}
+
 		return nil
 	})
 
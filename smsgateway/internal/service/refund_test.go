@@ -32,7 +32,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
@@ -40,7 +41,7 @@ func TestRefund_Refund(t *testing.T) {
 			State:     model.TxLogStateFailed,
 		}
 
-		expectedIdempotencyKey := "refund-1234567890-abc"
+		expectedIdempotencyKey := paymentKey("refund-", "1234567890", "abc")
 
 		mockTxLogRepo.On("GetByID", int64(1)).Return(txLog, nil)
 
@@ -82,7 +83,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		mockTxLogRepo.On("GetByID", int64(1)).Return((*model.TxLog)(nil), repository.ErrTxLogNotFound)
 
@@ -101,7 +103,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		dbError := errors.New("database connection failed")
 		mockTxLogRepo.On("GetByID", int64(1)).Return((*model.TxLog)(nil), dbError)
@@ -121,7 +124,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
@@ -145,7 +149,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
@@ -169,7 +174,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
@@ -193,7 +199,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
@@ -217,7 +224,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
@@ -241,7 +249,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
@@ -274,7 +283,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
@@ -308,7 +318,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
@@ -342,7 +353,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
@@ -379,7 +391,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
@@ -419,7 +432,8 @@ func TestRefund_Refund(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockPayment := &mocks.PaymentService{}
 
-		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment, logger)
+		svc := service.NewRefundService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockPayment,
+			newWebhookService(), logger)
 
 		txLog := &model.TxLog{
 			ID:        1,
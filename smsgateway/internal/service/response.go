@@ -1,12 +1,32 @@
 package service
 
+import "github.com/Behyna/sms-services/smsgateway/pkg/smstemplate"
+
 type CreateMessageResponse struct {
-	MessageID int64 `json:"message_id"`
+	MessageID int64  `json:"message_id"`
+	Status    string `json:"status"`
+	Duplicate bool   `json:"duplicate"`
+}
+
+type CreateMessageBatchResponse struct {
+	BatchID string            `json:"batch_id"`
+	Amount  int64             `json:"amount"`
+	Results []BatchItemResult `json:"results"`
+}
+
+type BatchItemResult struct {
+	Index           int    `json:"index"`
+	ClientMessageID string `json:"client_message_id"`
+	MessageID       int64  `json:"message_id"`
+	Status          string `json:"status"`
+	Segments        int    `json:"segments"`
+	ErrorCode       string `json:"error_code,omitempty"`
 }
 
 type GetMessagesResponse struct {
-	Messages []Message `json:"messages"`
-	Total    int64     `json:"total"`
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Total      *int64    `json:"total,omitempty"`
 }
 
 type Message struct {
@@ -17,3 +37,144 @@ type Message struct {
 	Status    string `json:"status"`
 	CreatedAt string `json:"created_at"`
 }
+
+type MessageDetails struct {
+	ID              int64          `json:"id"`
+	ClientMessageID string         `json:"client_message_id"`
+	BatchID         *string        `json:"batch_id,omitempty"`
+	TemplateID      *int64         `json:"template_id,omitempty"`
+	TemplateVersion *int           `json:"template_version,omitempty"`
+	From            string         `json:"from"`
+	To              string         `json:"to"`
+	Text            string         `json:"text"`
+	Status          string         `json:"status"`
+	SegmentCount    int            `json:"segment_count"`
+	Encoding        string         `json:"encoding"`
+	AttemptCount    int            `json:"attempt_count"`
+	LastAttemptAt   *string        `json:"last_attempt_at"`
+	Provider        *string        `json:"provider"`
+	ProviderMsgID   *string        `json:"provider_msg_id"`
+	LastError       *string        `json:"last_error"`
+	ReportedAt      *string        `json:"reported_at"`
+	SendAt          *string        `json:"send_at"`
+	ExpiresAt       *string        `json:"expires_at"`
+	Charge          *ChargeDetails `json:"charge"`
+	CreatedAt       string         `json:"created_at"`
+	UpdatedAt       string         `json:"updated_at"`
+}
+
+type ChargeDetails struct {
+	Amount      int    `json:"amount"`
+	State       string `json:"state"`
+	RefundState string `json:"refund_state"`
+}
+
+type DeliveryReportResult struct {
+	MessageID int64  `json:"message_id"`
+	Status    string `json:"status"`
+	Applied   bool   `json:"applied"`
+}
+
+type WebhookDetails struct {
+	UserID    string `json:"user_id"`
+	URL       string `json:"url"`
+	Secret    string `json:"secret,omitempty"`
+	Enabled   bool   `json:"enabled"`
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+type WebhookPayload struct {
+	Event           string `json:"event"`
+	MessageID       int64  `json:"message_id"`
+	ClientMessageID string `json:"client_message_id"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	Status          string `json:"status"`
+	Error           string `json:"error,omitempty"`
+	OccurredAt      string `json:"occurred_at"`
+}
+
+type CancelMessageResponse struct {
+	MessageID   int64  `json:"message_id"`
+	Status      string `json:"status"`
+	RefundState string `json:"refund_state"`
+}
+
+type AccountDetails struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Senders    []string `json:"senders"`
+	Enabled    bool     `json:"enabled"`
+	OTPEnabled bool     `json:"otp_enabled"`
+}
+
+type APIKeyDetails struct {
+	ID        int64  `json:"id"`
+	AccountID int64  `json:"account_id"`
+	Name      string `json:"name"`
+	Prefix    string `json:"prefix"`
+	Key       string `json:"key,omitempty"`
+}
+
+type TemplateDetails struct {
+	ID           int64                     `json:"id"`
+	Name         string                    `json:"name"`
+	Version      int                       `json:"version"`
+	Body         string                    `json:"body,omitempty"`
+	Placeholders []smstemplate.Placeholder `json:"placeholders,omitempty"`
+	CreatedAt    string                    `json:"created_at"`
+	UpdatedAt    string                    `json:"updated_at"`
+}
+
+type RenderedTemplate struct {
+	TemplateID int64
+	Version    int
+	Text       string
+}
+
+type SuppressionDetails struct {
+	ID        int64  `json:"id"`
+	MSISDN    string `json:"msisdn"`
+	Scope     string `json:"scope"`
+	Reason    string `json:"reason"`
+	AddedBy   string `json:"added_by"`
+	CreatedAt string `json:"created_at"`
+}
+
+type SuppressionStatus struct {
+	MSISDN     string               `json:"msisdn"`
+	Suppressed bool                 `json:"suppressed"`
+	Entries    []SuppressionDetails `json:"entries"`
+}
+
+type ImportSuppressionsResponse struct {
+	Added    int64 `json:"added"`
+	Existing int64 `json:"existing"`
+}
+
+type ListSuppressionsResponse struct {
+	Suppressions []SuppressionDetails `json:"suppressions"`
+	NextAfterID  int64                `json:"next_after_id,omitempty"`
+}
+
+type ReceiveInboundResponse struct {
+	Received   int `json:"received"`
+	Duplicates int `json:"duplicates"`
+}
+
+type InboundMessageDetails struct {
+	ID            int64   `json:"id"`
+	Provider      string  `json:"provider"`
+	ProviderMsgID *string `json:"provider_msg_id,omitempty"`
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	Text          string  `json:"text"`
+	Keyword       *string `json:"keyword,omitempty"`
+	ReceivedAt    string  `json:"received_at"`
+}
+
+type ListInboundResponse struct {
+	Messages     []InboundMessageDetails `json:"messages"`
+	NextBeforeID int64                   `json:"next_before_id,omitempty"`
+}
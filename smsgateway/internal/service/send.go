@@ -6,33 +6,76 @@ import (
 	"time"
 
 	"github.com/Behyna/common/pkg/mq"
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
 	"github.com/Behyna/sms-services/smsgateway/internal/model"
 	"github.com/Behyna/sms-services/smsgateway/internal/repository"
 	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
 	"go.uber.org/zap"
 )
 
-const maxRetries = 3
-
 type SendService interface {
 	SendMessage(ctx context.Context, cmd SendMessageCommand) error
 }
 
+// AttemptError is returned when a provider call failed temporarily. Only these use up a step of the message's retry
+// schedule; a send held back by a database error or open circuits keeps its place, so an outage cannot exhaust the
+// schedule before the message's attempts are spent and it is failed and refunded.
+type AttemptError struct {
+	Err error
+}
+
+func (e AttemptError) Error() string {
+	return e.Err.Error()
+}
+
+func (e AttemptError) Unwrap() error {
+	return e.Err
+}
+
+func (e AttemptError) Temporary() bool {
+	return true
+}
+
+// DeferError asks for the message to be redelivered after RetryIn. Like other failures that are not an AttemptError
+// it keeps the message's place in the retry schedule.
+type DeferError struct {
+	Err     error
+	RetryIn time.Duration
+}
+
+func (e DeferError) Error() string {
+	return e.Err.Error()
+}
+
+func (e DeferError) Unwrap() error {
+	return e.Err
+}
+
+func (e DeferError) Temporary() bool {
+	return true
+}
+
 type send struct {
 	messageRepo repository.MessageRepository
 	txLogRepo   repository.TxLogRepository
 	txManager   repository.TxManager
 	provider    ProviderService
+	suppression SuppressionService
+	webhook     WebhookService
+	maxAttempts int
 	logger      *zap.Logger
 }
 
 func NewSendService(messageRepo repository.MessageRepository, txLogRepo repository.TxLogRepository,
-	txManager repository.TxManager, provider ProviderService, logger *zap.Logger) SendService {
-	return &send{messageRepo: messageRepo, txLogRepo: txLogRepo, txManager: txManager, provider: provider, logger: logger}
+	txManager repository.TxManager, provider ProviderService, suppression SuppressionService, webhook WebhookService,
+	config *config.Config, logger *zap.Logger) SendService {
+	return &send{messageRepo: messageRepo, txLogRepo: txLogRepo, txManager: txManager, provider: provider,
+		suppression: suppression, webhook: webhook, maxAttempts: config.SendWorker.Attempts(), logger: logger}
 }
 
 func (s *send) SendMessage(ctx context.Context, cmd SendMessageCommand) error {
-	msg, err := s.getMessageForProcessing(cmd.MessageID)
+	msg, err := s.getMessageForProcessing(ctx, cmd.MessageID)
 	if err != nil {
 		s.logger.Debug("Message not processable",
 			zap.Int64("messageID", cmd.MessageID),
@@ -50,7 +93,7 @@ func (s *send) SendMessage(ctx context.Context, cmd SendMessageCommand) error {
 		attemptCount += 1
 	}
 
-	if attemptCount > maxRetries {
+	if attemptCount > s.maxAttempts {
 		s.logger.Warn("Message exceeded max retries",
 			zap.Int64("messageID", cmd.MessageID),
 			zap.Int("attempts", attemptCount))
@@ -63,6 +106,34 @@ func (s *send) SendMessage(ctx context.Context, cmd SendMessageCommand) error {
 		return nil
 	}
 
+	var accountID int64
+	if msg.AccountID != nil {
+		accountID = *msg.AccountID
+	}
+
+	// The recipient may have opted out after the message was accepted; such messages fail and are refunded.
+	suppressed, err := s.suppression.SuppressedRecipients(ctx, accountID, []string{cmd.ToMSISDN})
+	if err != nil {
+		return mq.Temporary(err)
+	}
+
+	if suppressed[cmd.ToMSISDN] {
+		s.logger.Info("Recipient suppressed since message was accepted, marking for refund",
+			zap.Int64("messageID", cmd.MessageID))
+
+		updateFailedCmd := UpdateMessageFailureCommand{MessageID: cmd.MessageID,
+			LastError: constants.ErrCodeRecipientSuppressed}
+		if err := s.updateMessageToPermanentFailure(ctx, updateFailedCmd); err != nil {
+			return mq.Temporary(err)
+		}
+
+		return nil
+	}
+
+	if retryIn, open := s.provider.CircuitOpen(accountID, cmd.ToMSISDN); open {
+		return s.deferWhileCircuitOpen(ctx, cmd.MessageID, retryIn)
+	}
+
 	updateSendingCmd := UpdateMessageToSendingCommand{MessageID: cmd.MessageID, AttemptCount: attemptCount}
 	if err := s.updateMessageToSending(ctx, updateSendingCmd); err != nil {
 		if errors.Is(err, ErrMessageBeingProcessed) {
@@ -78,11 +149,11 @@ func (s *send) SendMessage(ctx context.Context, cmd SendMessageCommand) error {
 	s.logger.Debug("Attempting to send SMS",
 		zap.Int64("messageID", cmd.MessageID),
 		zap.Int("attempt", attemptCount),
-		zap.Int("maxRetries", maxRetries),
+		zap.Int("maxAttempts", s.maxAttempts),
 		zap.String("to", cmd.ToMSISDN),
 		zap.String("from", cmd.FromMSISDN))
 
-	response, lastErr := s.provider.SendWithRetry(ctx, cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text)
+	response, lastErr := s.provider.SendWithRetry(ctx, accountID, cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text)
 	if lastErr == nil {
 		s.logger.Info("SMS sent successfully",
 			zap.Int64("messageID", cmd.MessageID),
@@ -99,10 +170,10 @@ func (s *send) SendMessage(ctx context.Context, cmd SendMessageCommand) error {
 		zap.Int64("messageID", cmd.MessageID),
 		zap.Int("attempt", attemptCount))
 
-	if lastErr.Error() == smsprovider.ErrorCodeInvalidNumber {
-		s.logger.Warn("Permanent failure due to invalid number, marking for refund",
+	if smsprovider.IsPermanent(lastErr) {
+		s.logger.Warn("Permanent provider failure, marking for refund",
 			zap.Int64("messageID", cmd.MessageID),
-			zap.String("reason", "invalid_number"))
+			zap.String("reason", lastErr.Error()))
 
 		updateFailedCmd := UpdateMessageFailureCommand{MessageID: cmd.MessageID, LastError: lastErr.Error()}
 		if err := s.updateMessageToPermanentFailure(ctx, updateFailedCmd); err != nil {
@@ -115,7 +186,7 @@ func (s *send) SendMessage(ctx context.Context, cmd SendMessageCommand) error {
 	s.logger.Debug("Temporary failure, will retry",
 		zap.Int64("messageID", cmd.MessageID),
 		zap.Int("attempt", attemptCount),
-		zap.Int("remainingRetries", maxRetries-attemptCount),
+		zap.Int("remainingAttempts", s.maxAttempts-attemptCount),
 		zap.Error(lastErr))
 
 	updateFailedCmd := UpdateMessageFailureCommand{MessageID: cmd.MessageID, LastError: lastErr.Error()}
@@ -123,10 +194,26 @@ func (s *send) SendMessage(ctx context.Context, cmd SendMessageCommand) error {
 		return mq.Temporary(err)
 	}
 
-	return mq.Temporary(lastErr)
+	return AttemptError{Err: mq.Temporary(lastErr)}
+}
+
+// deferWhileCircuitOpen holds back a message whose providers are all known to be down. The message goes to
+// FAILED_TEMP without using up an attempt and is redelivered once the first breaker is due to let a probe through,
+// so consumers neither call a dead provider nor spin on redeliveries.
+func (s *send) deferWhileCircuitOpen(ctx context.Context, messageID int64, retryIn time.Duration) error {
+	s.logger.Warn("All provider circuits open, deferring message",
+		zap.Int64("messageID", messageID),
+		zap.Duration("retryIn", retryIn))
+
+	updateFailedCmd := UpdateMessageFailureCommand{MessageID: messageID, LastError: smsprovider.ErrorCodeCircuitOpen}
+	if err := s.updateMessageToTemporaryFailure(ctx, updateFailedCmd); err != nil {
+		return mq.Temporary(err)
+	}
+
+	return DeferError{Err: mq.Temporary(errors.New(smsprovider.ErrorCodeCircuitOpen)), RetryIn: retryIn}
 }
 
-func (s *send) getMessageForProcessing(messageID int64) (*model.Message, error) {
+func (s *send) getMessageForProcessing(ctx context.Context, messageID int64) (*model.Message, error) {
 	msg, err := s.messageRepo.GetByID(messageID)
 	if err != nil {
 		if errors.Is(err, repository.ErrMessageNotFound) {
@@ -138,7 +225,7 @@ func (s *send) getMessageForProcessing(messageID int64) (*model.Message, error)
 
 	switch msg.Status {
 	case model.MessageStatusCreated:
-		return msg, nil
+		return s.checkExpiry(ctx, msg)
 
 	case model.MessageStatusSending:
 		if msg.LastAttemptAt != nil && time.Since(*msg.LastAttemptAt) < 5*time.Minute {
@@ -148,16 +235,18 @@ func (s *send) getMessageForProcessing(messageID int64) (*model.Message, error)
 			return nil, ErrMessageBeingProcessed
 		}
 
-		return msg, nil
+		return s.checkExpiry(ctx, msg)
 
-	case model.MessageStatusSubmitted, model.MessageStatusFailedPerm, model.MessageStatusRefunded:
+	case model.MessageStatusSubmitted, model.MessageStatusFailedPerm, model.MessageStatusRefunded,
+		model.MessageStatusDelivered, model.MessageStatusUndelivered, model.MessageStatusExpired,
+		model.MessageStatusRejected, model.MessageStatusCancelled:
 		s.logger.Info("Message already processed successfully",
 			zap.Int64("messageID", messageID), zap.String("status", string(msg.Status)))
 		return nil, ErrMessageAlreadyProcessed
 
 	case model.MessageStatusFailedTemp:
 		s.logger.Info("Message was temporarily failed, retrying", zap.Int64("messageID", messageID))
-		return msg, nil
+		return s.checkExpiry(ctx, msg)
 
 	default:
 		s.logger.Error("Unknown message status",
@@ -167,6 +256,26 @@ func (s *send) getMessageForProcessing(messageID int64) (*model.Message, error)
 	}
 }
 
+func (s *send) checkExpiry(ctx context.Context, msg *model.Message) (*model.Message, error) {
+	if !isExpired(msg.ExpiresAt) {
+		return msg, nil
+	}
+
+	s.logger.Warn("Message validity period expired, marking for refund",
+		zap.Int64("messageID", msg.ID),
+		zap.Time("expiresAt", *msg.ExpiresAt))
+
+	err := expireMessage(ctx, s.txManager, s.messageRepo, s.txLogRepo, msg.ID)
+	if err != nil && !errors.Is(err, repository.ErrNoRowsAffected) {
+		s.logger.Error("Failed to expire message",
+			zap.Int64("messageID", msg.ID),
+			zap.Error(err))
+		return nil, ErrDatabase
+	}
+
+	return nil, ErrMessageExpired
+}
+
 func (s *send) updateMessageToSending(ctx context.Context, cmd UpdateMessageToSendingCommand) error {
 	staleThreshold := time.Now().Add(-5 * time.Minute)
 
@@ -200,11 +309,20 @@ func (s *send) updateMessageToSending(ctx context.Context, cmd UpdateMessageToSe
 
 func (s *send) updateMessageSucceed(ctx context.Context, cmd UpdateMessageSuccessCommand) error {
 	msg := model.Message{
-		ID:            cmd.MessageID,
-		Status:        model.MessageStatusSubmitted,
-		ProviderMsgID: &cmd.ProviderMsgID,
-		Provider:      &cmd.Provider,
-		UpdatedAt:     time.Now(),
+		ID:        cmd.MessageID,
+		Status:    model.MessageStatusSubmitted,
+		Provider:  &cmd.Provider,
+		UpdatedAt: time.Now(),
+	}
+
+	// Ids are unique per provider, so a provider that accepted the message without one leaves provider_msg_id NULL
+	// rather than sharing '' with every other such message. No receipt can be matched to it.
+	if cmd.ProviderMsgID != "" {
+		msg.ProviderMsgID = &cmd.ProviderMsgID
+	} else {
+		s.logger.Warn("Provider accepted message without a message id",
+			zap.Int64("messageID", cmd.MessageID),
+			zap.String("provider", cmd.Provider))
 	}
 
 	if err := s.messageRepo.Update(ctx, &msg); err != nil {
@@ -229,6 +347,16 @@ func (s *send) updateMessageSucceed(ctx context.Context, cmd UpdateMessageSucces
 			zap.Error(err))
 	}
 
+	// The message is with the provider, so nothing here may fail the send and get it retried: like the updates above,
+	// the submitted event is best-effort and a failure to queue it is only logged.
+	notifyCmd := NotifyWebhookCommand{MessageID: cmd.MessageID, Event: model.WebhookEventSubmitted,
+		Status: model.MessageStatusSubmitted}
+	if err := s.webhook.Notify(ctx, notifyCmd); err != nil {
+		s.logger.Warn("Failed to queue submitted webhook event",
+			zap.Int64("messageID", cmd.MessageID),
+			zap.Error(err))
+	}
+
 	return nil
 }
 
@@ -263,6 +391,15 @@ func (s *send) updateMessageToPermanentFailure(ctx context.Context, cmd UpdateMe
 			return err
 		}
 
+		notifyCmd := NotifyWebhookCommand{MessageID: cmd.MessageID, Event: model.WebhookEventFailedPerm,
+			Status: model.MessageStatusFailedPerm, Error: cmd.LastError}
+		if err := s.webhook.Notify(ctx, notifyCmd); err != nil {
+			s.logger.Error("Failed to queue failed_perm webhook event",
+				zap.Int64("messageID", cmd.MessageID),
+				zap.Error(err))
+			return err
+		}
+
 		return nil
 	})
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
 	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
 	"github.com/Behyna/sms-services/smsgateway/internal/model"
 	"github.com/Behyna/sms-services/smsgateway/internal/repository"
@@ -18,6 +19,7 @@ import (
 
 func TestSend_SendMessage(t *testing.T) {
 	logger := zap.NewNop()
+	cfg := &config.Config{}
 
 	cmd := service.SendMessageCommand{
 		MessageID:  123,
@@ -32,10 +34,13 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
+		accountID := int64(7)
 		message := &model.Message{
 			ID:           123,
+			AccountID:    &accountID,
 			Status:       model.MessageStatusCreated,
 			AttemptCount: 0,
 		}
@@ -48,6 +53,7 @@ func TestSend_SendMessage(t *testing.T) {
 
 		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
 
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
 		mockMessageRepo.On("UpdateForSending", context.Background(),
 			mock.MatchedBy(func(msg *model.Message) bool {
 				return msg.ID == 123 &&
@@ -56,7 +62,7 @@ func TestSend_SendMessage(t *testing.T) {
 					msg.LastAttemptAt != nil
 			}), mock.AnythingOfType("time.Time")).Return(nil)
 
-		mockProvider.On("SendWithRetry", context.Background(), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
+		mockProvider.On("SendWithRetry", context.Background(), accountID, cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
 			Return(providerResponse, nil)
 
 		mockMessageRepo.On("Update", context.Background(),
@@ -89,7 +95,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:           123,
@@ -105,12 +112,13 @@ func TestSend_SendMessage(t *testing.T) {
 
 		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
 
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
 		mockMessageRepo.On("UpdateForSending", context.Background(),
 			mock.MatchedBy(func(msg *model.Message) bool {
 				return msg.ID == 123 && msg.AttemptCount == 2
 			}), mock.AnythingOfType("time.Time")).Return(nil)
 
-		mockProvider.On("SendWithRetry", context.Background(), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
+		mockProvider.On("SendWithRetry", context.Background(), int64(0), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
 			Return(providerResponse, nil)
 
 		mockMessageRepo.On("Update", context.Background(), mock.AnythingOfType("*model.Message")).Return(nil)
@@ -125,13 +133,41 @@ func TestSend_SendMessage(t *testing.T) {
 		mockProvider.AssertExpectations(t)
 	})
 
+	t.Run("leaves the provider message id null when the provider returns none", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockProvider := &mocks.ProviderService{}
+
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, &mocks.TxManager{}, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
+
+		mockMessageRepo.On("GetByID", int64(123)).
+			Return(&model.Message{ID: 123, Status: model.MessageStatusCreated}, nil)
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
+		mockMessageRepo.On("UpdateForSending", context.Background(), mock.AnythingOfType("*model.Message"),
+			mock.AnythingOfType("time.Time")).Return(nil)
+		mockProvider.On("SendWithRetry", context.Background(), int64(0), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
+			Return(smsprovider.Response{Provider: "test-provider", Status: "sent"}, nil)
+		mockMessageRepo.On("Update", context.Background(),
+			mock.MatchedBy(func(msg *model.Message) bool {
+				return msg.Status == model.MessageStatusSubmitted && msg.ProviderMsgID == nil
+			})).Return(nil)
+		mockTxLogRepo.On("UpdateByMessageID", context.Background(), mock.AnythingOfType("*model.TxLog")).Return(nil)
+
+		err := svc.SendMessage(context.Background(), cmd)
+
+		assert.NoError(t, err)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
 	t.Run("dequeue when message not found", func(t *testing.T) {
 		mockMessageRepo := &mocks.MessageRepository{}
 		mockTxLogRepo := &mocks.TxLogRepository{}
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		mockMessageRepo.On("GetByID", int64(123)).Return((*model.Message)(nil), repository.ErrMessageNotFound)
 
@@ -149,7 +185,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		dbError := errors.New("database connection failed")
 		mockMessageRepo.On("GetByID", int64(123)).Return((*model.Message)(nil), dbError)
@@ -158,8 +195,60 @@ func TestSend_SendMessage(t *testing.T) {
 
 		assert.Error(t, err)
 		assert.True(t, isTemporaryError(err))
+		assert.NotErrorAs(t, err, new(service.AttemptError))
+
+		mockMessageRepo.AssertExpectations(t)
+		mockProvider.AssertNotCalled(t, "SendWithRetry")
+	})
+
+	t.Run("expires message past its validity period instead of sending", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockProvider := &mocks.ProviderService{}
+
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
+
+		expiresAt := time.Now().Add(-time.Minute)
+		mockMessageRepo.On("GetByID", int64(123)).
+			Return(&model.Message{ID: 123, Status: model.MessageStatusFailedTemp, ExpiresAt: &expiresAt}, nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("UpdateForExpiry", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(msg *model.Message) bool {
+				return msg.ID == 123 && msg.Status == model.MessageStatusExpired
+			})).Return(nil)
+		mockTxLogRepo.On("UpdateForPermFailed", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(txLog *model.TxLog) bool {
+				return txLog.MessageID == 123 && txLog.State == model.TxLogStateFailed && !txLog.Published
+			})).Return(nil)
 
+		err := svc.SendMessage(context.Background(), cmd)
+
+		assert.NoError(t, err)
 		mockMessageRepo.AssertExpectations(t)
+		mockTxLogRepo.AssertExpectations(t)
+		mockProvider.AssertNotCalled(t, "SendWithRetry")
+	})
+
+	t.Run("requeue when expiring message fails", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockProvider := &mocks.ProviderService{}
+
+		svc := service.NewSendService(mockMessageRepo, &mocks.TxLogRepository{}, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
+
+		expiresAt := time.Now().Add(-time.Minute)
+		mockMessageRepo.On("GetByID", int64(123)).
+			Return(&model.Message{ID: 123, Status: model.MessageStatusCreated, ExpiresAt: &expiresAt}, nil)
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(errors.New("connection lost"))
+
+		err := svc.SendMessage(context.Background(), cmd)
+
+		assert.True(t, isTemporaryError(err))
 		mockProvider.AssertNotCalled(t, "SendWithRetry")
 	})
 
@@ -169,7 +258,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:     123,
@@ -192,7 +282,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:     123,
@@ -215,7 +306,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:     123,
@@ -238,7 +330,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		recentTime := time.Now().Add(-2 * time.Minute)
 		message := &model.Message{
@@ -263,7 +356,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		staleTime := time.Now().Add(-10 * time.Minute)
 		message := &model.Message{
@@ -281,12 +375,13 @@ func TestSend_SendMessage(t *testing.T) {
 
 		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
 
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
 		mockMessageRepo.On("UpdateForSending", context.Background(),
 			mock.MatchedBy(func(msg *model.Message) bool {
 				return msg.ID == 123 && msg.AttemptCount == 1
 			}), mock.AnythingOfType("time.Time")).Return(nil)
 
-		mockProvider.On("SendWithRetry", context.Background(), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
+		mockProvider.On("SendWithRetry", context.Background(), int64(0), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
 			Return(providerResponse, nil)
 
 		mockMessageRepo.On("Update", context.Background(), mock.AnythingOfType("*model.Message")).Return(nil)
@@ -307,7 +402,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:           123,
@@ -342,13 +438,88 @@ func TestSend_SendMessage(t *testing.T) {
 		mockProvider.AssertNotCalled(t, "SendWithRetry")
 	})
 
+	t.Run("fails and refunds a message whose recipient was suppressed after it was accepted", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockProvider := &mocks.ProviderService{}
+		mockSuppression := &mocks.SuppressionService{}
+
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			mockSuppression, newWebhookService(), cfg, logger)
+
+		accountID := int64(7)
+		mockMessageRepo.On("GetByID", int64(123)).
+			Return(&model.Message{ID: 123, AccountID: &accountID, Status: model.MessageStatusCreated}, nil)
+		mockSuppression.On("SuppressedRecipients", context.Background(), accountID, []string{cmd.ToMSISDN}).
+			Return(map[string]bool{cmd.ToMSISDN: true}, nil)
+
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockMessageRepo.On("Update", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(msg *model.Message) bool {
+				return msg.ID == 123 && msg.Status == model.MessageStatusFailedPerm
+			})).Return(nil)
+		mockTxLogRepo.On("UpdateForPermFailed", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(txLog *model.TxLog) bool {
+				return txLog.State == model.TxLogStateFailed && *txLog.LastError == "RECIPIENT_SUPPRESSED"
+			})).Return(nil)
+
+		err := svc.SendMessage(context.Background(), cmd)
+
+		assert.NoError(t, err)
+		mockTxLogRepo.AssertExpectations(t)
+		mockProvider.AssertNotCalled(t, "SendWithRetry")
+		mockMessageRepo.AssertNotCalled(t, "UpdateForSending")
+	})
+
+	t.Run("keeps sending below a configured attempt limit", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockProvider := &mocks.ProviderService{}
+
+		limitCfg := &config.Config{SendWorker: config.SendWorker{MaxAttempts: 5}}
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), limitCfg, logger)
+
+		message := &model.Message{
+			ID:           123,
+			Status:       model.MessageStatusFailedTemp,
+			AttemptCount: 3,
+		}
+
+		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
+
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
+		mockMessageRepo.On("UpdateForSending", context.Background(),
+			mock.MatchedBy(func(msg *model.Message) bool {
+				return msg.ID == 123 && msg.AttemptCount == 4
+			}),
+			mock.AnythingOfType("time.Time")).Return(nil)
+
+		mockProvider.On("SendWithRetry", context.Background(), int64(0), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
+			Return(smsprovider.Response{MessageID: "provider-msg-123", Provider: "default"}, nil)
+
+		mockMessageRepo.On("Update", context.Background(), mock.AnythingOfType("*model.Message")).Return(nil)
+		mockTxLogRepo.On("UpdateByMessageID", context.Background(), mock.AnythingOfType("*model.TxLog")).Return(nil)
+
+		err := svc.SendMessage(context.Background(), cmd)
+
+		assert.NoError(t, err)
+
+		mockMessageRepo.AssertExpectations(t)
+		mockProvider.AssertExpectations(t)
+	})
+
 	t.Run("requeue when max retries exceeded but update to permanent failure status fails", func(t *testing.T) {
 		mockMessageRepo := &mocks.MessageRepository{}
 		mockTxLogRepo := &mocks.TxLogRepository{}
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:           123,
@@ -379,7 +550,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:           123,
@@ -391,6 +563,7 @@ func TestSend_SendMessage(t *testing.T) {
 
 		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
 
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
 		mockMessageRepo.On("UpdateForSending", context.Background(),
 			mock.AnythingOfType("*model.Message"),
 			mock.AnythingOfType("time.Time")).Return(dbError)
@@ -410,7 +583,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:           123,
@@ -420,6 +594,7 @@ func TestSend_SendMessage(t *testing.T) {
 
 		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
 
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
 		mockMessageRepo.On("UpdateForSending", context.Background(),
 			mock.AnythingOfType("*model.Message"),
 			mock.AnythingOfType("time.Time")).Return(repository.ErrNoRowsAffected)
@@ -438,7 +613,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:           123,
@@ -450,11 +626,12 @@ func TestSend_SendMessage(t *testing.T) {
 
 		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
 
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
 		mockMessageRepo.On("UpdateForSending", context.Background(),
 			mock.AnythingOfType("*model.Message"),
 			mock.AnythingOfType("time.Time")).Return(nil)
 
-		mockProvider.On("SendWithRetry", context.Background(), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
+		mockProvider.On("SendWithRetry", context.Background(), int64(0), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
 			Return(smsprovider.Response{}, providerError)
 
 		mockTxManager.On("WithTx", context.Background(),
@@ -488,7 +665,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:           123,
@@ -501,11 +679,12 @@ func TestSend_SendMessage(t *testing.T) {
 
 		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
 
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
 		mockMessageRepo.On("UpdateForSending", context.Background(),
 			mock.AnythingOfType("*model.Message"),
 			mock.AnythingOfType("time.Time")).Return(nil)
 
-		mockProvider.On("SendWithRetry", context.Background(), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
+		mockProvider.On("SendWithRetry", context.Background(), int64(0), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
 			Return(smsprovider.Response{}, providerError)
 
 		mockTxManager.On("WithTx", context.Background(),
@@ -527,7 +706,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:           123,
@@ -539,11 +719,12 @@ func TestSend_SendMessage(t *testing.T) {
 
 		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
 
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
 		mockMessageRepo.On("UpdateForSending", context.Background(),
 			mock.AnythingOfType("*model.Message"),
 			mock.AnythingOfType("time.Time")).Return(nil)
 
-		mockProvider.On("SendWithRetry", context.Background(), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
+		mockProvider.On("SendWithRetry", context.Background(), int64(0), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
 			Return(smsprovider.Response{}, providerError)
 
 		mockTxManager.On("WithTx", context.Background(),
@@ -564,19 +745,65 @@ func TestSend_SendMessage(t *testing.T) {
 
 		assert.Error(t, err)
 		assert.True(t, isTemporaryError(err))
+		assert.ErrorAs(t, err, new(service.AttemptError))
 
 		mockMessageRepo.AssertExpectations(t)
 		mockTxLogRepo.AssertExpectations(t)
 		mockProvider.AssertExpectations(t)
 	})
 
+	t.Run("defers message without using an attempt while every provider circuit is open", func(t *testing.T) {
+		mockMessageRepo := &mocks.MessageRepository{}
+		mockTxLogRepo := &mocks.TxLogRepository{}
+		mockTxManager := &mocks.TxManager{}
+		mockProvider := &mocks.ProviderService{}
+
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
+
+		message := &model.Message{
+			ID:           123,
+			Status:       model.MessageStatusCreated,
+			AttemptCount: 0,
+		}
+
+		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
+		mockProvider.On("CircuitOpen", int64(0), cmd.ToMSISDN).Return(time.Millisecond, true)
+
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+
+		mockMessageRepo.On("Update", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(msg *model.Message) bool {
+				return msg.ID == 123 && msg.Status == model.MessageStatusFailedTemp && msg.AttemptCount == 0
+			})).Return(nil)
+
+		mockTxLogRepo.On("UpdateByMessageID", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(txLog *model.TxLog) bool {
+				return *txLog.LastError == smsprovider.ErrorCodeCircuitOpen
+			})).Return(nil)
+
+		err := svc.SendMessage(context.Background(), cmd)
+
+		assert.True(t, isTemporaryError(err))
+		assert.NotErrorAs(t, err, new(service.AttemptError))
+		var deferred service.DeferError
+		if assert.ErrorAs(t, err, &deferred) {
+			assert.Equal(t, time.Millisecond, deferred.RetryIn)
+		}
+		mockMessageRepo.AssertNotCalled(t, "UpdateForSending")
+		mockProvider.AssertNotCalled(t, "SendWithRetry")
+		mockTxLogRepo.AssertExpectations(t)
+	})
+
 	t.Run("requeue when temporary failure update fails", func(t *testing.T) {
 		mockMessageRepo := &mocks.MessageRepository{}
 		mockTxLogRepo := &mocks.TxLogRepository{}
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:           123,
@@ -589,11 +816,12 @@ func TestSend_SendMessage(t *testing.T) {
 
 		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
 
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
 		mockMessageRepo.On("UpdateForSending", context.Background(),
 			mock.AnythingOfType("*model.Message"),
 			mock.AnythingOfType("time.Time")).Return(nil)
 
-		mockProvider.On("SendWithRetry", context.Background(), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
+		mockProvider.On("SendWithRetry", context.Background(), int64(0), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
 			Return(smsprovider.Response{}, providerError)
 
 		mockTxManager.On("WithTx", context.Background(),
@@ -615,7 +843,8 @@ func TestSend_SendMessage(t *testing.T) {
 		mockTxManager := &mocks.TxManager{}
 		mockProvider := &mocks.ProviderService{}
 
-		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider, logger)
+		svc := service.NewSendService(mockMessageRepo, mockTxLogRepo, mockTxManager, mockProvider,
+			newSuppressionService(), newWebhookService(), cfg, logger)
 
 		message := &model.Message{
 			ID:           123,
@@ -631,11 +860,12 @@ func TestSend_SendMessage(t *testing.T) {
 
 		mockMessageRepo.On("GetByID", int64(123)).Return(message, nil)
 
+		mockProvider.On("CircuitOpen", mock.Anything, cmd.ToMSISDN).Return(time.Duration(0), false)
 		mockMessageRepo.On("UpdateForSending", context.Background(),
 			mock.AnythingOfType("*model.Message"),
 			mock.AnythingOfType("time.Time")).Return(nil)
 
-		mockProvider.On("SendWithRetry", context.Background(), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
+		mockProvider.On("SendWithRetry", context.Background(), int64(0), cmd.FromMSISDN, cmd.ToMSISDN, cmd.Text).
 			Return(providerResponse, nil)
 
 		mockMessageRepo.On("Update", context.Background(), mock.AnythingOfType("*model.Message")).Return(nil)
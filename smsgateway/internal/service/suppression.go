@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	SuppressionScopeAccount = "account"
+	SuppressionScopeGlobal  = "global"
+)
+
+type SuppressionService interface {
+	AddSuppression(ctx context.Context, cmd AddSuppressionCommand) (SuppressionDetails, error)
+	ImportSuppressions(ctx context.Context, cmd ImportSuppressionsCommand) (ImportSuppressionsResponse, error)
+	RemoveSuppression(ctx context.Context, cmd RemoveSuppressionCommand) error
+	GetSuppression(ctx context.Context, accountID int64, msisdn string) (SuppressionStatus, error)
+	ListSuppressions(ctx context.Context, query ListSuppressionsQuery) (ListSuppressionsResponse, error)
+	SuppressedRecipients(ctx context.Context, accountID int64, msisdns []string) (map[string]bool, error)
+}
+
+type suppression struct {
+	suppressionRepo repository.SuppressionRepository
+	logger          *zap.Logger
+}
+
+func NewSuppressionService(suppressionRepo repository.SuppressionRepository, logger *zap.Logger) SuppressionService {
+	return &suppression{suppressionRepo: suppressionRepo, logger: logger}
+}
+
+func (s *suppression) AddSuppression(ctx context.Context, cmd AddSuppressionCommand) (SuppressionDetails, error) {
+	entry := model.Suppression{
+		AccountID: cmd.AccountID,
+		MSISDN:    normalizeMSISDN(cmd.MSISDN),
+		Reason:    cmd.Reason,
+		AddedBy:   cmd.AddedBy,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.suppressionRepo.Create(ctx, &entry); err != nil {
+		if errors.Is(err, repository.ErrSuppressionExists) {
+			return SuppressionDetails{}, NewServiceError(constants.ErrCodeSuppressionExists, err)
+		}
+
+		s.logger.Error("Failed to add suppression", zap.String("msisdn", entry.MSISDN), zap.Error(err))
+		return SuppressionDetails{}, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	s.logger.Info("Suppression added",
+		zap.String("msisdn", entry.MSISDN),
+		zap.String("scope", suppressionScope(entry.AccountID)),
+		zap.String("addedBy", entry.AddedBy),
+		zap.String("reason", entry.Reason))
+
+	return suppressionDetails(entry), nil
+}
+
+// ImportSuppressions adds every entry that is not on the list yet. Entries already present keep their original reason
+// and author and are only counted.
+func (s *suppression) ImportSuppressions(ctx context.Context, cmd ImportSuppressionsCommand) (
+	ImportSuppressionsResponse, error) {
+
+	seen := make(map[string]bool, len(cmd.Entries))
+	entries := make([]model.Suppression, 0, len(cmd.Entries))
+	for _, item := range cmd.Entries {
+		msisdn := normalizeMSISDN(item.MSISDN)
+		if seen[msisdn] {
+			continue
+		}
+		seen[msisdn] = true
+
+		entries = append(entries, model.Suppression{
+			AccountID: cmd.AccountID,
+			MSISDN:    msisdn,
+			Reason:    item.Reason,
+			AddedBy:   cmd.AddedBy,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	if len(entries) == 0 {
+		return ImportSuppressionsResponse{}, nil
+	}
+
+	added, err := s.suppressionRepo.CreateBatch(ctx, entries)
+	if err != nil {
+		s.logger.Error("Failed to import suppressions", zap.Int("entries", len(entries)), zap.Error(err))
+		return ImportSuppressionsResponse{}, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	s.logger.Info("Suppressions imported",
+		zap.String("scope", suppressionScope(cmd.AccountID)),
+		zap.String("addedBy", cmd.AddedBy),
+		zap.Int64("added", added),
+		zap.Int("entries", len(entries)))
+
+	return ImportSuppressionsResponse{Added: added, Existing: int64(len(entries)) - added}, nil
+}
+
+func (s *suppression) RemoveSuppression(ctx context.Context, cmd RemoveSuppressionCommand) error {
+	msisdn := normalizeMSISDN(cmd.MSISDN)
+
+	if err := s.suppressionRepo.Delete(ctx, cmd.AccountID, msisdn); err != nil {
+		if errors.Is(err, repository.ErrSuppressionNotFound) {
+			return NewServiceError(constants.ErrCodeSuppressionNotFound, err)
+		}
+
+		s.logger.Error("Failed to remove suppression", zap.String("msisdn", msisdn), zap.Error(err))
+		return NewServiceError(ErrCodeDatabase, err)
+	}
+
+	s.logger.Info("Suppression removed",
+		zap.String("msisdn", msisdn),
+		zap.String("scope", suppressionScope(cmd.AccountID)))
+
+	return nil
+}
+
+// GetSuppression reports every entry that blocks the account from messaging msisdn, including global ones.
+func (s *suppression) GetSuppression(ctx context.Context, accountID int64, msisdn string) (SuppressionStatus, error) {
+	msisdn = normalizeMSISDN(msisdn)
+
+	entries, err := s.suppressionRepo.FindByMSISDNs(accountID, []string{msisdn})
+	if err != nil {
+		s.logger.Error("Failed to get suppression", zap.String("msisdn", msisdn), zap.Error(err))
+		return SuppressionStatus{}, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	status := SuppressionStatus{MSISDN: msisdn, Suppressed: len(entries) > 0,
+		Entries: make([]SuppressionDetails, len(entries))}
+	for i, entry := range entries {
+		status.Entries[i] = suppressionDetails(entry)
+	}
+
+	return status, nil
+}
+
+func (s *suppression) ListSuppressions(ctx context.Context, query ListSuppressionsQuery) (
+	ListSuppressionsResponse, error) {
+
+	entries, err := s.suppressionRepo.ListByAccount(query.AccountID, query.AfterID, query.Limit+1)
+	if err != nil {
+		s.logger.Error("Failed to list suppressions", zap.Error(err))
+		return ListSuppressionsResponse{}, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	var response ListSuppressionsResponse
+	if len(entries) > query.Limit {
+		entries = entries[:query.Limit]
+		response.NextAfterID = entries[len(entries)-1].ID
+	}
+
+	response.Suppressions = make([]SuppressionDetails, len(entries))
+	for i, entry := range entries {
+		response.Suppressions[i] = suppressionDetails(entry)
+	}
+
+	return response, nil
+}
+
+// SuppressedRecipients returns the subset of msisdns, keyed as given, that the account must not message. Lookup
+// failures are returned rather than ignored: sending to an opted-out number is worse than rejecting a request.
+func (s *suppression) SuppressedRecipients(ctx context.Context, accountID int64, msisdns []string) (
+	map[string]bool, error) {
+
+	suppressed := make(map[string]bool)
+	if len(msisdns) == 0 {
+		return suppressed, nil
+	}
+
+	normalized := make([]string, len(msisdns))
+	for i, msisdn := range msisdns {
+		normalized[i] = normalizeMSISDN(msisdn)
+	}
+
+	entries, err := s.suppressionRepo.FindByMSISDNs(accountID, normalized)
+	if err != nil {
+		s.logger.Error("Failed to check suppression list", zap.Int64("accountID", accountID), zap.Error(err))
+		return nil, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	blocked := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		blocked[entry.MSISDN] = true
+	}
+
+	for i, msisdn := range msisdns {
+		if blocked[normalized[i]] {
+			suppressed[msisdn] = true
+		}
+	}
+
+	return suppressed, nil
+}
+
+// normalizeMSISDN drops the optional leading '+' so "+98912..." and "98912..." share one entry.
+func normalizeMSISDN(msisdn string) string {
+	return strings.TrimPrefix(strings.TrimSpace(msisdn), "+")
+}
+
+func suppressionScope(accountID *int64) string {
+	if accountID == nil {
+		return SuppressionScopeGlobal
+	}
+	return SuppressionScopeAccount
+}
+
+func suppressionDetails(entry model.Suppression) SuppressionDetails {
+	return SuppressionDetails{
+		ID:        entry.ID,
+		MSISDN:    entry.MSISDN,
+		Scope:     suppressionScope(entry.AccountID),
+		Reason:    entry.Reason,
+		AddedBy:   entry.AddedBy,
+		CreatedAt: entry.CreatedAt.Format(timeFormat),
+	}
+}
@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func newSuppressionService() *mocks.SuppressionService {
+	suppression := &mocks.SuppressionService{}
+	suppression.On("SuppressedRecipients", mock.Anything, mock.Anything, mock.Anything).
+		Return(map[string]bool{}, nil).Maybe()
+	return suppression
+}
+
+func TestSuppression_SuppressedRecipients(t *testing.T) {
+	logger := zap.NewNop()
+
+	t.Run("matches account and global entries regardless of leading plus", func(t *testing.T) {
+		mockSuppressionRepo := &mocks.SuppressionRepository{}
+
+		svc := service.NewSuppressionService(mockSuppressionRepo, logger)
+
+		accountID := int64(7)
+		mockSuppressionRepo.On("FindByMSISDNs", int64(7), []string{"989121111111", "989122222222", "989123333333"}).
+			Return([]model.Suppression{
+				{MSISDN: "989121111111", AccountID: &accountID},
+				{MSISDN: "989123333333"},
+			}, nil)
+
+		suppressed, err := svc.SuppressedRecipients(context.Background(), 7,
+			[]string{"+989121111111", "989122222222", "989123333333"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]bool{"+989121111111": true, "989123333333": true}, suppressed)
+	})
+
+	t.Run("fails closed when the lookup fails", func(t *testing.T) {
+		mockSuppressionRepo := &mocks.SuppressionRepository{}
+
+		svc := service.NewSuppressionService(mockSuppressionRepo, logger)
+
+		mockSuppressionRepo.On("FindByMSISDNs", int64(7), mock.Anything).
+			Return([]model.Suppression(nil), errors.New("connection refused"))
+
+		_, err := svc.SuppressedRecipients(context.Background(), 7, []string{"989121111111"})
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, service.ErrCodeDatabase, serviceErr.Code)
+	})
+}
+
+func TestSuppression_AddSuppression(t *testing.T) {
+	logger := zap.NewNop()
+	accountID := int64(7)
+
+	t.Run("records who added the entry and why", func(t *testing.T) {
+		mockSuppressionRepo := &mocks.SuppressionRepository{}
+
+		svc := service.NewSuppressionService(mockSuppressionRepo, logger)
+
+		mockSuppressionRepo.On("Create", context.Background(),
+			mock.MatchedBy(func(entry *model.Suppression) bool {
+				return *entry.AccountID == 7 && entry.MSISDN == "989121111111" &&
+					entry.Reason == "customer request" && entry.AddedBy == "account:7"
+			})).Return(nil)
+
+		details, err := svc.AddSuppression(context.Background(), service.AddSuppressionCommand{
+			AccountID: &accountID, MSISDN: "+989121111111", Reason: "customer request", AddedBy: "account:7"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.SuppressionScopeAccount, details.Scope)
+		assert.Equal(t, "account:7", details.AddedBy)
+		mockSuppressionRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns conflict for an existing entry", func(t *testing.T) {
+		mockSuppressionRepo := &mocks.SuppressionRepository{}
+
+		svc := service.NewSuppressionService(mockSuppressionRepo, logger)
+
+		mockSuppressionRepo.On("Create", context.Background(), mock.AnythingOfType("*model.Suppression")).
+			Return(repository.ErrSuppressionExists)
+
+		_, err := svc.AddSuppression(context.Background(),
+			service.AddSuppressionCommand{MSISDN: "989121111111", Reason: "STOP", AddedBy: "smsctl"})
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeSuppressionExists, serviceErr.Code)
+	})
+}
+
+func TestSuppression_ImportSuppressions(t *testing.T) {
+	logger := zap.NewNop()
+
+	t.Run("deduplicates entries and counts existing ones", func(t *testing.T) {
+		mockSuppressionRepo := &mocks.SuppressionRepository{}
+
+		svc := service.NewSuppressionService(mockSuppressionRepo, logger)
+
+		mockSuppressionRepo.On("CreateBatch", context.Background(),
+			mock.MatchedBy(func(entries []model.Suppression) bool {
+				return len(entries) == 2 && entries[0].AccountID == nil && entries[1].Reason == "complaint"
+			})).Return(int64(1), nil)
+
+		resp, err := svc.ImportSuppressions(context.Background(), service.ImportSuppressionsCommand{
+			AddedBy: "smsctl:ops",
+			Entries: []service.SuppressionEntry{
+				{MSISDN: "989121111111", Reason: "STOP"},
+				{MSISDN: "+989121111111", Reason: "STOP"},
+				{MSISDN: "989122222222", Reason: "complaint"},
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.ImportSuppressionsResponse{Added: 1, Existing: 1}, resp)
+	})
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smstemplate"
+	"go.uber.org/zap"
+)
+
+const maxRenderedTextLength = 1600
+
+type TemplateService interface {
+	CreateTemplate(ctx context.Context, cmd CreateTemplateCommand) (TemplateDetails, error)
+	UpdateTemplate(ctx context.Context, cmd UpdateTemplateCommand) (TemplateDetails, error)
+	GetTemplate(ctx context.Context, accountID, id int64) (TemplateDetails, error)
+	ListTemplates(ctx context.Context, accountID int64) ([]TemplateDetails, error)
+	DeleteTemplate(ctx context.Context, accountID, id int64) error
+	RenderTemplate(ctx context.Context, cmd RenderTemplateCommand) (RenderedTemplate, error)
+}
+
+type template struct {
+	templateRepo repository.TemplateRepository
+	txManager    repository.TxManager
+	logger       *zap.Logger
+}
+
+func NewTemplateService(templateRepo repository.TemplateRepository, txManager repository.TxManager,
+	logger *zap.Logger) TemplateService {
+	return &template{templateRepo: templateRepo, txManager: txManager, logger: logger}
+}
+
+func (t *template) CreateTemplate(ctx context.Context, cmd CreateTemplateCommand) (TemplateDetails, error) {
+	parsed, err := parseTemplate(cmd.Body)
+	if err != nil {
+		return TemplateDetails{}, err
+	}
+
+	tmpl := model.Template{
+		AccountID:      cmd.AccountID,
+		Name:           cmd.Name,
+		CurrentVersion: 1,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	err = t.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := t.templateRepo.Create(ctx, &tmpl); err != nil {
+			return err
+		}
+
+		version := model.TemplateVersion{TemplateID: tmpl.ID, Version: 1, Body: cmd.Body, CreatedAt: time.Now()}
+		return t.templateRepo.CreateVersion(ctx, &version)
+	})
+	if err != nil {
+		t.logger.Error("Failed to create template",
+			zap.Int64("accountID", cmd.AccountID),
+			zap.String("name", cmd.Name),
+			zap.Error(err))
+		return TemplateDetails{}, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	t.logger.Info("Template created", zap.Int64("templateID", tmpl.ID), zap.Int64("accountID", cmd.AccountID))
+
+	return templateDetails(&tmpl, cmd.Body, parsed), nil
+}
+
+// UpdateTemplate never edits a stored body: it adds the next version and points the template at it, so messages
+// keep referring to the exact text they were rendered from.
+func (t *template) UpdateTemplate(ctx context.Context, cmd UpdateTemplateCommand) (TemplateDetails, error) {
+	parsed, err := parseTemplate(cmd.Body)
+	if err != nil {
+		return TemplateDetails{}, err
+	}
+
+	var tmpl *model.Template
+	err = t.txManager.WithTx(ctx, func(ctx context.Context) error {
+		tmpl, err = t.templateRepo.GetByIDForUpdate(ctx, cmd.AccountID, cmd.TemplateID)
+		if err != nil {
+			return err
+		}
+
+		tmpl.CurrentVersion++
+		tmpl.UpdatedAt = time.Now()
+		if cmd.Name != "" {
+			tmpl.Name = cmd.Name
+		}
+
+		version := model.TemplateVersion{
+			TemplateID: tmpl.ID,
+			Version:    tmpl.CurrentVersion,
+			Body:       cmd.Body,
+			CreatedAt:  time.Now(),
+		}
+		if err := t.templateRepo.CreateVersion(ctx, &version); err != nil {
+			return err
+		}
+
+		return t.templateRepo.Update(ctx, tmpl)
+	})
+	if err != nil {
+		return TemplateDetails{}, t.templateError(cmd.TemplateID, err)
+	}
+
+	t.logger.Info("Template updated",
+		zap.Int64("templateID", tmpl.ID),
+		zap.Int("version", tmpl.CurrentVersion))
+
+	return templateDetails(tmpl, cmd.Body, parsed), nil
+}
+
+func (t *template) GetTemplate(ctx context.Context, accountID, id int64) (TemplateDetails, error) {
+	tmpl, version, err := t.currentVersion(accountID, id)
+	if err != nil {
+		return TemplateDetails{}, err
+	}
+
+	parsed, err := smstemplate.Parse(version.Body)
+	if err != nil {
+		t.logger.Error("Stored template body does not parse", zap.Int64("templateID", id), zap.Error(err))
+		return TemplateDetails{}, NewServiceError(constants.ErrCodeInternalError, err)
+	}
+
+	return templateDetails(tmpl, version.Body, parsed), nil
+}
+
+func (t *template) ListTemplates(ctx context.Context, accountID int64) ([]TemplateDetails, error) {
+	templates, err := t.templateRepo.ListByAccount(accountID)
+	if err != nil {
+		t.logger.Error("Failed to list templates", zap.Int64("accountID", accountID), zap.Error(err))
+		return nil, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	details := make([]TemplateDetails, len(templates))
+	for i := range templates {
+		details[i] = templateDetails(&templates[i], "", nil)
+	}
+
+	return details, nil
+}
+
+func (t *template) DeleteTemplate(ctx context.Context, accountID, id int64) error {
+	if err := t.templateRepo.Delete(ctx, accountID, id, time.Now()); err != nil {
+		return t.templateError(id, err)
+	}
+
+	t.logger.Info("Template deleted", zap.Int64("templateID", id), zap.Int64("accountID", accountID))
+	return nil
+}
+
+// RenderTemplate renders the current version of an account's template. The result still has to fit in a single
+// message request, so it is held to the same length limit as submitted text.
+func (t *template) RenderTemplate(ctx context.Context, cmd RenderTemplateCommand) (RenderedTemplate, error) {
+	tmpl, version, err := t.currentVersion(cmd.AccountID, cmd.TemplateID)
+	if err != nil {
+		return RenderedTemplate{}, err
+	}
+
+	parsed, err := smstemplate.Parse(version.Body)
+	if err != nil {
+		t.logger.Error("Stored template body does not parse", zap.Int64("templateID", tmpl.ID), zap.Error(err))
+		return RenderedTemplate{}, NewServiceError(constants.ErrCodeInternalError, err)
+	}
+
+	text, err := parsed.Render(cmd.Variables)
+	if err != nil {
+		return RenderedTemplate{}, NewServiceError(constants.ErrCodeInvalidTemplateVariables, err)
+	}
+
+	if length := utf8.RuneCountInString(text); length == 0 || length > maxRenderedTextLength {
+		return RenderedTemplate{}, NewServiceError(constants.ErrCodeInvalidTemplateVariables, ErrRenderedTextLength)
+	}
+
+	return RenderedTemplate{TemplateID: tmpl.ID, Version: version.Version, Text: text}, nil
+}
+
+func (t *template) currentVersion(accountID, id int64) (*model.Template, *model.TemplateVersion, error) {
+	tmpl, err := t.templateRepo.GetByID(accountID, id)
+	if err != nil {
+		return nil, nil, t.templateError(id, err)
+	}
+
+	version, err := t.templateRepo.GetVersion(tmpl.ID, tmpl.CurrentVersion)
+	if err != nil {
+		return nil, nil, t.templateError(id, err)
+	}
+
+	return tmpl, version, nil
+}
+
+func (t *template) templateError(id int64, err error) error {
+	if errors.Is(err, repository.ErrTemplateNotFound) {
+		return NewServiceError(constants.ErrCodeTemplateNotFound, err)
+	}
+
+	t.logger.Error("Template operation failed", zap.Int64("templateID", id), zap.Error(err))
+	return NewServiceError(ErrCodeDatabase, err)
+}
+
+func parseTemplate(body string) (*smstemplate.Template, error) {
+	parsed, err := smstemplate.Parse(body)
+	if err != nil {
+		return nil, NewServiceError(constants.ErrCodeInvalidTemplate, err)
+	}
+	return parsed, nil
+}
+
+func templateDetails(tmpl *model.Template, body string, parsed *smstemplate.Template) TemplateDetails {
+	details := TemplateDetails{
+		ID:        tmpl.ID,
+		Name:      tmpl.Name,
+		Version:   tmpl.CurrentVersion,
+		Body:      body,
+		CreatedAt: tmpl.CreatedAt.Format(timeFormat),
+		UpdatedAt: tmpl.UpdatedAt.Format(timeFormat),
+	}
+
+	if parsed != nil {
+		details.Placeholders = parsed.Placeholders()
+	}
+
+	return details
+}
@@ -0,0 +1,165 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smstemplate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestTemplate_CreateTemplate(t *testing.T) {
+	logger := zap.NewNop()
+
+	t.Run("stores the template with its first version", func(t *testing.T) {
+		mockTemplateRepo := &mocks.TemplateRepository{}
+		mockTxManager := &mocks.TxManager{}
+
+		svc := service.NewTemplateService(mockTemplateRepo, mockTxManager, logger)
+
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockTemplateRepo.On("Create", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(tmpl *model.Template) bool {
+				return tmpl.AccountID == 7 && tmpl.Name == "otp" && tmpl.CurrentVersion == 1
+			})).Run(func(args mock.Arguments) {
+			args.Get(1).(*model.Template).ID = 10
+		}).Return(nil)
+		mockTemplateRepo.On("CreateVersion", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(version *model.TemplateVersion) bool {
+				return version.TemplateID == 10 && version.Version == 1 && version.Body == "Code: {{code:digits}}"
+			})).Return(nil)
+
+		details, err := svc.CreateTemplate(context.Background(),
+			service.CreateTemplateCommand{AccountID: 7, Name: "otp", Body: "Code: {{code:digits}}"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10), details.ID)
+		assert.Equal(t, 1, details.Version)
+		assert.Equal(t, []smstemplate.Placeholder{{Name: "code", Type: smstemplate.TypeDigits}}, details.Placeholders)
+		mockTemplateRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a body that does not parse", func(t *testing.T) {
+		mockTxManager := &mocks.TxManager{}
+
+		svc := service.NewTemplateService(&mocks.TemplateRepository{}, mockTxManager, logger)
+
+		_, err := svc.CreateTemplate(context.Background(),
+			service.CreateTemplateCommand{AccountID: 7, Name: "otp", Body: "Code: {{code"})
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeInvalidTemplate, serviceErr.Code)
+		mockTxManager.AssertNotCalled(t, "WithTx")
+	})
+}
+
+func TestTemplate_UpdateTemplate(t *testing.T) {
+	logger := zap.NewNop()
+
+	t.Run("adds the next version instead of editing the body", func(t *testing.T) {
+		mockTemplateRepo := &mocks.TemplateRepository{}
+		mockTxManager := &mocks.TxManager{}
+
+		svc := service.NewTemplateService(mockTemplateRepo, mockTxManager, logger)
+
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockTemplateRepo.On("GetByIDForUpdate", mock.AnythingOfType("*context.valueCtx"), int64(7), int64(10)).
+			Return(&model.Template{ID: 10, AccountID: 7, Name: "otp", CurrentVersion: 2}, nil)
+		mockTemplateRepo.On("CreateVersion", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(version *model.TemplateVersion) bool {
+				return version.TemplateID == 10 && version.Version == 3 && version.Body == "Your code is {{code}}"
+			})).Return(nil)
+		mockTemplateRepo.On("Update", mock.AnythingOfType("*context.valueCtx"),
+			mock.MatchedBy(func(tmpl *model.Template) bool {
+				return tmpl.CurrentVersion == 3 && tmpl.Name == "otp"
+			})).Return(nil)
+
+		details, err := svc.UpdateTemplate(context.Background(),
+			service.UpdateTemplateCommand{AccountID: 7, TemplateID: 10, Body: "Your code is {{code}}"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, details.Version)
+		mockTemplateRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for another account's template", func(t *testing.T) {
+		mockTemplateRepo := &mocks.TemplateRepository{}
+		mockTxManager := &mocks.TxManager{}
+
+		svc := service.NewTemplateService(mockTemplateRepo, mockTxManager, logger)
+
+		mockTxManager.On("WithTx", context.Background(),
+			mock.AnythingOfType("func(context.Context) error")).Return(nil)
+		mockTemplateRepo.On("GetByIDForUpdate", mock.AnythingOfType("*context.valueCtx"), int64(8), int64(10)).
+			Return((*model.Template)(nil), repository.ErrTemplateNotFound)
+
+		_, err := svc.UpdateTemplate(context.Background(),
+			service.UpdateTemplateCommand{AccountID: 8, TemplateID: 10, Body: "Hello"})
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeTemplateNotFound, serviceErr.Code)
+		mockTemplateRepo.AssertNotCalled(t, "CreateVersion")
+	})
+}
+
+func TestTemplate_RenderTemplate(t *testing.T) {
+	logger := zap.NewNop()
+
+	newService := func() service.TemplateService {
+		mockTemplateRepo := &mocks.TemplateRepository{}
+		mockTemplateRepo.On("GetByID", int64(7), int64(10)).
+			Return(&model.Template{ID: 10, AccountID: 7, CurrentVersion: 2}, nil)
+		mockTemplateRepo.On("GetVersion", int64(10), 2).
+			Return(&model.TemplateVersion{TemplateID: 10, Version: 2, Body: "Hi {{name}}, code {{code:digits}}"}, nil)
+
+		return service.NewTemplateService(mockTemplateRepo, &mocks.TxManager{}, logger)
+	}
+
+	t.Run("renders the current version", func(t *testing.T) {
+		rendered, err := newService().RenderTemplate(context.Background(), service.RenderTemplateCommand{
+			AccountID: 7, TemplateID: 10, Variables: map[string]string{"name": "Sara", "code": "0042"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.RenderedTemplate{TemplateID: 10, Version: 2, Text: "Hi Sara, code 0042"}, rendered)
+	})
+
+	t.Run("reports the variable that failed", func(t *testing.T) {
+		_, err := newService().RenderTemplate(context.Background(), service.RenderTemplateCommand{
+			AccountID: 7, TemplateID: 10, Variables: map[string]string{"name": "Sara", "code": "12a"}})
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeInvalidTemplateVariables, serviceErr.Code)
+
+		var varErr *smstemplate.VariableError
+		assert.True(t, errors.As(err, &varErr))
+		assert.Equal(t, "code", varErr.Name)
+	})
+
+	t.Run("returns not found for a deleted template", func(t *testing.T) {
+		mockTemplateRepo := &mocks.TemplateRepository{}
+		mockTemplateRepo.On("GetByID", int64(7), int64(11)).
+			Return((*model.Template)(nil), repository.ErrTemplateNotFound)
+
+		svc := service.NewTemplateService(mockTemplateRepo, &mocks.TxManager{}, logger)
+
+		_, err := svc.RenderTemplate(context.Background(),
+			service.RenderTemplateCommand{AccountID: 7, TemplateID: 11})
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeTemplateNotFound, serviceErr.Code)
+	})
+}
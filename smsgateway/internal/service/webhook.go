@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"go.uber.org/zap"
+)
+
+const webhookSecretBytes = 32
+
+type WebhookService interface {
+	RegisterWebhook(ctx context.Context, cmd RegisterWebhookCommand) (WebhookDetails, error)
+	GetWebhook(ctx context.Context, userID string) (WebhookDetails, error)
+	DeleteWebhook(ctx context.Context, userID string) error
+	Notify(ctx context.Context, cmd NotifyWebhookCommand) error
+}
+
+type webhook struct {
+	webhookRepo repository.WebhookRepository
+	eventRepo   repository.WebhookEventRepository
+	messageRepo repository.MessageRepository
+	logger      *zap.Logger
+}
+
+func NewWebhookService(webhookRepo repository.WebhookRepository, eventRepo repository.WebhookEventRepository,
+	messageRepo repository.MessageRepository, logger *zap.Logger) WebhookService {
+	return &webhook{webhookRepo: webhookRepo, eventRepo: eventRepo, messageRepo: messageRepo, logger: logger}
+}
+
+func (w *webhook) RegisterWebhook(ctx context.Context, cmd RegisterWebhookCommand) (WebhookDetails, error) {
+	secret := cmd.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			w.logger.Error("Failed to generate webhook secret", zap.Error(err))
+			return WebhookDetails{}, NewServiceError(constants.ErrCodeInternalError, err)
+		}
+		secret = generated
+	}
+
+	hook := model.Webhook{
+		UserID:    cmd.UserID,
+		URL:       cmd.URL,
+		Secret:    secret,
+		Enabled:   true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := w.webhookRepo.Upsert(ctx, &hook); err != nil {
+		w.logger.Error("Failed to register webhook",
+			zap.String("userID", cmd.UserID),
+			zap.Error(err))
+		return WebhookDetails{}, NewServiceError(ErrCodeDatabase, err)
+	}
+
+	w.logger.Info("Webhook registered", zap.String("userID", cmd.UserID), zap.String("url", cmd.URL))
+
+	return WebhookDetails{UserID: hook.UserID, URL: hook.URL, Secret: secret, Enabled: hook.Enabled}, nil
+}
+
+func (w *webhook) GetWebhook(ctx context.Context, userID string) (WebhookDetails, error) {
+	hook, err := w.webhookRepo.GetByUserID(userID)
+	if err != nil {
+		return WebhookDetails{}, w.webhookError(userID, err)
+	}
+
+	return WebhookDetails{
+		UserID:    hook.UserID,
+		URL:       hook.URL,
+		Enabled:   hook.Enabled,
+		CreatedAt: hook.CreatedAt.Format(timeFormat),
+		UpdatedAt: hook.UpdatedAt.Format(timeFormat),
+	}, nil
+}
+
+func (w *webhook) DeleteWebhook(ctx context.Context, userID string) error {
+	if err := w.webhookRepo.Delete(ctx, userID); err != nil {
+		return w.webhookError(userID, err)
+	}
+
+	w.logger.Info("Webhook deleted", zap.String("userID", userID))
+	return nil
+}
+
+// Notify writes a status event to the webhook outbox. It joins the caller's transaction when ctx carries one, and
+// is a no-op for users without an enabled webhook.
+func (w *webhook) Notify(ctx context.Context, cmd NotifyWebhookCommand) error {
+	msg, err := w.messageRepo.GetByID(cmd.MessageID)
+	if err != nil {
+		w.logger.Error("Failed to load message for webhook event",
+			zap.Int64("messageID", cmd.MessageID),
+			zap.String("event", cmd.Event),
+			zap.Error(err))
+		return err
+	}
+
+	hook, err := w.webhookRepo.GetByUserID(msg.FromMSISDN)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		w.logger.Error("Failed to load webhook",
+			zap.Int64("messageID", cmd.MessageID),
+			zap.String("event", cmd.Event),
+			zap.Error(err))
+		return err
+	}
+
+	if !hook.Enabled {
+		return nil
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(WebhookPayload{
+		Event:           cmd.Event,
+		MessageID:       msg.ID,
+		ClientMessageID: msg.ClientMessageID,
+		From:            msg.FromMSISDN,
+		To:              msg.ToMSISDN,
+		Status:          string(cmd.Status),
+		Error:           cmd.Error,
+		OccurredAt:      now.Format(timeFormat),
+	})
+	if err != nil {
+		return err
+	}
+
+	event := model.WebhookEvent{
+		WebhookID:     hook.ID,
+		MessageID:     msg.ID,
+		EventType:     cmd.Event,
+		Payload:       string(payload),
+		State:         model.WebhookEventStatePending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := w.eventRepo.Create(ctx, &event); err != nil {
+		w.logger.Error("Failed to create webhook event",
+			zap.Int64("messageID", cmd.MessageID),
+			zap.String("event", cmd.Event),
+			zap.Error(err))
+		return err
+	}
+
+	w.logger.Debug("Webhook event queued",
+		zap.Int64("messageID", cmd.MessageID),
+		zap.Int64("eventID", event.ID),
+		zap.String("event", cmd.Event))
+
+	return nil
+}
+
+func (w *webhook) webhookError(userID string, err error) error {
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		return NewServiceError(constants.ErrCodeWebhookNotFound, err)
+	}
+
+	w.logger.Error("Webhook lookup failed", zap.String("userID", userID), zap.Error(err))
+	return NewServiceError(ErrCodeDatabase, err)
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	pkgwebhook "github.com/Behyna/sms-services/smsgateway/pkg/webhook"
+	"go.uber.org/zap"
+)
+
+type WebhookDeliveryService interface {
+	DeliverDueEvents(ctx context.Context) error
+}
+
+type webhookDelivery struct {
+	eventRepo repository.WebhookEventRepository
+	client    pkgwebhook.Client
+	config    pkgwebhook.Config
+	claim     repository.Claim
+	logger    *zap.Logger
+}
+
+func NewWebhookDeliveryService(eventRepo repository.WebhookEventRepository, client pkgwebhook.Client,
+	config *config.Config, logger *zap.Logger) WebhookDeliveryService {
+	// A round delivers its events one after another, so the lease must outlast a batch of timed out requests.
+	lease := config.Webhook.ClaimLease
+	if lease <= 0 {
+		lease = max(defaultClaimLease, time.Duration(config.Webhook.BatchSize)*config.Webhook.Timeout)
+	}
+
+	return &webhookDelivery{eventRepo: eventRepo, client: client, config: config.Webhook,
+		claim: repository.Claim{Owner: instanceName(), Lease: lease}, logger: logger}
+}
+
+func (w *webhookDelivery) DeliverDueEvents(ctx context.Context) error {
+	events, err := w.eventRepo.ClaimDue(w.claim, time.Now(), w.config.BatchSize)
+	if err != nil {
+		w.logger.Error("Failed to find due webhook events", zap.Error(err))
+		return err
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	delivered := 0
+	for i := range events {
+		if w.deliver(ctx, &events[i]) {
+			delivered++
+		}
+	}
+
+	w.logger.Info("Webhook delivery round finished",
+		zap.Int("delivered", delivered),
+		zap.Int("total", len(events)))
+
+	return nil
+}
+
+func (w *webhookDelivery) deliver(ctx context.Context, event *model.WebhookEvent) bool {
+	attempt := event.AttemptCount + 1
+
+	reqCtx, cancel := context.WithTimeout(ctx, w.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := w.client.Deliver(reqCtx, pkgwebhook.Request{
+		URL:       event.Webhook.URL,
+		Secret:    event.Webhook.Secret,
+		Event:     event.EventType,
+		Payload:   []byte(event.Payload),
+		Timestamp: start,
+	})
+
+	record := model.WebhookAttempt{
+		EventID:    event.ID,
+		Attempt:    attempt,
+		DurationMS: time.Since(start).Milliseconds(),
+		CreatedAt:  time.Now(),
+	}
+
+	if resp.StatusCode != 0 {
+		record.StatusCode = &resp.StatusCode
+	}
+
+	if err != nil {
+		lastError := err.Error()
+		record.Error = &lastError
+	}
+
+	if recordErr := w.eventRepo.CreateAttempt(ctx, &record); recordErr != nil {
+		w.logger.Error("Failed to record webhook attempt",
+			zap.Int64("eventID", event.ID),
+			zap.Error(recordErr))
+	}
+
+	event.AttemptCount = attempt
+	event.UpdatedAt = time.Now()
+	event.LastError = record.Error
+
+	switch {
+	case err == nil:
+		deliveredAt := time.Now()
+		event.State = model.WebhookEventStateDelivered
+		event.DeliveredAt = &deliveredAt
+
+	case attempt >= w.config.MaxAttempts:
+		w.logger.Warn("Webhook event exhausted its attempts",
+			zap.Int64("eventID", event.ID),
+			zap.Int("attempts", attempt),
+			zap.Error(err))
+		event.State = model.WebhookEventStateFailed
+
+	default:
+		w.logger.Debug("Webhook delivery failed, will retry",
+			zap.Int64("eventID", event.ID),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+		event.NextAttemptAt = time.Now().Add(w.backoff(attempt))
+	}
+
+	updateErr := w.eventRepo.Update(ctx, event, w.claim.Owner)
+	if errors.Is(updateErr, repository.ErrNoRowsAffected) {
+		w.logger.Warn("Webhook event claim lapsed before its attempt was recorded",
+			zap.Int64("eventID", event.ID))
+	} else if updateErr != nil {
+		w.logger.Error("Failed to update webhook event",
+			zap.Int64("eventID", event.ID),
+			zap.Error(updateErr))
+	}
+
+	return err == nil
+}
+
+// backoff doubles the delay after every failed attempt, starting at InitialBackoff and capped at MaxBackoff.
+func (w *webhookDelivery) backoff(attempt int) time.Duration {
+	delay := w.config.InitialBackoff
+	for i := 1; i < attempt && delay < w.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > w.config.MaxBackoff {
+		return w.config.MaxBackoff
+	}
+
+	return delay
+}
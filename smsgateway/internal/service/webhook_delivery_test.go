@@ -0,0 +1,132 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/config"
+	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/Behyna/sms-services/smsgateway/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestWebhookDelivery_DeliverDueEvents(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.Config{Webhook: webhook.Config{
+		Timeout:        time.Second,
+		MaxAttempts:    3,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     3 * time.Minute,
+		BatchSize:      10,
+	}}
+
+	dueEvent := func(attempts int) []model.WebhookEvent {
+		return []model.WebhookEvent{{
+			ID:           1,
+			EventType:    model.WebhookEventDelivered,
+			Payload:      `{"message_id":123}`,
+			State:        model.WebhookEventStatePending,
+			AttemptCount: attempts,
+			Webhook:      model.Webhook{URL: "https://customer.test/hooks", Secret: "secret"},
+		}}
+	}
+
+	t.Run("marks event delivered and records the attempt", func(t *testing.T) {
+		mockEventRepo := &mocks.WebhookEventRepository{}
+		mockClient := &mocks.WebhookClient{}
+
+		svc := service.NewWebhookDeliveryService(mockEventRepo, mockClient, cfg, logger)
+
+		mockEventRepo.On("ClaimDue", anyClaim, mock.AnythingOfType("time.Time"), 10).Return(dueEvent(0), nil)
+		mockClient.On("Deliver", mock.Anything, mock.MatchedBy(func(req webhook.Request) bool {
+			return req.URL == "https://customer.test/hooks" && req.Secret == "secret" &&
+				string(req.Payload) == `{"message_id":123}`
+		})).Return(webhook.Response{StatusCode: 200}, nil)
+		mockEventRepo.On("CreateAttempt", context.Background(), mock.MatchedBy(func(attempt *model.WebhookAttempt) bool {
+			return attempt.EventID == 1 && attempt.Attempt == 1 && *attempt.StatusCode == 200 && attempt.Error == nil
+		})).Return(nil)
+		mockEventRepo.On("Update", context.Background(), mock.MatchedBy(func(event *model.WebhookEvent) bool {
+			return event.State == model.WebhookEventStateDelivered && event.AttemptCount == 1 &&
+				event.DeliveredAt != nil
+		}), mock.AnythingOfType("string")).Return(nil)
+
+		err := svc.DeliverDueEvents(context.Background())
+
+		assert.NoError(t, err)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("schedules retry with exponential backoff", func(t *testing.T) {
+		mockEventRepo := &mocks.WebhookEventRepository{}
+		mockClient := &mocks.WebhookClient{}
+
+		svc := service.NewWebhookDeliveryService(mockEventRepo, mockClient, cfg, logger)
+
+		mockEventRepo.On("ClaimDue", anyClaim, mock.AnythingOfType("time.Time"), 10).Return(dueEvent(1), nil)
+		mockClient.On("Deliver", mock.Anything, mock.Anything).
+			Return(webhook.Response{StatusCode: 503}, errors.New("unexpected status code 503"))
+		mockEventRepo.On("CreateAttempt", context.Background(), mock.AnythingOfType("*model.WebhookAttempt")).
+			Return(nil)
+		mockEventRepo.On("Update", context.Background(), mock.MatchedBy(func(event *model.WebhookEvent) bool {
+			delay := time.Until(event.NextAttemptAt)
+			return event.State == model.WebhookEventStatePending && event.AttemptCount == 2 &&
+				delay > time.Minute+50*time.Second && delay <= 2*time.Minute
+		}), mock.AnythingOfType("string")).Return(nil)
+
+		err := svc.DeliverDueEvents(context.Background())
+
+		assert.NoError(t, err)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("fails event after max attempts", func(t *testing.T) {
+		mockEventRepo := &mocks.WebhookEventRepository{}
+		mockClient := &mocks.WebhookClient{}
+
+		svc := service.NewWebhookDeliveryService(mockEventRepo, mockClient, cfg, logger)
+
+		mockEventRepo.On("ClaimDue", anyClaim, mock.AnythingOfType("time.Time"), 10).Return(dueEvent(2), nil)
+		mockClient.On("Deliver", mock.Anything, mock.Anything).
+			Return(webhook.Response{}, webhook.ErrTimeout)
+		mockEventRepo.On("CreateAttempt", context.Background(), mock.MatchedBy(func(attempt *model.WebhookAttempt) bool {
+			return attempt.StatusCode == nil && *attempt.Error == "TIMEOUT"
+		})).Return(nil)
+		mockEventRepo.On("Update", context.Background(), mock.MatchedBy(func(event *model.WebhookEvent) bool {
+			return event.State == model.WebhookEventStateFailed && event.AttemptCount == 3 &&
+				*event.LastError == "TIMEOUT"
+		}), mock.AnythingOfType("string")).Return(nil)
+
+		err := svc.DeliverDueEvents(context.Background())
+
+		assert.NoError(t, err)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("keeps going when the claim lapsed before the attempt was recorded", func(t *testing.T) {
+		mockEventRepo := &mocks.WebhookEventRepository{}
+		mockClient := &mocks.WebhookClient{}
+
+		svc := service.NewWebhookDeliveryService(mockEventRepo, mockClient, cfg, logger)
+
+		mockEventRepo.On("ClaimDue", mock.MatchedBy(func(claim repository.Claim) bool {
+			return claim.Owner != "" && claim.Lease >= 10*time.Second
+		}), mock.AnythingOfType("time.Time"), 10).Return(dueEvent(0), nil)
+		mockClient.On("Deliver", mock.Anything, mock.Anything).Return(webhook.Response{StatusCode: 200}, nil)
+		mockEventRepo.On("CreateAttempt", context.Background(), mock.AnythingOfType("*model.WebhookAttempt")).
+			Return(nil)
+		mockEventRepo.On("Update", context.Background(), mock.AnythingOfType("*model.WebhookEvent"),
+			mock.AnythingOfType("string")).Return(repository.ErrNoRowsAffected)
+
+		err := svc.DeliverDueEvents(context.Background())
+
+		assert.NoError(t, err)
+		mockEventRepo.AssertExpectations(t)
+	})
+}
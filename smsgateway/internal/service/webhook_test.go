@@ -0,0 +1,131 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/internal/constants"
+	"github.com/Behyna/sms-services/smsgateway/internal/mocks"
+	"github.com/Behyna/sms-services/smsgateway/internal/model"
+	"github.com/Behyna/sms-services/smsgateway/internal/repository"
+	"github.com/Behyna/sms-services/smsgateway/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func newWebhookService() *mocks.WebhookService {
+	webhook := &mocks.WebhookService{}
+	webhook.On("Notify", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return webhook
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	logger := zap.NewNop()
+
+	msg := &model.Message{ID: 123, ClientMessageID: "m-1", FromMSISDN: "1234567890", ToMSISDN: "0987654321"}
+	cmd := service.NotifyWebhookCommand{MessageID: 123, Event: model.WebhookEventFailedPerm,
+		Status: model.MessageStatusFailedPerm, Error: "INVALID_NUMBER"}
+
+	t.Run("queues a pending event for an enabled webhook", func(t *testing.T) {
+		mockWebhookRepo := &mocks.WebhookRepository{}
+		mockEventRepo := &mocks.WebhookEventRepository{}
+		mockMessageRepo := &mocks.MessageRepository{}
+
+		svc := service.NewWebhookService(mockWebhookRepo, mockEventRepo, mockMessageRepo, logger)
+
+		mockMessageRepo.On("GetByID", int64(123)).Return(msg, nil)
+		mockWebhookRepo.On("GetByUserID", "1234567890").
+			Return(&model.Webhook{ID: 9, UserID: "1234567890", Enabled: true}, nil)
+		mockEventRepo.On("Create", context.Background(), mock.MatchedBy(func(event *model.WebhookEvent) bool {
+			var payload service.WebhookPayload
+			if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+				return false
+			}
+
+			return event.WebhookID == 9 && event.MessageID == 123 &&
+				event.EventType == model.WebhookEventFailedPerm &&
+				event.State == model.WebhookEventStatePending &&
+				payload.ClientMessageID == "m-1" && payload.Status == "FAILED_PERM" &&
+				payload.Error == "INVALID_NUMBER"
+		})).Return(nil)
+
+		err := svc.Notify(context.Background(), cmd)
+
+		assert.NoError(t, err)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("skips users without a webhook", func(t *testing.T) {
+		mockWebhookRepo := &mocks.WebhookRepository{}
+		mockEventRepo := &mocks.WebhookEventRepository{}
+		mockMessageRepo := &mocks.MessageRepository{}
+
+		svc := service.NewWebhookService(mockWebhookRepo, mockEventRepo, mockMessageRepo, logger)
+
+		mockMessageRepo.On("GetByID", int64(123)).Return(msg, nil)
+		mockWebhookRepo.On("GetByUserID", "1234567890").
+			Return((*model.Webhook)(nil), repository.ErrWebhookNotFound)
+
+		err := svc.Notify(context.Background(), cmd)
+
+		assert.NoError(t, err)
+		mockEventRepo.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("skips disabled webhooks", func(t *testing.T) {
+		mockWebhookRepo := &mocks.WebhookRepository{}
+		mockEventRepo := &mocks.WebhookEventRepository{}
+		mockMessageRepo := &mocks.MessageRepository{}
+
+		svc := service.NewWebhookService(mockWebhookRepo, mockEventRepo, mockMessageRepo, logger)
+
+		mockMessageRepo.On("GetByID", int64(123)).Return(msg, nil)
+		mockWebhookRepo.On("GetByUserID", "1234567890").
+			Return(&model.Webhook{ID: 9, UserID: "1234567890", Enabled: false}, nil)
+
+		err := svc.Notify(context.Background(), cmd)
+
+		assert.NoError(t, err)
+		mockEventRepo.AssertNotCalled(t, "Create")
+	})
+}
+
+func TestWebhook_RegisterWebhook(t *testing.T) {
+	logger := zap.NewNop()
+
+	t.Run("generates a secret when none is given", func(t *testing.T) {
+		mockWebhookRepo := &mocks.WebhookRepository{}
+
+		svc := service.NewWebhookService(mockWebhookRepo, &mocks.WebhookEventRepository{},
+			&mocks.MessageRepository{}, logger)
+
+		mockWebhookRepo.On("Upsert", context.Background(), mock.MatchedBy(func(webhook *model.Webhook) bool {
+			return webhook.UserID == "1234567890" && len(webhook.Secret) == 64 && webhook.Enabled
+		})).Return(nil)
+
+		details, err := svc.RegisterWebhook(context.Background(),
+			service.RegisterWebhookCommand{UserID: "1234567890", URL: "https://customer.test/hooks"})
+
+		assert.NoError(t, err)
+		assert.Len(t, details.Secret, 64)
+		mockWebhookRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns not found when deleting a missing webhook", func(t *testing.T) {
+		mockWebhookRepo := &mocks.WebhookRepository{}
+
+		svc := service.NewWebhookService(mockWebhookRepo, &mocks.WebhookEventRepository{},
+			&mocks.MessageRepository{}, logger)
+
+		mockWebhookRepo.On("Delete", context.Background(), "1234567890").Return(repository.ErrWebhookNotFound)
+
+		err := svc.DeleteWebhook(context.Background(), "1234567890")
+
+		var serviceErr service.Error
+		assert.True(t, errors.As(err, &serviceErr))
+		assert.Equal(t, constants.ErrCodeWebhookNotFound, serviceErr.Code)
+	})
+}
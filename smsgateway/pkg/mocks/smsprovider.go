@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/stretchr/testify/mock"
+)
+
+type SMSProvider struct {
+	mock.Mock
+}
+
+func (_m *SMSProvider) Send(ctx context.Context, from string, to string, text string) (smsprovider.Response, error) {
+	ret := _m.Called(ctx, from, to, text)
+	return ret.Get(0).(smsprovider.Response), ret.Error(1)
+}
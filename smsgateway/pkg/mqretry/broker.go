@@ -0,0 +1,228 @@
+package mqretry
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Headers set on parked messages so operators can tell where they came from and why they stopped.
+const (
+	HeaderQueue   = "x-original-queue"
+	HeaderRetries = "x-retries"
+	HeaderError   = "x-last-error"
+)
+
+// Retrier schedules a failed message for another try.
+type Retrier interface {
+	// Retry publishes body to queue's delay queue for the given retry, counting from 1, or to its parking-lot queue
+	// once the schedule is used up, and reports whether it was parked. cause is recorded on parked messages.
+	Retry(ctx context.Context, queue string, retry int, body []byte, cause error) (parked bool, err error)
+	// Park moves a message that can never succeed, such as one that cannot be decoded, straight to the parking lot.
+	Park(ctx context.Context, queue string, body []byte, cause error) error
+	// Defer hands body back to queue after delay without using up a retry, for messages held back by something other
+	// than their own failure.
+	Defer(ctx context.Context, queue string, delay time.Duration, body []byte) error
+}
+
+// Broker declares the retry topology and publishes into it over its own channel, since delay queues need queue
+// arguments and per-message expirations. The channel is in confirm mode, so a retry or parked message is reported as
+// stored only once RabbitMQ has confirmed it, and it is reopened on the next call after the connection drops.
+type Broker struct {
+	url string
+
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	policies map[string]Policy
+}
+
+func Dial(url string) (*Broker, error) {
+	b := &Broker{url: url, policies: make(map[string]Policy)}
+	if err := b.connect(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// connect dials a new connection and opens a confirming channel on it.
+func (b *Broker) connect() error {
+	conn, err := amqp.Dial(b.url)
+	if err != nil {
+		return err
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	if err := channel.Confirm(false); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	b.conn, b.channel = conn, channel
+	return nil
+}
+
+// ready returns the open channel, redialling if the channel or its connection closed since the last call. A channel
+// also closes on a failed operation, such as declaring a queue with conflicting arguments. The declared queues are
+// durable, so the new channel needs no topology. The caller holds mu.
+func (b *Broker) ready() (*amqp.Channel, error) {
+	if b.channel != nil && !b.channel.IsClosed() {
+		return b.channel, nil
+	}
+
+	if b.conn != nil {
+		_ = b.conn.Close()
+		b.conn, b.channel = nil, nil
+	}
+
+	if err := b.connect(); err != nil {
+		return nil, fmt.Errorf("reconnect: %w", err)
+	}
+
+	return b.channel, nil
+}
+
+// publish sends msg to queue through the default exchange and waits for RabbitMQ to confirm it. The caller holds mu.
+func (b *Broker) publish(ctx context.Context, queue string, msg amqp.Publishing) error {
+	channel, err := b.ready()
+	if err != nil {
+		return err
+	}
+
+	confirm, err := channel.PublishWithDeferredConfirmWithContext(ctx, "", queue, false, false, msg)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return fmt.Errorf("publish to %s was not confirmed", queue)
+	}
+
+	return nil
+}
+
+// Declare creates queue's delay queues and parking-lot queue and remembers its policy. Each delay queue's TTL is
+// the longest jittered delay of its retry; messages carry their own shorter expiration, and once expired are
+// dead-lettered through the default exchange back to queue.
+func (b *Broker) Declare(queue string, policy Policy) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	channel, err := b.ready()
+	if err != nil {
+		return err
+	}
+
+	for retry := 1; retry <= len(policy.Delays); retry++ {
+		args := amqp.Table{
+			"x-message-ttl":             policy.maxDelay(retry).Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queue,
+		}
+
+		if _, err := channel.QueueDeclare(RetryQueue(queue, retry), true, false, false, false, args); err != nil {
+			return fmt.Errorf("declare %s: %w", RetryQueue(queue, retry), err)
+		}
+	}
+
+	if _, err := channel.QueueDeclare(ParkingQueue(queue), true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare %s: %w", ParkingQueue(queue), err)
+	}
+
+	b.policies[queue] = policy
+	return nil
+}
+
+func (b *Broker) Retry(ctx context.Context, queue string, retry int, body []byte, cause error) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delay, ok := b.policies[queue].Delay(retry, rand.Float64)
+	if !ok {
+		return true, b.park(ctx, queue, retry-1, body, cause)
+	}
+
+	msg := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Expiration:   strconv.FormatInt(delay.Milliseconds(), 10),
+		Body:         body,
+	}
+
+	return false, b.publish(ctx, RetryQueue(queue, retry), msg)
+}
+
+// Defer waits in one of queue's delay queues, so it needs no topology of its own. Delays longer than the longest delay
+// queue are cut short; the consumer then defers the message again.
+func (b *Broker) Defer(ctx context.Context, queue string, delay time.Duration, body []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	policy, ok := b.policies[queue]
+	if !ok || len(policy.Delays) == 0 {
+		return fmt.Errorf("no retry policy declared for %s", queue)
+	}
+
+	retry, delay := policy.Hold(delay)
+	msg := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Expiration:   strconv.FormatInt(delay.Milliseconds(), 10),
+		Body:         body,
+	}
+
+	return b.publish(ctx, RetryQueue(queue, retry), msg)
+}
+
+func (b *Broker) Park(ctx context.Context, queue string, body []byte, cause error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.park(ctx, queue, 0, body, cause)
+}
+
+func (b *Broker) park(ctx context.Context, queue string, retries int, body []byte, cause error) error {
+	msg := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Headers:      amqp.Table{HeaderQueue: queue, HeaderRetries: int64(retries)},
+		Body:         body,
+	}
+	if cause != nil {
+		msg.Headers[HeaderError] = cause.Error()
+	}
+
+	return b.publish(ctx, ParkingQueue(queue), msg)
+}
+
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		return nil
+	}
+
+	_ = b.channel.Close()
+	err := b.conn.Close()
+	b.conn, b.channel = nil, nil
+	return err
+}
@@ -0,0 +1,143 @@
+package mqretry
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Action is what Sweep does with one parked message.
+type Action int
+
+const (
+	Keep Action = iota
+	Remove
+	Replay
+)
+
+// DeadLetter is a message in a queue's parking lot, the dead-letter queue operators inspect and replay from.
+type DeadLetter struct {
+	Queue    string    `json:"queue"`
+	Retries  int64     `json:"retries"`
+	Error    string    `json:"error,omitempty"`
+	ParkedAt time.Time `json:"parked_at"`
+	Body     string    `json:"body"`
+}
+
+// Count returns how many messages are parked for queue.
+func (b *Broker) Count(queue string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	channel, err := b.ready()
+	if err != nil {
+		return 0, err
+	}
+
+	parked, err := channel.QueueDeclare(ParkingQueue(queue), true, false, false, false, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return parked.Messages, nil
+}
+
+// Sweep passes every message parked for queue to visit, in order, and keeps, removes or replays it as visit
+// decides. Replayed messages go back to queue with their retry count cleared so they get the full schedule again;
+// the consumers' status checks make a replay of an already handled message a no-op. Kept messages are returned to
+// the parking lot once the sweep ends. It returns how many messages were removed or replayed.
+func (b *Broker) Sweep(ctx context.Context, queue string, visit func(DeadLetter) Action) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	channel, err := b.ready()
+	if err != nil {
+		return 0, err
+	}
+
+	var kept []amqp.Delivery
+	defer func() {
+		for _, delivery := range kept {
+			_ = delivery.Nack(false, true)
+		}
+	}()
+
+	affected := 0
+	for {
+		delivery, ok, err := channel.Get(ParkingQueue(queue), false)
+		if err != nil || !ok {
+			return affected, err
+		}
+
+		switch visit(newDeadLetter(queue, delivery)) {
+		case Replay:
+			msg := amqp.Publishing{
+				ContentType:  delivery.ContentType,
+				DeliveryMode: amqp.Persistent,
+				Timestamp:    time.Now(),
+				Body:         clearRetry(delivery.Body),
+			}
+			if err := b.publish(ctx, queue, msg); err != nil {
+				kept = append(kept, delivery)
+				return affected, err
+			}
+		case Remove:
+		default:
+			kept = append(kept, delivery)
+			continue
+		}
+
+		if err := delivery.Ack(false); err != nil {
+			return affected, err
+		}
+		affected++
+	}
+}
+
+// Purge drops every message parked for queue.
+func (b *Broker) Purge(queue string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	channel, err := b.ready()
+	if err != nil {
+		return 0, err
+	}
+
+	return channel.QueuePurge(ParkingQueue(queue), false)
+}
+
+func newDeadLetter(queue string, delivery amqp.Delivery) DeadLetter {
+	letter := DeadLetter{Queue: queue, ParkedAt: delivery.Timestamp, Body: string(delivery.Body)}
+
+	if retries, ok := delivery.Headers[HeaderRetries].(int64); ok {
+		letter.Retries = retries
+	}
+	if cause, ok := delivery.Headers[HeaderError].(string); ok {
+		letter.Error = cause
+	}
+
+	return letter
+}
+
+// clearRetry drops the retry counter from a JSON command. Bodies that are not JSON objects are replayed unchanged.
+func clearRetry(body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+
+	if _, ok := fields["retry"]; !ok {
+		return body
+	}
+	delete(fields, "retry")
+
+	cleared, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+
+	return cleared
+}
@@ -0,0 +1,62 @@
+// Package mqretry delays redelivery of failed messages with RabbitMQ's own machinery. Every queue gets one delay queue
+// per retry whose expired messages are dead-lettered back to it, and a parking-lot queue for messages that used up
+// the schedule.
+package mqretry
+
+import (
+	"fmt"
+	"time"
+)
+
+// Policy is the backoff schedule for one queue: the nth retry waits Delays[n-1], varied by up to Jitter (a fraction
+// such as 0.2) either way so retries of a failed burst do not arrive together. Messages that fail again after the
+// last delay are parked.
+type Policy struct {
+	Delays []time.Duration `mapstructure:"delays"`
+	Jitter float64         `mapstructure:"jitter"`
+}
+
+// Delay returns how long the given retry waits, with jitter drawn from random, a source of values in [0, 1). It
+// reports false once the schedule is used up.
+func (p Policy) Delay(retry int, random func() float64) (time.Duration, bool) {
+	if retry < 1 || retry > len(p.Delays) {
+		return 0, false
+	}
+
+	delay := p.Delays[retry-1]
+	if p.Jitter <= 0 {
+		return delay, true
+	}
+
+	spread := float64(delay) * p.Jitter
+	return time.Duration(float64(delay) - spread + 2*spread*random()), true
+}
+
+// Hold picks the delay queue for a message that should come back after delay without moving along the schedule: the
+// first retry whose delay queue can keep it that long, or the last one when none can. It returns that retry and how
+// long the message will actually wait, which is delay capped at the queue's TTL. The schedule must not be empty.
+func (p Policy) Hold(delay time.Duration) (int, time.Duration) {
+	for retry := 1; retry <= len(p.Delays); retry++ {
+		if p.maxDelay(retry) >= delay {
+			return retry, delay
+		}
+	}
+
+	return len(p.Delays), p.maxDelay(len(p.Delays))
+}
+
+// maxDelay is the longest a retry can wait, which is the TTL of its delay queue.
+func (p Policy) maxDelay(retry int) time.Duration {
+	delay := p.Delays[retry-1]
+	return delay + time.Duration(float64(delay)*max(p.Jitter, 0))
+}
+
+// RetryQueue names the delay queue for a queue's nth retry.
+func RetryQueue(queue string, retry int) string {
+	return fmt.Sprintf("%s.retry.%d", queue, retry)
+}
+
+// ParkingQueue names the queue that holds a queue's messages once their retries are used up.
+func ParkingQueue(queue string) string {
+	return queue + ".parking"
+}
@@ -0,0 +1,70 @@
+package mqretry_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/mqretry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Delay(t *testing.T) {
+	policy := mqretry.Policy{Delays: []time.Duration{time.Second, 10 * time.Second}, Jitter: 0.2}
+
+	t.Run("follows the schedule", func(t *testing.T) {
+		delay, ok := policy.Delay(2, func() float64 { return 0.5 })
+
+		assert.True(t, ok)
+		assert.Equal(t, 10*time.Second, delay)
+	})
+
+	t.Run("varies delays by the jitter either way", func(t *testing.T) {
+		shortest, _ := policy.Delay(1, func() float64 { return 0 })
+		longest, _ := policy.Delay(1, func() float64 { return 0.999999 })
+
+		assert.Equal(t, 800*time.Millisecond, shortest)
+		assert.InDelta(t, float64(1200*time.Millisecond), float64(longest), float64(time.Millisecond))
+	})
+
+	t.Run("is exact without jitter", func(t *testing.T) {
+		delay, ok := mqretry.Policy{Delays: []time.Duration{time.Minute}}.Delay(1, func() float64 { return 0.9 })
+
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, delay)
+	})
+
+	t.Run("parks once the schedule is used up", func(t *testing.T) {
+		_, ok := policy.Delay(3, func() float64 { return 0.5 })
+
+		assert.False(t, ok)
+	})
+
+	t.Run("parks at once without a schedule", func(t *testing.T) {
+		_, ok := mqretry.Policy{}.Delay(1, func() float64 { return 0.5 })
+
+		assert.False(t, ok)
+	})
+}
+
+func TestQueueNames(t *testing.T) {
+	assert.Equal(t, "sms.send.otp.retry.2", mqretry.RetryQueue("sms.send.otp", 2))
+	assert.Equal(t, "sms.refund.parking", mqretry.ParkingQueue("sms.refund"))
+}
+
+func TestPolicy_Hold(t *testing.T) {
+	policy := mqretry.Policy{Delays: []time.Duration{time.Second, 10 * time.Second}, Jitter: 0.2}
+
+	t.Run("uses the first delay queue that can hold the message", func(t *testing.T) {
+		retry, delay := policy.Hold(5 * time.Second)
+
+		assert.Equal(t, 2, retry)
+		assert.Equal(t, 5*time.Second, delay)
+	})
+
+	t.Run("caps delays beyond the longest delay queue", func(t *testing.T) {
+		retry, delay := policy.Hold(time.Minute)
+
+		assert.Equal(t, 2, retry)
+		assert.Equal(t, 12*time.Second, delay)
+	})
+}
@@ -0,0 +1,38 @@
+package ratelimit
+
+import "time"
+
+const (
+	BackendMemory = "memory"
+	BackendMySQL  = "mysql"
+)
+
+type Config struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	Backend     string `mapstructure:"backend"`
+	Account     Limit  `mapstructure:"account"`
+	Sender      Limit  `mapstructure:"sender"`
+	Destination Limit  `mapstructure:"destination"`
+}
+
+// Limit refills Requests tokens every Per, holding at most Burst. A zero Requests disables the limit.
+type Limit struct {
+	Requests int           `mapstructure:"requests"`
+	Per      time.Duration `mapstructure:"per"`
+	Burst    int           `mapstructure:"burst"`
+}
+
+func (l Limit) Disabled() bool {
+	return l.Requests <= 0 || l.Per <= 0
+}
+
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+func (l Limit) ratePerSecond() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter is a keyed token bucket store. Allow takes cost tokens from the bucket named key when enough are
+// available and otherwise reports how long until they will be.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit, cost int) (Result, error)
+}
+
+// take refills a bucket last touched at updatedAt and tries to spend cost tokens from it. It returns the new token
+// count alongside the result; callers persist tokens with now as the new update time.
+func take(tokens float64, updatedAt, now time.Time, limit Limit, cost int) (float64, Result) {
+	capacity := limit.capacity()
+	rate := limit.ratePerSecond()
+
+	if elapsed := now.Sub(updatedAt).Seconds(); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed*rate)
+	}
+
+	need := float64(cost)
+	if tokens >= need {
+		tokens -= need
+		return tokens, Result{Allowed: true, Remaining: int(tokens)}
+	}
+
+	if need > capacity {
+		return tokens, Result{RetryAfter: limit.Per}
+	}
+
+	wait := time.Duration((need - tokens) / rate * float64(time.Second))
+	return tokens, Result{Remaining: int(tokens), RetryAfter: wait}
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepEvery = 1024
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	idleAfter time.Time
+}
+
+// Memory keeps buckets in process. It is exact for a single API instance; replicas each get their own budget.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (m *Memory) Allow(_ context.Context, key string, limit Limit, cost int) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.capacity(), updatedAt: now}
+		m.buckets[key] = b
+	}
+
+	tokens, result := take(b.tokens, b.updatedAt, now, limit, cost)
+	b.tokens = tokens
+	b.updatedAt = now
+	// A bucket idle long enough to refill completely is indistinguishable from a new one and can be dropped.
+	b.idleAfter = now.Add(time.Duration((limit.capacity() - tokens) / limit.ratePerSecond() * float64(time.Second)))
+
+	return result, nil
+}
+
+func (m *Memory) sweep(now time.Time) {
+	m.calls++
+	if m.calls%sweepEvery != 0 {
+		return
+	}
+
+	for key, b := range m.buckets {
+		if now.After(b.idleAfter) {
+			delete(m.buckets, key)
+		}
+	}
+}
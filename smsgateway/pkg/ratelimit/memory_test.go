@@ -0,0 +1,62 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_Allow(t *testing.T) {
+	ctx := context.Background()
+	limit := ratelimit.Limit{Requests: 1, Per: 50 * time.Millisecond, Burst: 2}
+
+	t.Run("allows the burst then reports when to retry", func(t *testing.T) {
+		limiter := ratelimit.NewMemory()
+
+		for i := 0; i < 2; i++ {
+			result, err := limiter.Allow(ctx, "sender:1234567890", limit, 1)
+			assert.NoError(t, err)
+			assert.True(t, result.Allowed)
+		}
+
+		result, err := limiter.Allow(ctx, "sender:1234567890", limit, 1)
+
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Greater(t, result.RetryAfter, time.Duration(0))
+		assert.LessOrEqual(t, result.RetryAfter, limit.Per)
+
+		time.Sleep(result.RetryAfter)
+
+		result, err = limiter.Allow(ctx, "sender:1234567890", limit, 1)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("keeps separate buckets per key", func(t *testing.T) {
+		limiter := ratelimit.NewMemory()
+
+		for i := 0; i < 2; i++ {
+			_, _ = limiter.Allow(ctx, "destination:0987654321", limit, 1)
+		}
+
+		result, err := limiter.Allow(ctx, "destination:0987654322", limit, 1)
+
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 1, result.Remaining)
+	})
+
+	t.Run("never allows a cost above the burst", func(t *testing.T) {
+		limiter := ratelimit.NewMemory()
+
+		result, err := limiter.Allow(ctx, "account:7", limit, 3)
+
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, limit.Per, result.RetryAfter)
+	})
+}
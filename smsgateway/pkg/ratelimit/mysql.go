@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type bucketRow struct {
+	BucketKey string    `gorm:"primaryKey;type:varchar(255)"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"type:timestamp(6);not null;autoUpdateTime:false"`
+}
+
+func (bucketRow) TableName() string {
+	return "rate_limit_buckets"
+}
+
+// MySQL stores buckets in the rate_limit_buckets table so that every API replica spends from the same budget. Each
+// call locks its bucket row for the duration of a short transaction.
+type MySQL struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+func NewMySQL(db *gorm.DB) *MySQL {
+	return &MySQL{db: db, now: time.Now}
+}
+
+func (m *MySQL) Allow(ctx context.Context, key string, limit Limit, cost int) (Result, error) {
+	var result Result
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := m.now()
+
+		seed := bucketRow{BucketKey: key, Tokens: limit.capacity(), UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seed).Error; err != nil {
+			return err
+		}
+
+		var row bucketRow
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("bucket_key = ?", key).First(&row).Error; err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, result = take(row.Tokens, row.UpdatedAt, now, limit, cost)
+
+		return tx.Model(&bucketRow{}).Where("bucket_key = ?", key).
+			Updates(map[string]any{"tokens": tokens, "updated_at": now}).Error
+	})
+
+	return result, err
+}
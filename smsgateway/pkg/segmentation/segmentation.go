@@ -0,0 +1,212 @@
+package segmentation
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+)
+
+type Encoding string
+
+const (
+	EncodingGSM7 Encoding = "GSM7"
+	EncodingUCS2 Encoding = "UCS2"
+)
+
+const (
+	GSM7SingleSegmentSize = 160
+	GSM7MultiSegmentSize  = 153
+	UCS2SingleSegmentSize = 70
+	UCS2MultiSegmentSize  = 67
+)
+
+// gsm7Basic is the GSM 03.38 default alphabet, excluding the escape character.
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension holds characters sent as an escape sequence, costing two septets each.
+const gsm7Extension = "\f^{}\\[~]|€"
+
+// gsm7Escape prefixes a character from the extension table.
+const gsm7Escape = 0x1B
+
+var (
+	basicSet     = runeSet(gsm7Basic)
+	extensionSet = runeSet(gsm7Extension)
+
+	basicCodes     = runeCodes(gsm7Basic)
+	extensionCodes = map[rune]byte{'\f': 0x0A, '^': 0x14, '{': 0x28, '}': 0x29, '\\': 0x2F, '[': 0x3C, '~': 0x3D,
+		']': 0x3E, '|': 0x40, '€': 0x65}
+)
+
+type Result struct {
+	Encoding Encoding
+	Segments int
+	// Units is the encoded length: septets for GSM-7, UTF-16 code units for UCS-2.
+	Units int
+}
+
+// Calculate detects the encoding needed for text and counts the SMS segments it occupies.
+func Calculate(text string) Result {
+	if widths, ok := gsm7Widths(text); ok {
+		return Result{
+			Encoding: EncodingGSM7,
+			Segments: countSegments(widths, GSM7SingleSegmentSize, GSM7MultiSegmentSize),
+			Units:    sum(widths),
+		}
+	}
+
+	widths := ucs2Widths(text)
+	return Result{
+		Encoding: EncodingUCS2,
+		Segments: countSegments(widths, UCS2SingleSegmentSize, UCS2MultiSegmentSize),
+		Units:    sum(widths),
+	}
+}
+
+// Split breaks text into the segments Calculate counts, so what is sent matches what was charged.
+func Split(text string) (Encoding, []string) {
+	widths, ok := gsm7Widths(text)
+	singleSize, multiSize := GSM7SingleSegmentSize, GSM7MultiSegmentSize
+	encoding := EncodingGSM7
+	if !ok {
+		widths = ucs2Widths(text)
+		singleSize, multiSize = UCS2SingleSegmentSize, UCS2MultiSegmentSize
+		encoding = EncodingUCS2
+	}
+
+	if sum(widths) <= singleSize {
+		return encoding, []string{text}
+	}
+
+	var segments []string
+	var current strings.Builder
+	used, i := 0, 0
+	for _, r := range text {
+		if used+widths[i] > multiSize {
+			segments = append(segments, current.String())
+			current.Reset()
+			used = 0
+		}
+		current.WriteRune(r)
+		used += widths[i]
+		i++
+	}
+
+	return encoding, append(segments, current.String())
+}
+
+// EncodeGSM7 returns text in the GSM 03.38 default alphabet, one septet per octet as SMPP carries it. Characters
+// outside the alphabet become '?'; check the encoding with Calculate or Split first.
+func EncodeGSM7(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		if code, ok := basicCodes[r]; ok {
+			encoded = append(encoded, code)
+			continue
+		}
+
+		if code, ok := extensionCodes[r]; ok {
+			encoded = append(encoded, gsm7Escape, code)
+			continue
+		}
+
+		encoded = append(encoded, basicCodes['?'])
+	}
+
+	return encoded
+}
+
+// EncodeUCS2 returns text as big-endian UTF-16, the byte order SMSCs expect for UCS-2.
+func EncodeUCS2(text string) []byte {
+	units := utf16.Encode([]rune(text))
+
+	encoded := make([]byte, 2*len(units))
+	for i, unit := range units {
+		binary.BigEndian.PutUint16(encoded[2*i:], unit)
+	}
+
+	return encoded
+}
+
+func gsm7Widths(text string) ([]int, bool) {
+	widths := make([]int, 0, len(text))
+	for _, r := range text {
+		switch {
+		case basicSet[r]:
+			widths = append(widths, 1)
+		case extensionSet[r]:
+			widths = append(widths, 2)
+		default:
+			return nil, false
+		}
+	}
+
+	return widths, true
+}
+
+func ucs2Widths(text string) []int {
+	widths := make([]int, 0, len(text))
+	for _, r := range text {
+		if utf16.RuneLen(r) == 2 {
+			widths = append(widths, 2)
+			continue
+		}
+
+		widths = append(widths, 1)
+	}
+
+	return widths
+}
+
+// countSegments packs characters into concatenated segments without splitting
+// an escape sequence or surrogate pair across a segment boundary.
+func countSegments(widths []int, singleSize, multiSize int) int {
+	if sum(widths) <= singleSize {
+		return 1
+	}
+
+	segments, used := 1, 0
+	for _, w := range widths {
+		if used+w > multiSize {
+			segments++
+			used = 0
+		}
+		used += w
+	}
+
+	return segments
+}
+
+func sum(widths []int) int {
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+
+	return total
+}
+
+func runeSet(chars string) map[rune]bool {
+	set := make(map[rune]bool, len(chars))
+	for _, r := range chars {
+		set[r] = true
+	}
+
+	return set
+}
+
+// runeCodes maps each character of the alphabet to its position, skipping the escape code.
+func runeCodes(alphabet string) map[rune]byte {
+	codes := make(map[rune]byte)
+	var code byte
+	for _, r := range alphabet {
+		if code == gsm7Escape {
+			code++
+		}
+		codes[r] = code
+		code++
+	}
+
+	return codes
+}
@@ -0,0 +1,78 @@
+package segmentation_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/segmentation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		encoding segmentation.Encoding
+		segments int
+		units    int
+	}{
+		{"short gsm7", "Hello World", segmentation.EncodingGSM7, 1, 11},
+		{"full single gsm7 segment", strings.Repeat("a", 160), segmentation.EncodingGSM7, 1, 160},
+		{"two gsm7 segments", strings.Repeat("a", 161), segmentation.EncodingGSM7, 2, 161},
+		{"three gsm7 segments", strings.Repeat("a", 307), segmentation.EncodingGSM7, 3, 307},
+		{"extension table counts double", strings.Repeat("€", 80), segmentation.EncodingGSM7, 1, 160},
+		{"extension table overflows", strings.Repeat("€", 81), segmentation.EncodingGSM7, 2, 162},
+		{"escape sequence not split", strings.Repeat("a", 152) + "€" + strings.Repeat("a", 152), segmentation.EncodingGSM7, 3, 306},
+		{"persian text", "کد تایید شما ۱۲۳۴ است", segmentation.EncodingUCS2, 1, 21},
+		{"full single ucs2 segment", strings.Repeat("س", 70), segmentation.EncodingUCS2, 1, 70},
+		{"two ucs2 segments", strings.Repeat("س", 71), segmentation.EncodingUCS2, 2, 71},
+		{"mixed latin and arabic", strings.Repeat("a", 100) + "ع", segmentation.EncodingUCS2, 2, 101},
+		{"emoji uses surrogate pair", strings.Repeat("😀", 35), segmentation.EncodingUCS2, 1, 70},
+		{"surrogate pair not split", strings.Repeat("س", 66) + "😀" + strings.Repeat("س", 66), segmentation.EncodingUCS2, 3, 134},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := segmentation.Calculate(tt.text)
+
+			assert.Equal(t, tt.encoding, result.Encoding)
+			assert.Equal(t, tt.segments, result.Segments)
+			assert.Equal(t, tt.units, result.Units)
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	t.Run("keeps a single segment whole", func(t *testing.T) {
+		encoding, segments := segmentation.Split("Hello World")
+
+		assert.Equal(t, segmentation.EncodingGSM7, encoding)
+		assert.Equal(t, []string{"Hello World"}, segments)
+	})
+
+	t.Run("splits as many segments as Calculate counts", func(t *testing.T) {
+		texts := []string{
+			strings.Repeat("a", 307),
+			strings.Repeat("a", 152) + "€" + strings.Repeat("a", 152),
+			strings.Repeat("س", 66) + "😀" + strings.Repeat("س", 66),
+		}
+
+		for _, text := range texts {
+			encoding, segments := segmentation.Split(text)
+
+			assert.Equal(t, segmentation.Calculate(text).Encoding, encoding)
+			assert.Len(t, segments, segmentation.Calculate(text).Segments)
+			assert.Equal(t, text, strings.Join(segments, ""))
+		}
+	})
+}
+
+func TestEncode(t *testing.T) {
+	t.Run("gsm7 uses the default alphabet and escapes the extension table", func(t *testing.T) {
+		assert.Equal(t, []byte{0x00, 0x41, 0x61, 0x1B, 0x65, 0x1C}, segmentation.EncodeGSM7("@Aa€Æ"))
+	})
+
+	t.Run("ucs2 is big-endian utf-16", func(t *testing.T) {
+		assert.Equal(t, []byte{0x06, 0x33, 0xD8, 0x3D, 0xDE, 0x00}, segmentation.EncodeUCS2("س😀"))
+	})
+}
@@ -0,0 +1,192 @@
+// Package simulator decides how a fake SMS provider answers: how long it takes, whether it accepts the message and
+// what delivery receipt follows. It serves cmd/provider-simulator, which speaks the default HTTP provider contract.
+package simulator
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+)
+
+type Outcome string
+
+const (
+	// OutcomeDelivered accepts the message and reports it delivered.
+	OutcomeDelivered Outcome = "delivered"
+	// OutcomeUndelivered accepts the message and reports it undeliverable.
+	OutcomeUndelivered Outcome = "undelivered"
+	// OutcomeInvalidNumber rejects the message with 400.
+	OutcomeInvalidNumber Outcome = "invalid_number"
+	// OutcomeServerError fails with 503.
+	OutcomeServerError Outcome = "server_error"
+	// OutcomeTimeout answers only after TimeoutDelay, later than any sensible client timeout.
+	OutcomeTimeout Outcome = "timeout"
+)
+
+const (
+	DistributionFixed       = "fixed"
+	DistributionUniform     = "uniform"
+	DistributionNormal      = "normal"
+	DistributionExponential = "exponential"
+)
+
+const defaultTimeoutDelay = 30 * time.Second
+
+type Config struct {
+	Port         string             `mapstructure:"port"`
+	Latency      Latency            `mapstructure:"latency"`
+	Errors       ErrorRates         `mapstructure:"errors"`
+	TimeoutDelay time.Duration      `mapstructure:"timeout_delay"`
+	MagicNumbers map[string]Outcome `mapstructure:"magic_numbers"`
+	Receipts     Receipts           `mapstructure:"receipts"`
+	// Seed makes the random error mix and latencies repeatable. Zero seeds from the clock.
+	Seed uint64 `mapstructure:"seed"`
+}
+
+// Latency describes a delay distribution. Fixed waits Mean; uniform picks between Min and Max; normal centres on
+// Mean with StdDev; exponential adds an exponentially distributed delay with mean Mean to Min. Samples are clamped
+// to Min and, when set, Max.
+type Latency struct {
+	Distribution string        `mapstructure:"distribution"`
+	Min          time.Duration `mapstructure:"min"`
+	Max          time.Duration `mapstructure:"max"`
+	Mean         time.Duration `mapstructure:"mean"`
+	StdDev       time.Duration `mapstructure:"stddev"`
+}
+
+// ErrorRates are the probabilities, between 0 and 1, of each outcome other than delivered for numbers that are not
+// magic. Their sum should not exceed 1.
+type ErrorRates struct {
+	InvalidNumber float64 `mapstructure:"invalid_number"`
+	ServerError   float64 `mapstructure:"server_error"`
+	Timeout       float64 `mapstructure:"timeout"`
+	Undelivered   float64 `mapstructure:"undelivered"`
+}
+
+// Receipts configures delivery receipt callbacks. Without a URL no receipts are sent. Auth must match the receipts
+// setting of the provider route the simulator stands in for.
+type Receipts struct {
+	URL     string                   `mapstructure:"url"`
+	Timeout time.Duration            `mapstructure:"timeout"`
+	Delay   Latency                  `mapstructure:"delay"`
+	Auth    smsprovider.CallbackAuth `mapstructure:"auth"`
+}
+
+// Receipt is the body posted to the gateway's delivery report endpoint.
+type Receipt struct {
+	ProviderMsgID string    `json:"provider_msg_id"`
+	Status        string    `json:"status"`
+	ErrorCode     string    `json:"error_code,omitempty"`
+	ReportedAt    time.Time `json:"reported_at"`
+}
+
+type Simulator struct {
+	cfg      Config
+	mu       sync.Mutex
+	rng      *rand.Rand
+	sequence atomic.Int64
+}
+
+func New(cfg Config) *Simulator {
+	if cfg.TimeoutDelay <= 0 {
+		cfg.TimeoutDelay = defaultTimeoutDelay
+	}
+
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+
+	return &Simulator{cfg: cfg, rng: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// Outcome returns the configured outcome for a magic number, and otherwise draws one from the error mix.
+func (s *Simulator) Outcome(to string) Outcome {
+	if outcome, ok := s.cfg.MagicNumbers[to]; ok {
+		return outcome
+	}
+
+	s.mu.Lock()
+	draw := s.rng.Float64()
+	s.mu.Unlock()
+
+	rates := s.cfg.Errors
+	for _, candidate := range []struct {
+		outcome Outcome
+		rate    float64
+	}{
+		{OutcomeInvalidNumber, rates.InvalidNumber},
+		{OutcomeServerError, rates.ServerError},
+		{OutcomeTimeout, rates.Timeout},
+		{OutcomeUndelivered, rates.Undelivered},
+	} {
+		if draw < candidate.rate {
+			return candidate.outcome
+		}
+		draw -= candidate.rate
+	}
+
+	return OutcomeDelivered
+}
+
+// Latency returns how long to wait before answering a send request.
+func (s *Simulator) Latency() time.Duration {
+	return s.sample(s.cfg.Latency)
+}
+
+// ReceiptDelay returns how long after accepting a message its receipt is sent.
+func (s *Simulator) ReceiptDelay() time.Duration {
+	return s.sample(s.cfg.Receipts.Delay)
+}
+
+func (s *Simulator) TimeoutDelay() time.Duration {
+	return s.cfg.TimeoutDelay
+}
+
+// NextMessageID returns the id reported for the next accepted message.
+func (s *Simulator) NextMessageID() string {
+	return fmt.Sprintf("sim-%d", s.sequence.Add(1))
+}
+
+// Receipt builds the delivery receipt for an accepted message. It reports false for outcomes that were never
+// accepted.
+func (s *Simulator) Receipt(messageID string, outcome Outcome, reportedAt time.Time) (Receipt, bool) {
+	switch outcome {
+	case OutcomeDelivered:
+		return Receipt{ProviderMsgID: messageID, Status: "DELIVRD", ReportedAt: reportedAt}, true
+	case OutcomeUndelivered:
+		return Receipt{ProviderMsgID: messageID, Status: "UNDELIV", ErrorCode: "001", ReportedAt: reportedAt}, true
+	default:
+		return Receipt{}, false
+	}
+}
+
+func (s *Simulator) sample(latency Latency) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var value time.Duration
+	switch latency.Distribution {
+	case DistributionUniform:
+		if latency.Max > latency.Min {
+			value = latency.Min + time.Duration(s.rng.Int64N(int64(latency.Max-latency.Min)))
+		}
+	case DistributionNormal:
+		value = latency.Mean + time.Duration(s.rng.NormFloat64()*float64(latency.StdDev))
+	case DistributionExponential:
+		value = latency.Min + time.Duration(s.rng.ExpFloat64()*float64(latency.Mean))
+	default:
+		value = latency.Mean
+	}
+
+	value = max(value, latency.Min, 0)
+	if latency.Max > 0 {
+		value = min(value, latency.Max)
+	}
+
+	return value
+}
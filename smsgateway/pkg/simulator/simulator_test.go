@@ -0,0 +1,129 @@
+package simulator_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/simulator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutcome(t *testing.T) {
+	t.Run("magic numbers always get their outcome", func(t *testing.T) {
+		sim := simulator.New(simulator.Config{
+			Errors:       simulator.ErrorRates{ServerError: 1},
+			MagicNumbers: map[string]simulator.Outcome{"989000000400": simulator.OutcomeInvalidNumber},
+		})
+
+		for range 20 {
+			assert.Equal(t, simulator.OutcomeInvalidNumber, sim.Outcome("989000000400"))
+		}
+	})
+
+	t.Run("draws other numbers from the error mix", func(t *testing.T) {
+		sim := simulator.New(simulator.Config{
+			Seed:   42,
+			Errors: simulator.ErrorRates{InvalidNumber: 0.1, ServerError: 0.2, Timeout: 0.1, Undelivered: 0.1},
+		})
+
+		counts := make(map[simulator.Outcome]int)
+		for range 10000 {
+			counts[sim.Outcome("989121234567")]++
+		}
+
+		assert.InDelta(t, 1000, counts[simulator.OutcomeInvalidNumber], 150)
+		assert.InDelta(t, 2000, counts[simulator.OutcomeServerError], 200)
+		assert.InDelta(t, 1000, counts[simulator.OutcomeTimeout], 150)
+		assert.InDelta(t, 1000, counts[simulator.OutcomeUndelivered], 150)
+		assert.InDelta(t, 5000, counts[simulator.OutcomeDelivered], 250)
+	})
+
+	t.Run("delivers everything without an error mix", func(t *testing.T) {
+		sim := simulator.New(simulator.Config{})
+
+		assert.Equal(t, simulator.OutcomeDelivered, sim.Outcome("989121234567"))
+	})
+
+	t.Run("repeats the same sequence for the same seed", func(t *testing.T) {
+		cfg := simulator.Config{Seed: 7, Errors: simulator.ErrorRates{ServerError: 0.5}}
+		first, second := simulator.New(cfg), simulator.New(cfg)
+
+		for range 50 {
+			assert.Equal(t, first.Outcome("989121234567"), second.Outcome("989121234567"))
+		}
+	})
+}
+
+func TestLatency(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency simulator.Latency
+		min     time.Duration
+		max     time.Duration
+	}{
+		{
+			name:    "fixed waits the mean",
+			latency: simulator.Latency{Distribution: simulator.DistributionFixed, Mean: 50 * time.Millisecond},
+			min:     50 * time.Millisecond,
+			max:     50 * time.Millisecond,
+		},
+		{
+			name: "uniform stays within its bounds",
+			latency: simulator.Latency{Distribution: simulator.DistributionUniform, Min: 10 * time.Millisecond,
+				Max: 20 * time.Millisecond},
+			min: 10 * time.Millisecond,
+			max: 20 * time.Millisecond,
+		},
+		{
+			name: "normal is clamped to min and max",
+			latency: simulator.Latency{Distribution: simulator.DistributionNormal, Mean: 100 * time.Millisecond,
+				StdDev: time.Second, Min: 50 * time.Millisecond, Max: 150 * time.Millisecond},
+			min: 50 * time.Millisecond,
+			max: 150 * time.Millisecond,
+		},
+		{
+			name: "exponential starts at min",
+			latency: simulator.Latency{Distribution: simulator.DistributionExponential, Min: 5 * time.Millisecond,
+				Mean: 10 * time.Millisecond, Max: time.Second},
+			min: 5 * time.Millisecond,
+			max: time.Second,
+		},
+		{
+			name:    "unset is immediate",
+			latency: simulator.Latency{},
+			min:     0,
+			max:     0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := simulator.New(simulator.Config{Seed: 1, Latency: tt.latency})
+
+			for range 200 {
+				latency := sim.Latency()
+				assert.GreaterOrEqual(t, latency, tt.min)
+				assert.LessOrEqual(t, latency, tt.max)
+			}
+		})
+	}
+}
+
+func TestReceipt(t *testing.T) {
+	sim := simulator.New(simulator.Config{})
+	now := time.Now()
+
+	t.Run("reports accepted messages", func(t *testing.T) {
+		receipt, ok := sim.Receipt("sim-1", simulator.OutcomeUndelivered, now)
+
+		assert.True(t, ok)
+		assert.Equal(t, simulator.Receipt{ProviderMsgID: "sim-1", Status: "UNDELIV", ErrorCode: "001",
+			ReportedAt: now}, receipt)
+	})
+
+	t.Run("has no receipt for rejected messages", func(t *testing.T) {
+		_, ok := sim.Receipt("sim-1", simulator.OutcomeServerError, now)
+
+		assert.False(t, ok)
+	})
+}
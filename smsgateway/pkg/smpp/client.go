@@ -0,0 +1,424 @@
+package smpp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/segmentation"
+)
+
+const (
+	defaultWindowSize          = 10
+	defaultEnquireLinkInterval = 30 * time.Second
+	defaultResponseTimeout     = 10 * time.Second
+	defaultReconnectDelay      = 5 * time.Second
+)
+
+var (
+	ErrNotBound        = errors.New("smpp: not bound")
+	ErrConnectionLost  = errors.New("smpp: connection lost")
+	ErrResponseTimeout = errors.New("smpp: response timeout")
+	ErrClosed          = errors.New("smpp: client closed")
+)
+
+type Config struct {
+	Address             string        `mapstructure:"address"`
+	SystemID            string        `mapstructure:"system_id"`
+	Password            string        `mapstructure:"password"`
+	SystemType          string        `mapstructure:"system_type"`
+	TLS                 bool          `mapstructure:"tls"`
+	InsecureSkipVerify  bool          `mapstructure:"insecure_skip_verify"`
+	SourceTON           byte          `mapstructure:"source_ton"`
+	SourceNPI           byte          `mapstructure:"source_npi"`
+	DestTON             byte          `mapstructure:"dest_ton"`
+	DestNPI             byte          `mapstructure:"dest_npi"`
+	RegisteredDelivery  bool          `mapstructure:"registered_delivery"`
+	WindowSize          int           `mapstructure:"window_size"`
+	EnquireLinkInterval time.Duration `mapstructure:"enquire_link_interval"`
+	ResponseTimeout     time.Duration `mapstructure:"response_timeout"`
+	ReconnectDelay      time.Duration `mapstructure:"reconnect_delay"`
+}
+
+// Handlers receive what the SMSC sends on its own. Both are optional and are called from the read loop, so they
+// should hand work off rather than block.
+type Handlers struct {
+	// OnReceipt reports whether it took the receipt. A refused receipt is answered with a temporary error so the SMSC
+	// delivers it again later.
+	OnReceipt func(Receipt) bool
+	// OnBind is called after every bind attempt and when a bound session ends, with the error that ended it.
+	OnBind func(bound bool, err error)
+}
+
+// Client keeps one transceiver session to an SMSC. It binds in the background, answers enquire_link, sends its own
+// to detect dead links, and rebinds after ReconnectDelay whenever the session ends. Up to WindowSize submit_sm may
+// await their responses at once; further submits wait for a free slot.
+type Client struct {
+	cfg      Config
+	handlers Handlers
+	window   chan struct{}
+	sequence atomic.Uint32
+	refs     atomic.Uint32
+
+	mu      sync.Mutex
+	conn    net.Conn
+	pending map[uint32]chan PDU
+
+	writeMu sync.Mutex
+
+	started   atomic.Bool
+	done      chan struct{}
+	closeOnce sync.Once
+	stopped   chan struct{}
+}
+
+func NewClient(cfg Config, handlers Handlers) *Client {
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = defaultWindowSize
+	}
+	if cfg.EnquireLinkInterval <= 0 {
+		cfg.EnquireLinkInterval = defaultEnquireLinkInterval
+	}
+	if cfg.ResponseTimeout <= 0 {
+		cfg.ResponseTimeout = defaultResponseTimeout
+	}
+	if cfg.ReconnectDelay <= 0 {
+		cfg.ReconnectDelay = defaultReconnectDelay
+	}
+
+	return &Client{cfg: cfg, handlers: handlers, window: make(chan struct{}, cfg.WindowSize),
+		pending: make(map[uint32]chan PDU), done: make(chan struct{}), stopped: make(chan struct{})}
+}
+
+// Start binds in the background and keeps the session up until Close.
+func (c *Client) Start() {
+	if c.started.CompareAndSwap(false, true) {
+		go c.run()
+	}
+}
+
+// Close unbinds, drops the connection and stops reconnecting.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg.ResponseTimeout)
+		defer cancel()
+		_, _ = c.request(ctx, Unbind, nil)
+
+		c.mu.Lock()
+		if c.conn != nil {
+			_ = c.conn.Close()
+		}
+		c.mu.Unlock()
+	})
+
+	if c.started.Load() {
+		<-c.stopped
+	}
+	return nil
+}
+
+// Bound reports whether a session is currently up.
+func (c *Client) Bound() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Submit sends text as one submit_sm, or as several carrying a concatenation UDH when it needs more than one
+// segment, and returns the SMSC message id of every segment. Segments are sent in order; if one fails the earlier
+// ones have already been accepted.
+func (c *Client) Submit(ctx context.Context, from, to, text string) ([]string, error) {
+	encoding, segments := segmentation.Split(text)
+
+	dataCoding := byte(DataCodingDefault)
+	encode := segmentation.EncodeGSM7
+	if encoding == segmentation.EncodingUCS2 {
+		dataCoding = DataCodingUCS2
+		encode = segmentation.EncodeUCS2
+	}
+
+	msg := ShortMessage{
+		SourceTON:  c.cfg.SourceTON,
+		SourceNPI:  c.cfg.SourceNPI,
+		SourceAddr: from,
+		DestTON:    c.cfg.DestTON,
+		DestNPI:    c.cfg.DestNPI,
+		DestAddr:   to,
+		DataCoding: dataCoding,
+	}
+	if c.cfg.RegisteredDelivery {
+		msg.RegisteredDelivery = 1
+	}
+
+	ref := byte(c.refs.Add(1))
+
+	ids := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		msg.Message = encode(segment)
+		if len(segments) > 1 {
+			msg.ESMClass = ESMClassUDHI
+			udh := []byte{0x05, 0x00, 0x03, ref, byte(len(segments)), byte(i + 1)}
+			msg.Message = append(udh, msg.Message...)
+		}
+
+		id, err := c.submit(ctx, msg)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (c *Client) submit(ctx context.Context, msg ShortMessage) (string, error) {
+	select {
+	case c.window <- struct{}{}:
+		defer func() { <-c.window }()
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	resp, err := c.request(ctx, SubmitSM, msg.Marshal())
+	if err != nil {
+		return "", err
+	}
+
+	d := decoder{data: resp.Body}
+	id := d.cstring()
+	if d.err != nil {
+		return "", d.err
+	}
+
+	return id, nil
+}
+
+// request sends a PDU on the current session and waits for the response with the same sequence number.
+func (c *Client) request(ctx context.Context, commandID uint32, body []byte) (PDU, error) {
+	sequence := c.sequence.Add(1)
+	response := make(chan PDU, 1)
+
+	c.mu.Lock()
+	conn := c.conn
+	if conn == nil {
+		c.mu.Unlock()
+		return PDU{}, ErrNotBound
+	}
+	c.pending[sequence] = response
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, sequence)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(conn, PDU{CommandID: commandID, Sequence: sequence, Body: body}); err != nil {
+		_ = conn.Close()
+		return PDU{}, ErrConnectionLost
+	}
+
+	timer := time.NewTimer(c.cfg.ResponseTimeout)
+	defer timer.Stop()
+
+	select {
+	case resp, ok := <-response:
+		if !ok {
+			return PDU{}, ErrConnectionLost
+		}
+		if resp.Status != StatusOK {
+			return resp, StatusError(resp.Status)
+		}
+		return resp, nil
+	case <-timer.C:
+		return PDU{}, ErrResponseTimeout
+	case <-ctx.Done():
+		return PDU{}, ctx.Err()
+	}
+}
+
+func (c *Client) write(conn net.Conn, pdu PDU) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(c.cfg.ResponseTimeout))
+	return WritePDU(conn, pdu)
+}
+
+func (c *Client) run() {
+	defer close(c.stopped)
+
+	for {
+		conn, err := c.bind()
+		c.notifyBind(err == nil, err)
+
+		if err == nil {
+			err = c.serve(conn)
+			c.notifyBind(false, err)
+		}
+
+		select {
+		case <-c.done:
+			return
+		case <-time.After(c.cfg.ReconnectDelay):
+		}
+	}
+}
+
+func (c *Client) bind() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: c.cfg.ResponseTimeout}
+
+	var conn net.Conn
+	var err error
+	if c.cfg.TLS {
+		tlsConfig := &tls.Config{InsecureSkipVerify: c.cfg.InsecureSkipVerify}
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.cfg.Address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", c.cfg.Address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bind := Bind{SystemID: c.cfg.SystemID, Password: c.cfg.Password, SystemType: c.cfg.SystemType}
+	sequence := c.sequence.Add(1)
+	if err := c.write(conn, PDU{CommandID: BindTransceiver, Sequence: sequence, Body: bind.Marshal()}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(c.cfg.ResponseTimeout))
+	resp, err := ReadPDU(conn)
+	_ = conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if resp.CommandID != BindTransceiverResp || resp.Sequence != sequence {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%w: unexpected bind response 0x%08X", ErrInvalidPDU, resp.CommandID)
+	}
+
+	if resp.Status != StatusOK {
+		_ = conn.Close()
+		return nil, StatusError(resp.Status)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.done:
+		_ = conn.Close()
+		return nil, ErrClosed
+	default:
+	}
+
+	c.conn = conn
+	return conn, nil
+}
+
+// serve reads the session until it ends, then fails every request still waiting on it.
+func (c *Client) serve(conn net.Conn) error {
+	stop := make(chan struct{})
+	go c.keepAlive(conn, stop)
+
+	err := c.readLoop(conn)
+
+	close(stop)
+	_ = conn.Close()
+
+	c.mu.Lock()
+	c.conn = nil
+	for sequence, response := range c.pending {
+		close(response)
+		delete(c.pending, sequence)
+	}
+	c.mu.Unlock()
+
+	return err
+}
+
+func (c *Client) readLoop(conn net.Conn) error {
+	for {
+		pdu, err := ReadPDU(conn)
+		if err != nil {
+			return err
+		}
+
+		if pdu.IsResponse() {
+			c.mu.Lock()
+			response, ok := c.pending[pdu.Sequence]
+			if ok {
+				delete(c.pending, pdu.Sequence)
+			}
+			c.mu.Unlock()
+
+			if ok {
+				response <- pdu
+			}
+			continue
+		}
+
+		switch pdu.CommandID {
+		case DeliverSM:
+			c.handleDeliver(conn, pdu)
+		case EnquireLink:
+			_ = c.write(conn, PDU{CommandID: EnquireLinkResp, Sequence: pdu.Sequence})
+		case Unbind:
+			_ = c.write(conn, PDU{CommandID: UnbindResp, Sequence: pdu.Sequence})
+			return ErrNotBound
+		default:
+			_ = c.write(conn, PDU{CommandID: GenericNack, Status: StatusInvalidCmdID, Sequence: pdu.Sequence})
+		}
+	}
+}
+
+func (c *Client) handleDeliver(conn net.Conn, pdu PDU) {
+	msg, err := UnmarshalShortMessage(pdu.Body)
+	if err != nil {
+		_ = c.write(conn, PDU{CommandID: DeliverSMResp, Status: StatusInvalidMsgLen, Sequence: pdu.Sequence})
+		return
+	}
+
+	status := StatusOK
+	if receipt, ok := ParseReceipt(msg); ok && c.handlers.OnReceipt != nil && !c.handlers.OnReceipt(receipt) {
+		status = StatusTempAppError
+	}
+
+	// deliver_sm_resp carries an empty message_id.
+	_ = c.write(conn, PDU{CommandID: DeliverSMResp, Status: status, Sequence: pdu.Sequence, Body: []byte{0}})
+}
+
+// keepAlive sends enquire_link every interval and drops the connection when one goes unanswered, which ends the
+// read loop and triggers a rebind.
+func (c *Client) keepAlive(conn net.Conn, stop chan struct{}) {
+	ticker := time.NewTicker(c.cfg.EnquireLinkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := c.request(context.Background(), EnquireLink, nil); err != nil {
+				_ = conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) notifyBind(bound bool, err error) {
+	if c.handlers.OnBind != nil {
+		c.handlers.OnBind(bound, err)
+	}
+}
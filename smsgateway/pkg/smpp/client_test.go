@@ -0,0 +1,372 @@
+package smpp_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/smpp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smsc is an in-process SMPP server stub. It accepts transceiver binds for system id "esme", acknowledges every
+// submit_sm with a sequential message id and rejects the destination "000". The destination "001" is accepted only
+// once, so every segment after the first is rejected.
+type smsc struct {
+	listener net.Listener
+
+	mu           sync.Mutex
+	conn         net.Conn
+	binds        int
+	enquireLinks int
+	submitted    []smpp.ShortMessage
+	delivered    chan smpp.PDU
+}
+
+func newSMSC(t *testing.T, tlsConfig *tls.Config) *smsc {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	s := &smsc{listener: listener, delivered: make(chan smpp.PDU, 10)}
+	go s.accept()
+	t.Cleanup(func() { _ = listener.Close(); s.drop() })
+
+	return s
+}
+
+func (s *smsc) address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *smsc) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conn = conn
+		s.mu.Unlock()
+
+		go s.serve(conn)
+	}
+}
+
+func (s *smsc) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		pdu, err := smpp.ReadPDU(conn)
+		if err != nil {
+			return
+		}
+
+		resp := smpp.PDU{CommandID: pdu.CommandID | smpp.GenericNack, Sequence: pdu.Sequence}
+
+		s.mu.Lock()
+		switch pdu.CommandID {
+		case smpp.BindTransceiver:
+			s.binds++
+			bind, _ := smpp.UnmarshalBind(pdu.Body)
+			if bind.SystemID != "esme" {
+				resp.Status = smpp.StatusInvalidSystemID
+			}
+			resp.Body = []byte("smsc\x00")
+		case smpp.SubmitSM:
+			msg, _ := smpp.UnmarshalShortMessage(pdu.Body)
+			if msg.DestAddr == "000" || msg.DestAddr == "001" && s.accepted("001") {
+				resp.Status = smpp.StatusInvalidDstAddr
+			} else {
+				s.submitted = append(s.submitted, msg)
+				resp.Body = []byte(fmt.Sprintf("msg-%d\x00", len(s.submitted)))
+			}
+		case smpp.EnquireLink:
+			s.enquireLinks++
+		case smpp.DeliverSMResp:
+			s.delivered <- pdu
+			s.mu.Unlock()
+			continue
+		}
+		s.mu.Unlock()
+
+		_ = smpp.WritePDU(conn, resp)
+
+		if pdu.CommandID == smpp.Unbind {
+			return
+		}
+	}
+}
+
+// accepted reports whether a submit to dest was already accepted; the caller holds mu.
+func (s *smsc) accepted(dest string) bool {
+	for _, msg := range s.submitted {
+		if msg.DestAddr == dest {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *smsc) deliver(msg smpp.ShortMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return smpp.WritePDU(s.conn, smpp.PDU{CommandID: smpp.DeliverSM, Sequence: 1, Body: msg.Marshal()})
+}
+
+func (s *smsc) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+}
+
+func (s *smsc) stats() (binds, enquireLinks int, submitted []smpp.ShortMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.binds, s.enquireLinks, append([]smpp.ShortMessage(nil), s.submitted...)
+}
+
+func startClient(t *testing.T, cfg smpp.Config, handlers smpp.Handlers) *smpp.Client {
+	if cfg.SystemID == "" {
+		cfg.SystemID = "esme"
+	}
+	if cfg.ReconnectDelay == 0 {
+		cfg.ReconnectDelay = 10 * time.Millisecond
+	}
+	cfg.ResponseTimeout = time.Second
+
+	client := smpp.NewClient(cfg, handlers)
+	client.Start()
+	t.Cleanup(func() { _ = client.Close() })
+
+	require.Eventually(t, client.Bound, time.Second, 5*time.Millisecond)
+	return client
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("submits a single segment message", func(t *testing.T) {
+		server := newSMSC(t, nil)
+		client := startClient(t, smpp.Config{Address: server.address(), RegisteredDelivery: true}, smpp.Handlers{})
+
+		ids, err := client.Submit(ctx, "1000", "989121234567", "hello")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"msg-1"}, ids)
+
+		_, _, submitted := server.stats()
+		require.Len(t, submitted, 1)
+		assert.Equal(t, "1000", submitted[0].SourceAddr)
+		assert.Equal(t, "989121234567", submitted[0].DestAddr)
+		assert.Equal(t, []byte("hello"), submitted[0].Message)
+		assert.Equal(t, byte(smpp.DataCodingDefault), submitted[0].DataCoding)
+		assert.Equal(t, byte(1), submitted[0].RegisteredDelivery)
+		assert.Zero(t, submitted[0].ESMClass)
+	})
+
+	t.Run("splits long messages with a concatenation header", func(t *testing.T) {
+		server := newSMSC(t, nil)
+		client := startClient(t, smpp.Config{Address: server.address()}, smpp.Handlers{})
+
+		ids, err := client.Submit(ctx, "1000", "989121234567", strings.Repeat("سلام ", 30))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"msg-1", "msg-2", "msg-3"}, ids)
+
+		_, _, submitted := server.stats()
+		require.Len(t, submitted, 3)
+
+		ref := submitted[0].Message[3]
+		for i, msg := range submitted {
+			assert.Equal(t, byte(smpp.ESMClassUDHI), msg.ESMClass)
+			assert.Equal(t, byte(smpp.DataCodingUCS2), msg.DataCoding)
+			assert.Equal(t, []byte{0x05, 0x00, 0x03, ref, 3, byte(i + 1)}, msg.Message[:6])
+			assert.LessOrEqual(t, len(msg.Message), 140)
+		}
+	})
+
+	t.Run("returns the command status of a rejected submit", func(t *testing.T) {
+		server := newSMSC(t, nil)
+		client := startClient(t, smpp.Config{Address: server.address()}, smpp.Handlers{})
+
+		_, err := client.Submit(ctx, "1000", "000", "hello")
+
+		assert.ErrorIs(t, err, smpp.StatusError(smpp.StatusInvalidDstAddr))
+	})
+
+	t.Run("returns the ids of segments accepted before a rejected one", func(t *testing.T) {
+		server := newSMSC(t, nil)
+		client := startClient(t, smpp.Config{Address: server.address()}, smpp.Handlers{})
+
+		ids, err := client.Submit(ctx, "1000", "001", strings.Repeat("a", 200))
+
+		assert.ErrorIs(t, err, smpp.StatusError(smpp.StatusInvalidDstAddr))
+		assert.Equal(t, []string{"msg-1"}, ids)
+	})
+
+	t.Run("passes delivery receipts to the handler", func(t *testing.T) {
+		server := newSMSC(t, nil)
+		receipts := make(chan smpp.Receipt, 1)
+		startClient(t, smpp.Config{Address: server.address()}, smpp.Handlers{
+			OnReceipt: func(receipt smpp.Receipt) bool {
+				receipts <- receipt
+				return true
+			},
+		})
+
+		require.NoError(t, server.deliver(smpp.ShortMessage{
+			ESMClass: smpp.ESMClassReceipt,
+			Message:  []byte("id:msg-7 sub:001 dlvrd:001 submit date:2501021500 done date:2501021501 stat:UNDELIV err:034"),
+		}))
+
+		select {
+		case receipt := <-receipts:
+			assert.Equal(t, "msg-7", receipt.MessageID)
+			assert.Equal(t, "UNDELIV", receipt.Status)
+			assert.Equal(t, "034", receipt.Error)
+			require.NotNil(t, receipt.DoneAt)
+			assert.Equal(t, time.Date(2025, 1, 2, 15, 1, 0, 0, time.UTC), *receipt.DoneAt)
+		case <-time.After(time.Second):
+			t.Fatal("receipt not delivered")
+		}
+
+		select {
+		case resp := <-server.delivered:
+			assert.Equal(t, smpp.StatusOK, resp.Status)
+		case <-time.After(time.Second):
+			t.Fatal("deliver_sm not acknowledged")
+		}
+	})
+
+	t.Run("asks for redelivery of a receipt the handler refuses", func(t *testing.T) {
+		server := newSMSC(t, nil)
+		startClient(t, smpp.Config{Address: server.address()}, smpp.Handlers{
+			OnReceipt: func(smpp.Receipt) bool { return false },
+		})
+
+		require.NoError(t, server.deliver(smpp.ShortMessage{
+			ESMClass: smpp.ESMClassReceipt,
+			Message:  []byte("id:msg-7 sub:001 dlvrd:001 submit date:2501021500 done date:2501021501 stat:DELIVRD err:000"),
+		}))
+
+		select {
+		case resp := <-server.delivered:
+			assert.Equal(t, smpp.StatusTempAppError, resp.Status)
+		case <-time.After(time.Second):
+			t.Fatal("deliver_sm not acknowledged")
+		}
+	})
+
+	t.Run("sends enquire_link while idle", func(t *testing.T) {
+		server := newSMSC(t, nil)
+		startClient(t, smpp.Config{Address: server.address(), EnquireLinkInterval: 10 * time.Millisecond},
+			smpp.Handlers{})
+
+		assert.Eventually(t, func() bool {
+			_, enquireLinks, _ := server.stats()
+			return enquireLinks >= 2
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("rebinds after the connection drops", func(t *testing.T) {
+		server := newSMSC(t, nil)
+		var mu sync.Mutex
+		var events []bool
+		client := startClient(t, smpp.Config{Address: server.address()}, smpp.Handlers{
+			OnBind: func(bound bool, err error) {
+				mu.Lock()
+				events = append(events, bound)
+				mu.Unlock()
+			},
+		})
+
+		server.drop()
+
+		require.Eventually(t, func() bool {
+			binds, _, _ := server.stats()
+			return binds == 2 && client.Bound()
+		}, time.Second, 5*time.Millisecond)
+
+		ids, err := client.Submit(ctx, "1000", "989121234567", "hello")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"msg-1"}, ids)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, []bool{true, false, true}, events)
+	})
+
+	t.Run("reports a refused bind and is not bound", func(t *testing.T) {
+		server := newSMSC(t, nil)
+		bindErrors := make(chan error, 10)
+		client := smpp.NewClient(smpp.Config{Address: server.address(), SystemID: "intruder",
+			ReconnectDelay: time.Hour}, smpp.Handlers{OnBind: func(bound bool, err error) { bindErrors <- err }})
+		client.Start()
+		t.Cleanup(func() { _ = client.Close() })
+
+		select {
+		case err := <-bindErrors:
+			assert.ErrorIs(t, err, smpp.StatusError(smpp.StatusInvalidSystemID))
+		case <-time.After(time.Second):
+			t.Fatal("bind not attempted")
+		}
+
+		_, err := client.Submit(ctx, "1000", "989121234567", "hello")
+		assert.ErrorIs(t, err, smpp.ErrNotBound)
+	})
+
+	t.Run("binds over TLS", func(t *testing.T) {
+		server := newSMSC(t, selfSignedTLS(t))
+		client := startClient(t, smpp.Config{Address: server.address(), TLS: true, InsecureSkipVerify: true},
+			smpp.Handlers{})
+
+		ids, err := client.Submit(ctx, "1000", "989121234567", "hello")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"msg-1"}, ids)
+	})
+
+	t.Run("closes without having started", func(t *testing.T) {
+		client := smpp.NewClient(smpp.Config{Address: "127.0.0.1:1"}, smpp.Handlers{})
+
+		assert.NoError(t, client.Close())
+	})
+}
+
+func selfSignedTLS(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smsc"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
@@ -0,0 +1,182 @@
+package smpp
+
+import (
+	"strings"
+	"time"
+)
+
+// Bind is the body of a bind_transceiver request.
+type Bind struct {
+	SystemID     string
+	Password     string
+	SystemType   string
+	AddrTON      byte
+	AddrNPI      byte
+	AddressRange string
+}
+
+func (b Bind) Marshal() []byte {
+	var e encoder
+	e.cstring(b.SystemID)
+	e.cstring(b.Password)
+	e.cstring(b.SystemType)
+	e.octet(InterfaceVersion)
+	e.octet(b.AddrTON)
+	e.octet(b.AddrNPI)
+	e.cstring(b.AddressRange)
+	return e.buf.Bytes()
+}
+
+func UnmarshalBind(body []byte) (Bind, error) {
+	d := decoder{data: body}
+	b := Bind{SystemID: d.cstring(), Password: d.cstring(), SystemType: d.cstring()}
+	d.octet()
+	b.AddrTON = d.octet()
+	b.AddrNPI = d.octet()
+	b.AddressRange = d.cstring()
+	return b, d.err
+}
+
+// ShortMessage is the body shared by submit_sm and deliver_sm.
+type ShortMessage struct {
+	ServiceType          string
+	SourceTON            byte
+	SourceNPI            byte
+	SourceAddr           string
+	DestTON              byte
+	DestNPI              byte
+	DestAddr             string
+	ESMClass             byte
+	ProtocolID           byte
+	PriorityFlag         byte
+	ScheduleDeliveryTime string
+	ValidityPeriod       string
+	RegisteredDelivery   byte
+	ReplaceIfPresent     byte
+	DataCoding           byte
+	DefaultMsgID         byte
+	Message              []byte
+	TLVs                 map[uint16][]byte
+}
+
+func (m ShortMessage) Marshal() []byte {
+	var e encoder
+	e.cstring(m.ServiceType)
+	e.octet(m.SourceTON)
+	e.octet(m.SourceNPI)
+	e.cstring(m.SourceAddr)
+	e.octet(m.DestTON)
+	e.octet(m.DestNPI)
+	e.cstring(m.DestAddr)
+	e.octet(m.ESMClass)
+	e.octet(m.ProtocolID)
+	e.octet(m.PriorityFlag)
+	e.cstring(m.ScheduleDeliveryTime)
+	e.cstring(m.ValidityPeriod)
+	e.octet(m.RegisteredDelivery)
+	e.octet(m.ReplaceIfPresent)
+	e.octet(m.DataCoding)
+	e.octet(m.DefaultMsgID)
+	e.octet(byte(len(m.Message)))
+	e.octets(m.Message)
+	for tag, value := range m.TLVs {
+		e.tlv(tag, value)
+	}
+	return e.buf.Bytes()
+}
+
+func UnmarshalShortMessage(body []byte) (ShortMessage, error) {
+	d := decoder{data: body}
+	m := ShortMessage{
+		ServiceType:          d.cstring(),
+		SourceTON:            d.octet(),
+		SourceNPI:            d.octet(),
+		SourceAddr:           d.cstring(),
+		DestTON:              d.octet(),
+		DestNPI:              d.octet(),
+		DestAddr:             d.cstring(),
+		ESMClass:             d.octet(),
+		ProtocolID:           d.octet(),
+		PriorityFlag:         d.octet(),
+		ScheduleDeliveryTime: d.cstring(),
+		ValidityPeriod:       d.cstring(),
+		RegisteredDelivery:   d.octet(),
+		ReplaceIfPresent:     d.octet(),
+		DataCoding:           d.octet(),
+		DefaultMsgID:         d.octet(),
+	}
+	m.Message = d.octets(int(d.octet()))
+	m.TLVs = d.tlvs()
+	return m, d.err
+}
+
+// messageStates maps the message_state parameter to the stat values of the receipt text.
+var messageStates = map[byte]string{
+	1: "ENROUTE",
+	2: "DELIVRD",
+	3: "EXPIRED",
+	4: "DELETED",
+	5: "UNDELIV",
+	6: "ACCEPTD",
+	7: "UNKNOWN",
+	8: "REJECTD",
+}
+
+// Receipt is a delivery receipt carried by deliver_sm.
+type Receipt struct {
+	MessageID string
+	Status    string
+	Error     string
+	DoneAt    *time.Time
+}
+
+// ParseReceipt reads the receipt from a deliver_sm. The receipted_message_id and message_state parameters win over
+// the "id:... stat:... err:..." text most SMSCs also send. It reports false for mobile-originated messages.
+func ParseReceipt(m ShortMessage) (Receipt, bool) {
+	if m.ESMClass&ESMClassReceipt == 0 {
+		return Receipt{}, false
+	}
+
+	text := string(m.Message)
+	receipt := Receipt{
+		MessageID: receiptField(text, "id:"),
+		Status:    receiptField(text, "stat:"),
+		Error:     receiptField(text, "err:"),
+	}
+
+	if done := receiptField(text, "done date:"); done != "" {
+		for _, layout := range []string{"0601021504", "060102150405"} {
+			if doneAt, err := time.Parse(layout, done); err == nil {
+				receipt.DoneAt = &doneAt
+				break
+			}
+		}
+	}
+
+	if id, ok := m.TLVs[TagReceiptedMessageID]; ok {
+		receipt.MessageID = strings.TrimRight(string(id), "\x00")
+	}
+
+	if state, ok := m.TLVs[TagMessageState]; ok && len(state) == 1 {
+		if status, ok := messageStates[state[0]]; ok {
+			receipt.Status = status
+		}
+	}
+
+	return receipt, receipt.MessageID != ""
+}
+
+// receiptField returns the value after key, up to the next space.
+func receiptField(text, key string) string {
+	start := strings.Index(text, key)
+	if start < 0 {
+		return ""
+	}
+
+	value := text[start+len(key):]
+	if end := strings.IndexByte(value, ' '); end >= 0 {
+		value = value[:end]
+	}
+
+	return value
+}
@@ -0,0 +1,207 @@
+// Package smpp implements the parts of SMPP 3.4 an ESME needs to submit messages over a transceiver bind and
+// receive their delivery receipts.
+package smpp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	GenericNack         uint32 = 0x80000000
+	BindTransceiver     uint32 = 0x00000009
+	BindTransceiverResp uint32 = 0x80000009
+	Unbind              uint32 = 0x00000006
+	UnbindResp          uint32 = 0x80000006
+	SubmitSM            uint32 = 0x00000004
+	SubmitSMResp        uint32 = 0x80000004
+	DeliverSM           uint32 = 0x00000005
+	DeliverSMResp       uint32 = 0x80000005
+	EnquireLink         uint32 = 0x00000015
+	EnquireLinkResp     uint32 = 0x80000015
+)
+
+// Command statuses the client reacts to. Any other non-zero status is returned as a StatusError as well.
+const (
+	StatusOK              uint32 = 0x00000000
+	StatusInvalidMsgLen   uint32 = 0x00000001
+	StatusInvalidCmdID    uint32 = 0x00000003
+	StatusSystemError     uint32 = 0x00000008
+	StatusInvalidSrcAddr  uint32 = 0x0000000A
+	StatusInvalidDstAddr  uint32 = 0x0000000B
+	StatusBindFailed      uint32 = 0x0000000D
+	StatusInvalidPassword uint32 = 0x0000000E
+	StatusInvalidSystemID uint32 = 0x0000000F
+	StatusMsgQueueFull    uint32 = 0x00000014
+	StatusSubmitFailed    uint32 = 0x00000045
+	StatusInvalidDstTON   uint32 = 0x00000050
+	StatusInvalidDstNPI   uint32 = 0x00000051
+	StatusThrottled       uint32 = 0x00000058
+	StatusTempAppError    uint32 = 0x00000064
+)
+
+const (
+	InterfaceVersion = 0x34
+
+	DataCodingDefault = 0x00
+	DataCodingUCS2    = 0x08
+
+	ESMClassReceipt = 0x04
+	ESMClassUDHI    = 0x40
+)
+
+// Optional parameter tags used in delivery receipts.
+const (
+	TagReceiptedMessageID uint16 = 0x001E
+	TagMessageState       uint16 = 0x0427
+)
+
+const (
+	headerLen = 16
+	maxPDULen = 64 * 1024
+)
+
+var ErrInvalidPDU = errors.New("smpp: invalid pdu")
+
+// StatusError is a response that carried a non-zero command_status.
+type StatusError uint32
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("smpp: command status 0x%08X", uint32(e))
+}
+
+type PDU struct {
+	CommandID uint32
+	Status    uint32
+	Sequence  uint32
+	Body      []byte
+}
+
+func (p PDU) IsResponse() bool {
+	return p.CommandID&GenericNack != 0
+}
+
+func ReadPDU(r io.Reader) (PDU, error) {
+	var header [headerLen]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return PDU{}, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length < headerLen || length > maxPDULen {
+		return PDU{}, fmt.Errorf("%w: command_length %d", ErrInvalidPDU, length)
+	}
+
+	pdu := PDU{
+		CommandID: binary.BigEndian.Uint32(header[4:8]),
+		Status:    binary.BigEndian.Uint32(header[8:12]),
+		Sequence:  binary.BigEndian.Uint32(header[12:16]),
+		Body:      make([]byte, length-headerLen),
+	}
+
+	if _, err := io.ReadFull(r, pdu.Body); err != nil {
+		return PDU{}, err
+	}
+
+	return pdu, nil
+}
+
+// WritePDU writes the whole PDU in one call so concurrent writers serialised by the caller never interleave.
+func WritePDU(w io.Writer, pdu PDU) error {
+	buf := make([]byte, headerLen+len(pdu.Body))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(buf)))
+	binary.BigEndian.PutUint32(buf[4:8], pdu.CommandID)
+	binary.BigEndian.PutUint32(buf[8:12], pdu.Status)
+	binary.BigEndian.PutUint32(buf[12:16], pdu.Sequence)
+	copy(buf[headerLen:], pdu.Body)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) cstring(s string) {
+	e.buf.WriteString(s)
+	e.buf.WriteByte(0)
+}
+
+func (e *encoder) octet(b byte) {
+	e.buf.WriteByte(b)
+}
+
+func (e *encoder) octets(b []byte) {
+	e.buf.Write(b)
+}
+
+func (e *encoder) tlv(tag uint16, value []byte) {
+	var header [4]byte
+	binary.BigEndian.PutUint16(header[0:2], tag)
+	binary.BigEndian.PutUint16(header[2:4], uint16(len(value)))
+	e.buf.Write(header[:])
+	e.buf.Write(value)
+}
+
+// decoder reads fields in order and remembers the first error, so callers check it once at the end.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) cstring() string {
+	if d.err != nil {
+		return ""
+	}
+
+	end := bytes.IndexByte(d.data, 0)
+	if end < 0 {
+		d.err = fmt.Errorf("%w: unterminated c-octet string", ErrInvalidPDU)
+		return ""
+	}
+
+	value := string(d.data[:end])
+	d.data = d.data[end+1:]
+	return value
+}
+
+func (d *decoder) octet() byte {
+	value := d.octets(1)
+	if value == nil {
+		return 0
+	}
+	return value[0]
+}
+
+func (d *decoder) octets(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if len(d.data) < n {
+		d.err = fmt.Errorf("%w: body too short", ErrInvalidPDU)
+		return nil
+	}
+
+	value := d.data[:n]
+	d.data = d.data[n:]
+	return value
+}
+
+func (d *decoder) tlvs() map[uint16][]byte {
+	tlvs := make(map[uint16][]byte)
+	for d.err == nil && len(d.data) >= 4 {
+		header := d.octets(4)
+		tag := binary.BigEndian.Uint16(header[0:2])
+		value := d.octets(int(binary.BigEndian.Uint16(header[2:4])))
+		if d.err == nil {
+			tlvs[tag] = value
+		}
+	}
+
+	return tlvs
+}
@@ -0,0 +1,161 @@
+package smsprovider
+
+import (
+	"sync"
+	"time"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+	defaultHalfOpenMaxCalls = 1
+)
+
+// BreakerConfig tunes the circuit breaker kept for every provider. Zero values fall back to the defaults above.
+type BreakerConfig struct {
+	FailureThreshold int           `mapstructure:"failure_threshold"`
+	OpenTimeout      time.Duration `mapstructure:"open_timeout"`
+	HalfOpenMaxCalls int           `mapstructure:"half_open_max_calls"`
+}
+
+// BreakerStatus is a point-in-time view of one breaker.
+type BreakerStatus struct {
+	Provider            string       `json:"provider"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	LastError           string       `json:"last_error,omitempty"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAt             *time.Time   `json:"retry_at,omitempty"`
+}
+
+// Breaker stops calls to a provider after FailureThreshold consecutive provider-side failures. After OpenTimeout it
+// lets up to HalfOpenMaxCalls probes through: a successful probe closes the circuit, a failed one opens it again.
+// Only the codes IsRetryable accepts count as failures; a permanent rejection means the provider answered and counts
+// as a success, and any other error, such as ErrorCodeCanceled for a cancelled context, is not counted at all.
+type Breaker struct {
+	mu       sync.Mutex
+	name     string
+	cfg      BreakerConfig
+	onChange func(name string, from, to BreakerState, err error)
+	now      func() time.Time
+
+	state     BreakerState
+	failures  int
+	probes    int
+	lastError string
+	openedAt  time.Time
+}
+
+// NewBreaker returns a closed breaker. onChange, when set, is called on every state transition with the error that
+// caused it, if any; it runs under the breaker's lock and must not call back into the breaker.
+func NewBreaker(name string, cfg BreakerConfig, onChange func(name string, from, to BreakerState, err error)) *Breaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultFailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaultOpenTimeout
+	}
+	if cfg.HalfOpenMaxCalls <= 0 {
+		cfg.HalfOpenMaxCalls = defaultHalfOpenMaxCalls
+	}
+
+	return &Breaker{name: name, cfg: cfg, onChange: onChange, now: time.Now, state: BreakerClosed}
+}
+
+// Allow reports whether a call may be made now. Every allowed call must be followed by Record.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Before(b.openedAt.Add(b.cfg.OpenTimeout)) {
+			return false
+		}
+		b.transition(BreakerHalfOpen, nil)
+		b.probes = 1
+		return true
+	case BreakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenMaxCalls {
+			return false
+		}
+		b.probes++
+		return true
+	default:
+		return true
+	}
+}
+
+// Record feeds the outcome of an allowed call into the breaker.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+
+	switch {
+	case err == nil || IsPermanent(err):
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.transition(BreakerClosed, nil)
+		}
+	case IsRetryable(err):
+		b.failures++
+		b.lastError = err.Error()
+		if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.cfg.FailureThreshold) {
+			b.openedAt = b.now()
+			b.transition(BreakerOpen, err)
+		}
+	}
+}
+
+// RetryIn returns how long until an open breaker lets a probe through, or zero when calls are allowed now.
+func (b *Breaker) RetryIn() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerOpen {
+		return 0
+	}
+
+	return max(b.openedAt.Add(b.cfg.OpenTimeout).Sub(b.now()), 0)
+}
+
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{Provider: b.name, State: b.state, ConsecutiveFailures: b.failures,
+		LastError: b.lastError}
+
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.cfg.OpenTimeout)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+
+	return status
+}
+
+func (b *Breaker) transition(to BreakerState, err error) {
+	from := b.state
+	b.state = to
+	if to == BreakerClosed {
+		b.probes = 0
+	}
+
+	if b.onChange != nil {
+		b.onChange(b.name, from, to, err)
+	}
+}
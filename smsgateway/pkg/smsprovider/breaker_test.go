@@ -0,0 +1,77 @@
+package smsprovider_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	cfg := smsprovider.BreakerConfig{FailureThreshold: 2, OpenTimeout: 20 * time.Millisecond, HalfOpenMaxCalls: 1}
+	serverError := errors.New(smsprovider.ErrorCodeServerError)
+
+	t.Run("opens after consecutive provider failures", func(t *testing.T) {
+		var transitions []smsprovider.BreakerState
+		breaker := smsprovider.NewBreaker("primary", cfg, func(name string, from, to smsprovider.BreakerState,
+			err error) {
+			transitions = append(transitions, to)
+		})
+
+		breaker.Record(serverError)
+		assert.True(t, breaker.Allow())
+		breaker.Record(serverError)
+
+		assert.False(t, breaker.Allow())
+		assert.Equal(t, smsprovider.BreakerOpen, breaker.Status().State)
+		assert.Greater(t, breaker.RetryIn(), time.Duration(0))
+		assert.Equal(t, []smsprovider.BreakerState{smsprovider.BreakerOpen}, transitions)
+	})
+
+	t.Run("does not count invalid numbers or cancelled calls", func(t *testing.T) {
+		breaker := smsprovider.NewBreaker("primary", cfg, nil)
+
+		breaker.Record(serverError)
+		breaker.Record(errors.New(smsprovider.ErrorCodeInvalidNumber))
+		breaker.Record(serverError)
+		breaker.Record(context.Canceled)
+		breaker.Record(errors.New(smsprovider.ErrorCodeCanceled))
+
+		assert.Equal(t, smsprovider.BreakerClosed, breaker.Status().State)
+		assert.Equal(t, 1, breaker.Status().ConsecutiveFailures)
+	})
+
+	t.Run("closes after a successful probe", func(t *testing.T) {
+		breaker := smsprovider.NewBreaker("primary", cfg, nil)
+		breaker.Record(serverError)
+		breaker.Record(serverError)
+
+		time.Sleep(cfg.OpenTimeout)
+
+		assert.True(t, breaker.Allow())
+		assert.Equal(t, smsprovider.BreakerHalfOpen, breaker.Status().State)
+		assert.False(t, breaker.Allow())
+
+		breaker.Record(nil)
+
+		assert.Equal(t, smsprovider.BreakerClosed, breaker.Status().State)
+		assert.True(t, breaker.Allow())
+	})
+
+	t.Run("reopens after a failed probe", func(t *testing.T) {
+		breaker := smsprovider.NewBreaker("primary", cfg, nil)
+		breaker.Record(serverError)
+		breaker.Record(serverError)
+
+		time.Sleep(cfg.OpenTimeout)
+
+		assert.True(t, breaker.Allow())
+		breaker.Record(serverError)
+
+		assert.Equal(t, smsprovider.BreakerOpen, breaker.Status().State)
+		assert.False(t, breaker.Allow())
+	})
+}
@@ -0,0 +1,83 @@
+package smsprovider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	CallbackAuthToken = "token"
+	CallbackAuthHMAC  = "hmac"
+)
+
+const (
+	defaultTokenHeader     = "X-Callback-Token"
+	defaultSignatureHeader = "X-Signature"
+)
+
+var ErrInvalidCallbackSignature = errors.New("INVALID_CALLBACK_SIGNATURE")
+
+// CallbackAuth is how a provider proves that a callback to the gateway, such as a delivery receipt or an inbound
+// push, came from it: the shared secret itself in a header (token), or a hex HMAC-SHA256 of the raw body keyed with
+// the secret, optionally prefixed with "sha256=" (hmac). Header defaults to X-Callback-Token or X-Signature.
+type CallbackAuth struct {
+	Type   string `mapstructure:"type"`
+	Header string `mapstructure:"header"`
+	Secret string `mapstructure:"secret"`
+}
+
+// HeaderName returns the header the credential is sent in.
+func (a CallbackAuth) HeaderName() string {
+	if a.Header != "" {
+		return a.Header
+	}
+	if a.Type == CallbackAuthHMAC {
+		return defaultSignatureHeader
+	}
+	return defaultTokenHeader
+}
+
+// Sign returns the header value a provider sends with body.
+func (a CallbackAuth) Sign(body []byte) string {
+	if a.Type != CallbackAuthHMAC {
+		return a.Secret
+	}
+
+	mac := hmac.New(sha256.New, []byte(a.Secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the credential a callback carried in its header against body. Callbacks are refused while no secret
+// is configured, so a provider cannot be left open by mistake.
+func (a CallbackAuth) Verify(credential string, body []byte) error {
+	if a.Secret == "" || credential == "" {
+		return ErrInvalidCallbackSignature
+	}
+
+	switch a.Type {
+	case CallbackAuthHMAC:
+		signature, err := hex.DecodeString(strings.TrimPrefix(credential, "sha256="))
+		if err != nil {
+			return ErrInvalidCallbackSignature
+		}
+
+		mac := hmac.New(sha256.New, []byte(a.Secret))
+		mac.Write(body)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrInvalidCallbackSignature
+		}
+	case CallbackAuthToken, "":
+		if subtle.ConstantTimeCompare([]byte(credential), []byte(a.Secret)) != 1 {
+			return ErrInvalidCallbackSignature
+		}
+	default:
+		return ErrInvalidCallbackSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package smsprovider_test
+
+import (
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallbackAuth(t *testing.T) {
+	body := []byte(`{"provider_msg_id":"abc","status":"REJECTED"}`)
+
+	t.Run("accepts the shared token", func(t *testing.T) {
+		auth := smsprovider.CallbackAuth{Secret: "s3cret"}
+
+		assert.Equal(t, "X-Callback-Token", auth.HeaderName())
+		assert.NoError(t, auth.Verify(auth.Sign(body), body))
+		assert.ErrorIs(t, auth.Verify("wrong", body), smsprovider.ErrInvalidCallbackSignature)
+	})
+
+	t.Run("accepts a body signature", func(t *testing.T) {
+		auth := smsprovider.CallbackAuth{Type: smsprovider.CallbackAuthHMAC, Secret: "s3cret"}
+		signature := auth.Sign(body)
+
+		assert.Equal(t, "X-Signature", auth.HeaderName())
+		assert.NoError(t, auth.Verify(signature, body))
+		assert.NoError(t, auth.Verify("sha256="+signature, body))
+	})
+
+	t.Run("rejects a signature over another body", func(t *testing.T) {
+		auth := smsprovider.CallbackAuth{Type: smsprovider.CallbackAuthHMAC, Secret: "s3cret"}
+		signature := auth.Sign(body)
+
+		err := auth.Verify(signature, []byte(`{"provider_msg_id":"abc","status":"DELIVRD"}`))
+
+		assert.ErrorIs(t, err, smsprovider.ErrInvalidCallbackSignature)
+	})
+
+	t.Run("rejects malformed signatures", func(t *testing.T) {
+		auth := smsprovider.CallbackAuth{Type: smsprovider.CallbackAuthHMAC, Secret: "s3cret"}
+
+		assert.ErrorIs(t, auth.Verify("not-hex", body), smsprovider.ErrInvalidCallbackSignature)
+	})
+
+	t.Run("rejects everything without a secret", func(t *testing.T) {
+		auth := smsprovider.CallbackAuth{}
+
+		assert.ErrorIs(t, auth.Verify("", body), smsprovider.ErrInvalidCallbackSignature)
+		assert.ErrorIs(t, auth.Verify(auth.Sign(body), body), smsprovider.ErrInvalidCallbackSignature)
+	})
+
+	t.Run("uses a configured header", func(t *testing.T) {
+		auth := smsprovider.CallbackAuth{Header: "X-Provider-Key", Secret: "s3cret"}
+
+		assert.Equal(t, "X-Provider-Key", auth.HeaderName())
+	})
+}
@@ -3,6 +3,38 @@ package smsprovider
 const (
 	ErrorCodeServerError   = "SERVER_ERROR"   // For 5xx HTTP status
 	ErrorCodeTimeout       = "TIMEOUT"        // For context timeout
+	ErrorCodeCanceled      = "CANCELED"       // For calls abandoned because the caller cancelled the context
 	ErrorCodeInvalidNumber = "INVALID_NUMBER" // For 400/validation errors
+	ErrorCodeRejected      = "REJECTED"       // For responses mapped to the permanent category
 	ErrorCodeNetworkError  = "NETWORK_ERROR"  // For connection failures
+	ErrorCodeNoRoute       = "NO_ROUTE"       // For destinations no configured provider accepts
+	ErrorCodeCircuitOpen   = "CIRCUIT_OPEN"   // For providers whose circuit breaker is open
 )
+
+// IsRetryable reports whether err is a provider-side failure that another attempt, or another provider, may not hit.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch err.Error() {
+	case ErrorCodeServerError, ErrorCodeTimeout, ErrorCodeNetworkError:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsPermanent reports whether err is a provider answer that no retry or other provider would change.
+func IsPermanent(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch err.Error() {
+	case ErrorCodeInvalidNumber, ErrorCodeRejected:
+		return true
+	default:
+		return false
+	}
+}
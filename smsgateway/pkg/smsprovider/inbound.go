@@ -0,0 +1,206 @@
+package smsprovider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	InboundFormatJSON = "json"
+	InboundFormatForm = "form"
+)
+
+var (
+	ErrInvalidInboundPayload = errors.New("INVALID_INBOUND_PAYLOAD")
+	ErrUnknownInboundFormat  = errors.New("UNKNOWN_INBOUND_FORMAT")
+)
+
+// InboundMessage is a mobile-originated message pushed to the gateway by a provider.
+type InboundMessage struct {
+	ProviderMsgID string
+	From          string
+	To            string
+	Text          string
+	ReceivedAt    *time.Time
+}
+
+// InboundParser turns one provider push into the messages it carries. Each provider payload format gets its own
+// parser, the same way each outbound API gets its own Provider.
+type InboundParser interface {
+	Parse(contentType string, body []byte) ([]InboundMessage, error)
+}
+
+// InboundConfig selects the payload format of one provider and the names of the fields it uses. Auth authenticates
+// the provider's pushes; without a secret every push is refused.
+type InboundConfig struct {
+	Format string        `mapstructure:"format"`
+	Fields InboundFields `mapstructure:"fields"`
+	Auth   CallbackAuth  `mapstructure:"auth"`
+}
+
+type InboundFields struct {
+	MessageID  string `mapstructure:"message_id"`
+	From       string `mapstructure:"from"`
+	To         string `mapstructure:"to"`
+	Text       string `mapstructure:"text"`
+	ReceivedAt string `mapstructure:"received_at"`
+}
+
+// InboundParsers maps provider names to the parser for their payloads.
+type InboundParsers map[string]InboundParser
+
+func NewInboundParsers(providers map[string]InboundConfig) (InboundParsers, error) {
+	parsers := make(InboundParsers, len(providers))
+	for name, cfg := range providers {
+		parser, err := NewInboundParser(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("inbound provider %s: %w", name, err)
+		}
+		parsers[name] = parser
+	}
+
+	return parsers, nil
+}
+
+func NewInboundParser(cfg InboundConfig) (InboundParser, error) {
+	fields := cfg.Fields.withDefaults()
+
+	switch cfg.Format {
+	case InboundFormatJSON, "":
+		return &JSONInboundParser{fields: fields}, nil
+	case InboundFormatForm:
+		return &FormInboundParser{fields: fields}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownInboundFormat, cfg.Format)
+	}
+}
+
+// JSONInboundParser reads a single JSON object or an array of objects.
+type JSONInboundParser struct {
+	fields InboundFields
+}
+
+func (p *JSONInboundParser) Parse(contentType string, body []byte) ([]InboundMessage, error) {
+	body = bytes.TrimSpace(body)
+
+	var objects []map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var err error
+	if len(body) > 0 && body[0] == '[' {
+		err = decoder.Decode(&objects)
+	} else {
+		var object map[string]any
+		err = decoder.Decode(&object)
+		objects = []map[string]any{object}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInboundPayload, err)
+	}
+
+	messages := make([]InboundMessage, 0, len(objects))
+	for _, object := range objects {
+		msg, err := p.fields.message(func(name string) string { return jsonString(object[name]) })
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// FormInboundParser reads one message from a URL-encoded form body, as sent by Kannel-style gateways.
+type FormInboundParser struct {
+	fields InboundFields
+}
+
+func (p *FormInboundParser) Parse(contentType string, body []byte) ([]InboundMessage, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInboundPayload, err)
+	}
+
+	msg, err := p.fields.message(values.Get)
+	if err != nil {
+		return nil, err
+	}
+
+	return []InboundMessage{msg}, nil
+}
+
+func (f InboundFields) withDefaults() InboundFields {
+	defaults := InboundFields{MessageID: "message_id", From: "from", To: "to", Text: "text",
+		ReceivedAt: "received_at"}
+
+	if f.MessageID == "" {
+		f.MessageID = defaults.MessageID
+	}
+	if f.From == "" {
+		f.From = defaults.From
+	}
+	if f.To == "" {
+		f.To = defaults.To
+	}
+	if f.Text == "" {
+		f.Text = defaults.Text
+	}
+	if f.ReceivedAt == "" {
+		f.ReceivedAt = defaults.ReceivedAt
+	}
+
+	return f
+}
+
+func (f InboundFields) message(get func(name string) string) (InboundMessage, error) {
+	msg := InboundMessage{
+		ProviderMsgID: strings.TrimSpace(get(f.MessageID)),
+		From:          strings.TrimSpace(get(f.From)),
+		To:            strings.TrimSpace(get(f.To)),
+		Text:          get(f.Text),
+	}
+
+	if msg.From == "" || msg.To == "" {
+		return InboundMessage{}, fmt.Errorf("%w: %q and %q are required", ErrInvalidInboundPayload, f.From, f.To)
+	}
+
+	if value := strings.TrimSpace(get(f.ReceivedAt)); value != "" {
+		receivedAt, err := parseReceivedAt(value)
+		if err != nil {
+			return InboundMessage{}, fmt.Errorf("%w: %q: %v", ErrInvalidInboundPayload, f.ReceivedAt, err)
+		}
+		msg.ReceivedAt = &receivedAt
+	}
+
+	return msg, nil
+}
+
+// parseReceivedAt accepts RFC3339 timestamps and unix seconds, the two forms providers commonly send.
+func parseReceivedAt(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+func jsonString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,75 @@
+package smsprovider_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONInboundParser_Parse(t *testing.T) {
+	parser, err := smsprovider.NewInboundParser(smsprovider.InboundConfig{Format: smsprovider.InboundFormatJSON})
+	assert.NoError(t, err)
+
+	t.Run("parses a single object", func(t *testing.T) {
+		messages, err := parser.Parse("application/json",
+			[]byte(`{"message_id": 42, "from": "989121111111", "to": "3000", "text": "STOP", "received_at": 1700000000}`))
+
+		assert.NoError(t, err)
+		assert.Len(t, messages, 1)
+		assert.Equal(t, "42", messages[0].ProviderMsgID)
+		assert.Equal(t, "STOP", messages[0].Text)
+		assert.True(t, time.Unix(1700000000, 0).Equal(*messages[0].ReceivedAt))
+	})
+
+	t.Run("parses an array", func(t *testing.T) {
+		messages, err := parser.Parse("application/json",
+			[]byte(`[{"from": "989121111111", "to": "3000", "text": "hi"}, {"from": "989122222222", "to": "3000"}]`))
+
+		assert.NoError(t, err)
+		assert.Len(t, messages, 2)
+		assert.Nil(t, messages[0].ReceivedAt)
+		assert.Equal(t, "989122222222", messages[1].From)
+	})
+
+	t.Run("rejects a message without sender", func(t *testing.T) {
+		_, err := parser.Parse("application/json", []byte(`{"to": "3000", "text": "hi"}`))
+
+		assert.ErrorIs(t, err, smsprovider.ErrInvalidInboundPayload)
+	})
+}
+
+func TestFormInboundParser_Parse(t *testing.T) {
+	parser, err := smsprovider.NewInboundParser(smsprovider.InboundConfig{
+		Format: smsprovider.InboundFormatForm,
+		Fields: smsprovider.InboundFields{From: "sender", To: "receiver", Text: "msg"},
+	})
+	assert.NoError(t, err)
+
+	t.Run("reads the configured field names", func(t *testing.T) {
+		messages, err := parser.Parse("application/x-www-form-urlencoded",
+			[]byte("sender=%2B989121111111&receiver=3000&msg=%D9%84%D8%BA%D9%88&received_at=2024-01-02T03:04:05Z"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []smsprovider.InboundMessage{{
+			From:       "+989121111111",
+			To:         "3000",
+			Text:       "لغو",
+			ReceivedAt: messages[0].ReceivedAt,
+		}}, messages)
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), messages[0].ReceivedAt.UTC())
+	})
+
+	t.Run("rejects an invalid timestamp", func(t *testing.T) {
+		_, err := parser.Parse("", []byte("sender=1&receiver=2&received_at=yesterday"))
+
+		assert.ErrorIs(t, err, smsprovider.ErrInvalidInboundPayload)
+	})
+}
+
+func TestNewInboundParser(t *testing.T) {
+	_, err := smsprovider.NewInboundParser(smsprovider.InboundConfig{Format: "xml"})
+
+	assert.ErrorIs(t, err, smsprovider.ErrUnknownInboundFormat)
+}
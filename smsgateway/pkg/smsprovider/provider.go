@@ -1,39 +1,175 @@
 package smsprovider
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Behyna/common/pkg/httpclient"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smpp"
 )
 
+const (
+	TypeHTTP = "http"
+	TypeSMPP = "smpp"
+)
+
+const (
+	FormatJSON  = "json"
+	FormatForm  = "form"
+	FormatQuery = "query"
+)
+
+const (
+	AuthBearer = "bearer"
+	AuthBasic  = "basic"
+	AuthHeader = "header"
+)
+
+// Response categories a provider's result codes are mapped to.
+const (
+	CategorySuccess       = "success"
+	CategoryRetryable     = "retryable"
+	CategoryPermanent     = "permanent"
+	CategoryInvalidNumber = "invalid_number"
+)
+
+const maxResponseSize = 1 << 20
+
+var ErrInvalidProviderConfig = errors.New("INVALID_PROVIDER_CONFIG")
+
 type Provider interface {
 	Send(ctx context.Context, from string, to string, text string) (res Response, err error)
 }
 
+// Config describes how to reach one provider: an HTTP SMS API by default, or an SMPP SMSC. For HTTP, body field
+// values may use the {{from}}, {{to}} and {{text}} placeholders; without a body the fields from, to and text are
+// sent. Receipts authenticates the delivery reports the provider posts back.
 type Config struct {
-	Enable   bool          `mapstructure:"enable"`
-	URL      string        `mapstructure:"url"`
-	Timeout  time.Duration `mapstructure:"timeout"`
-	MaxRetry int           `mapstructure:"max_retry"`
+	Enable   bool              `mapstructure:"enable"`
+	Type     string            `mapstructure:"type"`
+	URL      string            `mapstructure:"url"`
+	Timeout  time.Duration     `mapstructure:"timeout"`
+	MaxRetry int               `mapstructure:"max_retry"`
+	Format   string            `mapstructure:"format"`
+	Headers  map[string]string `mapstructure:"headers"`
+	Auth     AuthConfig        `mapstructure:"auth"`
+	Body     []BodyField       `mapstructure:"body"`
+	Response ResponseConfig    `mapstructure:"response"`
+	SMPP     smpp.Config       `mapstructure:"smpp"`
+	Receipts CallbackAuth      `mapstructure:"receipts"`
+}
+
+// BodyField is a list entry rather than a map key so field names keep their case when loaded from config.
+type BodyField struct {
+	Name  string `mapstructure:"name"`
+	Value string `mapstructure:"value"`
+}
+
+// AuthConfig adds credentials to every request: a bearer token, basic auth, or a token in a named header.
+type AuthConfig struct {
+	Type     string `mapstructure:"type"`
+	Token    string `mapstructure:"token"`
+	Header   string `mapstructure:"header"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// ResponseConfig says where a JSON response carries the message id and the provider's own result code, using dotted
+// paths such as "data.id", and which category each code falls in. Codes are matched against the result code first,
+// then the exact HTTP status, then the status class such as "5xx". Unmatched 2xx responses succeed, an unmatched 400
+// is an invalid number and anything else is retryable.
+type ResponseConfig struct {
+	MessageIDField string            `mapstructure:"message_id_field"`
+	StatusField    string            `mapstructure:"status_field"`
+	CodeField      string            `mapstructure:"code_field"`
+	Codes          map[string]string `mapstructure:"codes"`
+}
+
+// Validate checks the settings Send cannot recover from at request time.
+func (c Config) Validate() error {
+	switch c.Type {
+	case TypeHTTP, "":
+	case TypeSMPP:
+		if c.SMPP.Address == "" {
+			return fmt.Errorf("%w: smpp address is required", ErrInvalidProviderConfig)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidProviderConfig, c.Type)
+	}
+
+	switch c.Format {
+	case FormatJSON, FormatForm, FormatQuery, "":
+	default:
+		return fmt.Errorf("%w: unknown format %q", ErrInvalidProviderConfig, c.Format)
+	}
+
+	switch c.Auth.Type {
+	case AuthBearer, AuthBasic, "":
+	case AuthHeader:
+		if c.Auth.Header == "" {
+			return fmt.Errorf("%w: auth header name is required", ErrInvalidProviderConfig)
+		}
+	default:
+		return fmt.Errorf("%w: unknown auth type %q", ErrInvalidProviderConfig, c.Auth.Type)
+	}
+
+	for code, category := range c.Response.Codes {
+		switch category {
+		case CategorySuccess, CategoryRetryable, CategoryPermanent, CategoryInvalidNumber:
+		default:
+			return fmt.Errorf("%w: unknown category %q for code %q", ErrInvalidProviderConfig, category, code)
+		}
+	}
+
+	return nil
 }
 
 type SMSProvider struct {
 	cfg    Config
 	client httpclient.HTTPClient
+	codes  map[string]string
 }
 
 func NewSMSProvider(cfg Config, client httpclient.HTTPClient) Provider {
-	return &SMSProvider{cfg: cfg, client: client}
+	if len(cfg.Body) == 0 {
+		cfg.Body = []BodyField{{Name: "from", Value: "{{from}}"}, {Name: "to", Value: "{{to}}"},
+			{Name: "text", Value: "{{text}}"}}
+	}
+	if cfg.Response.MessageIDField == "" {
+		cfg.Response.MessageIDField = "message_id"
+	}
+	if cfg.Response.StatusField == "" {
+		cfg.Response.StatusField = "status"
+	}
+
+	// Config keys arrive lowercased, so codes are matched case-insensitively.
+	codes := make(map[string]string, len(cfg.Response.Codes))
+	for code, category := range cfg.Response.Codes {
+		codes[strings.ToLower(code)] = category
+	}
+
+	return &SMSProvider{cfg: cfg, client: client, codes: codes}
 }
 
 func (s *SMSProvider) Send(ctx context.Context, from string, to string, text string) (Response, error) {
-	resp, err := s.client.Post(ctx, s.cfg.URL, nil, nil) // TODO:: Replace nil with actual body
+	resp, err := s.request(ctx, from, to, text)
 	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		if errors.Is(err, context.Canceled) {
+			return Response{}, errors.New(ErrorCodeCanceled)
+		}
+
+		if errors.Is(err, context.DeadlineExceeded) {
 			return Response{}, errors.New(ErrorCodeTimeout)
 		}
 
@@ -42,21 +178,147 @@ func (s *SMSProvider) Send(ctx context.Context, from string, to string, text str
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		switch resp.StatusCode {
-		case 400:
-			return Response{}, errors.New(ErrorCodeInvalidNumber)
-		case 500, 502, 503, 504:
-			return Response{}, errors.New(ErrorCodeServerError)
-		default:
-			return Response{}, errors.New(ErrorCodeServerError)
-		}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return Response{}, errors.New(ErrorCodeNetworkError)
 	}
 
-	var res Response
-	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+	fields := decodeFields(body)
+
+	switch s.category(resp.StatusCode, lookupField(fields, s.cfg.Response.CodeField)) {
+	case CategorySuccess:
+		// A provider that accepted the message without a readable id is still a success: retrying would send the
+		// message twice.
+		return Response{
+			MessageID: lookupField(fields, s.cfg.Response.MessageIDField),
+			Status:    lookupField(fields, s.cfg.Response.StatusField),
+		}, nil
+	case CategoryInvalidNumber:
+		return Response{}, errors.New(ErrorCodeInvalidNumber)
+	case CategoryPermanent:
+		return Response{}, errors.New(ErrorCodeRejected)
+	default:
 		return Response{}, errors.New(ErrorCodeServerError)
 	}
+}
+
+func (s *SMSProvider) request(ctx context.Context, from, to, text string) (*http.Response, error) {
+	replacer := strings.NewReplacer("{{from}}", from, "{{to}}", to, "{{text}}", text)
+	headers := s.headers()
+
+	switch s.cfg.Format {
+	case FormatForm:
+		values := url.Values{}
+		for _, field := range s.cfg.Body {
+			values.Add(field.Name, replacer.Replace(field.Value))
+		}
+
+		headers["Content-Type"] = "application/x-www-form-urlencoded"
+		return s.client.Post(ctx, s.cfg.URL, strings.NewReader(values.Encode()), headers)
+
+	case FormatQuery:
+		target, err := url.Parse(s.cfg.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		values := target.Query()
+		for _, field := range s.cfg.Body {
+			values.Add(field.Name, replacer.Replace(field.Value))
+		}
+		target.RawQuery = values.Encode()
+
+		return s.client.Get(ctx, target.String(), headers)
+
+	default:
+		payload := make(map[string]string, len(s.cfg.Body))
+		for _, field := range s.cfg.Body {
+			payload[field.Name] = replacer.Replace(field.Value)
+		}
+
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		headers["Content-Type"] = "application/json"
+		return s.client.Post(ctx, s.cfg.URL, bytes.NewReader(body), headers)
+	}
+}
+
+func (s *SMSProvider) headers() map[string]string {
+	headers := make(map[string]string, len(s.cfg.Headers)+2)
+	for name, value := range s.cfg.Headers {
+		headers[name] = value
+	}
+
+	auth := s.cfg.Auth
+	switch auth.Type {
+	case AuthBearer:
+		headers["Authorization"] = "Bearer " + auth.Token
+	case AuthBasic:
+		credentials := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+		headers["Authorization"] = "Basic " + credentials
+	case AuthHeader:
+		headers[auth.Header] = auth.Token
+	}
+
+	return headers
+}
+
+func (s *SMSProvider) category(statusCode int, code string) string {
+	if code != "" {
+		if category, ok := s.codes[strings.ToLower(code)]; ok {
+			return category
+		}
+	}
+
+	status := strconv.Itoa(statusCode)
+	if category, ok := s.codes[status]; ok {
+		return category
+	}
+
+	if category, ok := s.codes[status[:1]+"xx"]; ok {
+		return category
+	}
+
+	switch {
+	case statusCode >= 200 && statusCode < 300:
+		return CategorySuccess
+	case statusCode == http.StatusBadRequest:
+		return CategoryInvalidNumber
+	default:
+		return CategoryRetryable
+	}
+}
+
+// decodeFields reads a JSON object response. Other bodies yield no fields, which leaves the HTTP status to decide.
+func decodeFields(body []byte) map[string]any {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return nil
+	}
+
+	return fields
+}
+
+// lookupField follows a dotted path through nested objects and returns the value found as a string.
+func lookupField(fields map[string]any, path string) string {
+	if path == "" || fields == nil {
+		return ""
+	}
+
+	var value any = fields
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
 
-	return res, nil
+	return jsonString(value)
 }
@@ -0,0 +1,202 @@
+package smsprovider_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/mocks"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func httpResponse(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func readBody(body io.Reader) string {
+	data, _ := io.ReadAll(body)
+	return string(data)
+}
+
+func TestSMSProvider_Send(t *testing.T) {
+	t.Run("posts the default JSON body", func(t *testing.T) {
+		mockClient := &mocks.HTTPClient{}
+		provider := smsprovider.NewSMSProvider(smsprovider.Config{URL: "https://sms.test/send"}, mockClient)
+
+		mockClient.On("Post", context.Background(), "https://sms.test/send",
+			mock.MatchedBy(func(body io.Reader) bool {
+				return readBody(body) == `{"from":"1000","text":"hi","to":"989121234567"}`
+			}),
+			mock.MatchedBy(func(headers map[string]string) bool {
+				return headers["Content-Type"] == "application/json"
+			})).Return(httpResponse(200, `{"message_id":"p-1","status":"queued"}`), nil)
+
+		response, err := provider.Send(context.Background(), "1000", "989121234567", "hi")
+
+		assert.NoError(t, err)
+		assert.Equal(t, smsprovider.Response{MessageID: "p-1", Status: "queued"}, response)
+	})
+
+	t.Run("posts templated form fields with auth", func(t *testing.T) {
+		mockClient := &mocks.HTTPClient{}
+		cfg := smsprovider.Config{
+			URL:     "https://sms.test/send",
+			Format:  smsprovider.FormatForm,
+			Headers: map[string]string{"X-Client": "gateway"},
+			Auth:    smsprovider.AuthConfig{Type: smsprovider.AuthBasic, Username: "user", Password: "pass"},
+			Body: []smsprovider.BodyField{
+				{Name: "From", Value: "{{from}}"},
+				{Name: "To", Value: "+{{to}}"},
+				{Name: "Body", Value: "{{text}}"},
+			},
+			Response: smsprovider.ResponseConfig{MessageIDField: "sid"},
+		}
+		provider := smsprovider.NewSMSProvider(cfg, mockClient)
+
+		mockClient.On("Post", context.Background(), "https://sms.test/send",
+			mock.MatchedBy(func(body io.Reader) bool {
+				values, err := url.ParseQuery(readBody(body))
+				return err == nil && values.Get("From") == "1000" && values.Get("To") == "+989121234567" &&
+					values.Get("Body") == "hi there"
+			}),
+			mock.MatchedBy(func(headers map[string]string) bool {
+				return headers["Content-Type"] == "application/x-www-form-urlencoded" &&
+					headers["Authorization"] == "Basic dXNlcjpwYXNz" &&
+					headers["X-Client"] == "gateway"
+			})).Return(httpResponse(201, `{"sid":"SM1"}`), nil)
+
+		response, err := provider.Send(context.Background(), "1000", "989121234567", "hi there")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "SM1", response.MessageID)
+	})
+
+	t.Run("sends query parameters on GET", func(t *testing.T) {
+		mockClient := &mocks.HTTPClient{}
+		cfg := smsprovider.Config{
+			URL:    "https://sms.test/cgi-bin/sendsms?smsc=main",
+			Format: smsprovider.FormatQuery,
+			Auth:   smsprovider.AuthConfig{Type: smsprovider.AuthHeader, Header: "X-Api-Key", Token: "key"},
+		}
+		provider := smsprovider.NewSMSProvider(cfg, mockClient)
+
+		mockClient.On("Get", context.Background(),
+			"https://sms.test/cgi-bin/sendsms?from=1000&smsc=main&text=hi&to=989121234567",
+			map[string]string{"X-Api-Key": "key"}).Return(httpResponse(202, "0: Accepted for delivery"), nil)
+
+		response, err := provider.Send(context.Background(), "1000", "989121234567", "hi")
+
+		assert.NoError(t, err)
+		assert.Empty(t, response.MessageID)
+	})
+
+	t.Run("maps provider codes to categories", func(t *testing.T) {
+		cfg := smsprovider.Config{
+			Response: smsprovider.ResponseConfig{
+				MessageIDField: "data.id",
+				CodeField:      "result.code",
+				Codes: map[string]string{
+					"e21": smsprovider.CategoryInvalidNumber,
+					"e30": smsprovider.CategoryPermanent,
+					"429": smsprovider.CategoryRetryable,
+					"4xx": smsprovider.CategoryPermanent,
+				},
+			},
+		}
+
+		cases := []struct {
+			status int
+			body   string
+			err    string
+		}{
+			{status: 200, body: `{"result":{"code":"E21"}}`, err: smsprovider.ErrorCodeInvalidNumber},
+			{status: 200, body: `{"result":{"code":"E30"}}`, err: smsprovider.ErrorCodeRejected},
+			{status: 429, body: ``, err: smsprovider.ErrorCodeServerError},
+			{status: 403, body: ``, err: smsprovider.ErrorCodeRejected},
+			{status: 503, body: `<html></html>`, err: smsprovider.ErrorCodeServerError},
+		}
+
+		for _, c := range cases {
+			mockClient := &mocks.HTTPClient{}
+			provider := smsprovider.NewSMSProvider(cfg, mockClient)
+
+			mockClient.On("Post", context.Background(), mock.Anything, mock.Anything, mock.Anything).
+				Return(httpResponse(c.status, c.body), nil)
+
+			_, err := provider.Send(context.Background(), "1000", "989121234567", "hi")
+
+			assert.EqualError(t, err, c.err, "status %d body %s", c.status, c.body)
+		}
+	})
+
+	t.Run("keeps the default mapping without a code table", func(t *testing.T) {
+		mockClient := &mocks.HTTPClient{}
+		provider := smsprovider.NewSMSProvider(smsprovider.Config{}, mockClient)
+
+		mockClient.On("Post", context.Background(), mock.Anything, mock.Anything, mock.Anything).
+			Return(httpResponse(400, `{}`), nil)
+
+		_, err := provider.Send(context.Background(), "1000", "989121234567", "hi")
+
+		assert.EqualError(t, err, smsprovider.ErrorCodeInvalidNumber)
+	})
+
+	t.Run("maps transport errors", func(t *testing.T) {
+		mockClient := &mocks.HTTPClient{}
+		provider := smsprovider.NewSMSProvider(smsprovider.Config{}, mockClient)
+
+		mockClient.On("Post", context.Background(), mock.Anything, mock.Anything, mock.Anything).
+			Return((*http.Response)(nil), context.DeadlineExceeded).Once()
+		mockClient.On("Post", context.Background(), mock.Anything, mock.Anything, mock.Anything).
+			Return((*http.Response)(nil), errors.New("connection refused")).Once()
+
+		_, err := provider.Send(context.Background(), "1000", "989121234567", "hi")
+		assert.EqualError(t, err, smsprovider.ErrorCodeTimeout)
+
+		_, err = provider.Send(context.Background(), "1000", "989121234567", "hi")
+		assert.EqualError(t, err, smsprovider.ErrorCodeNetworkError)
+	})
+
+	t.Run("maps a cancelled context to a code the breaker ignores", func(t *testing.T) {
+		mockClient := &mocks.HTTPClient{}
+		provider := smsprovider.NewSMSProvider(smsprovider.Config{}, mockClient)
+		breaker := smsprovider.NewBreaker("primary", smsprovider.BreakerConfig{FailureThreshold: 1}, nil)
+
+		mockClient.On("Post", context.Background(), mock.Anything, mock.Anything, mock.Anything).
+			Return((*http.Response)(nil), context.Canceled)
+
+		_, err := provider.Send(context.Background(), "1000", "989121234567", "hi")
+		breaker.Record(err)
+
+		assert.EqualError(t, err, smsprovider.ErrorCodeCanceled)
+		assert.False(t, smsprovider.IsRetryable(err))
+		assert.False(t, smsprovider.IsPermanent(err))
+		assert.Equal(t, smsprovider.BreakerClosed, breaker.Status().State)
+		assert.Equal(t, 0, breaker.Status().ConsecutiveFailures)
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Run("accepts the zero config", func(t *testing.T) {
+		assert.NoError(t, smsprovider.Config{}.Validate())
+	})
+
+	t.Run("rejects unknown settings", func(t *testing.T) {
+		configs := []smsprovider.Config{
+			{Format: "xml"},
+			{Auth: smsprovider.AuthConfig{Type: "digest"}},
+			{Auth: smsprovider.AuthConfig{Type: smsprovider.AuthHeader}},
+			{Response: smsprovider.ResponseConfig{Codes: map[string]string{"500": "maybe"}}},
+		}
+
+		for _, cfg := range configs {
+			assert.ErrorIs(t, cfg.Validate(), smsprovider.ErrInvalidProviderConfig)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package smsprovider
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidRoute = errors.New("INVALID_ROUTE")
+
+// RouteConfig is one named provider and the rules that decide which messages it takes. A route without prefixes
+// accepts every destination and a route without accounts accepts every account.
+type RouteConfig struct {
+	Name     string   `mapstructure:"name"`
+	Priority int      `mapstructure:"priority"`
+	Prefixes []string `mapstructure:"prefixes"`
+	Accounts []int64  `mapstructure:"accounts"`
+	Config   `mapstructure:",squash"`
+}
+
+// Route is a provider selected for a message, together with the settings to call it with.
+type Route struct {
+	Name     string
+	Provider Provider
+	Config   Config
+}
+
+// Providers maps route names to the client used to reach them.
+type Providers map[string]Provider
+
+type Router struct {
+	routes    []RouteConfig
+	providers Providers
+}
+
+func NewRouter(routes []RouteConfig, providers Providers) (*Router, error) {
+	seen := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		if route.Name == "" {
+			return nil, fmt.Errorf("%w: provider name is required", ErrInvalidRoute)
+		}
+		if seen[route.Name] {
+			return nil, fmt.Errorf("%w: duplicate provider %q", ErrInvalidRoute, route.Name)
+		}
+		if _, ok := providers[route.Name]; !ok {
+			return nil, fmt.Errorf("%w: no client for provider %q", ErrInvalidRoute, route.Name)
+		}
+		seen[route.Name] = true
+	}
+
+	return &Router{routes: routes, providers: providers}, nil
+}
+
+// Names returns every configured route name in configuration order, including disabled routes.
+func (r *Router) Names() []string {
+	names := make([]string, len(r.routes))
+	for i, route := range r.routes {
+		names[i] = route.Name
+	}
+
+	return names
+}
+
+// Routes returns the enabled providers that accept the message, in the order they should be tried. Routes bound to
+// the account come first, then routes with the longest matching destination prefix, then lower priority values;
+// routes that tie keep their configured order. Every route after the first is a failover candidate.
+func (r *Router) Routes(accountID int64, to string) []Route {
+	to = strings.TrimPrefix(to, "+")
+
+	type candidate struct {
+		config    RouteConfig
+		account   bool
+		prefixLen int
+	}
+
+	candidates := make([]candidate, 0, len(r.routes))
+	for _, route := range r.routes {
+		if !route.Enable {
+			continue
+		}
+
+		prefixLen, ok := matchPrefix(route.Prefixes, to)
+		if !ok || !matchAccount(route.Accounts, accountID) {
+			continue
+		}
+
+		candidates = append(candidates, candidate{config: route, account: len(route.Accounts) > 0,
+			prefixLen: prefixLen})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.account != b.account {
+			return a.account
+		}
+		if a.prefixLen != b.prefixLen {
+			return a.prefixLen > b.prefixLen
+		}
+		return a.config.Priority < b.config.Priority
+	})
+
+	routes := make([]Route, len(candidates))
+	for i, c := range candidates {
+		routes[i] = Route{Name: c.config.Name, Provider: r.providers[c.config.Name], Config: c.config.Config}
+	}
+
+	return routes
+}
+
+// matchPrefix returns the length of the longest prefix of to in prefixes. An empty list matches with length zero.
+func matchPrefix(prefixes []string, to string) (int, bool) {
+	if len(prefixes) == 0 {
+		return 0, true
+	}
+
+	longest, matched := 0, false
+	for _, prefix := range prefixes {
+		prefix = strings.TrimPrefix(prefix, "+")
+		if strings.HasPrefix(to, prefix) && (!matched || len(prefix) > longest) {
+			longest, matched = len(prefix), true
+		}
+	}
+
+	return longest, matched
+}
+
+func matchAccount(accounts []int64, accountID int64) bool {
+	if len(accounts) == 0 {
+		return true
+	}
+
+	for _, id := range accounts {
+		if id == accountID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package smsprovider_test
+
+import (
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/mocks"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/stretchr/testify/assert"
+)
+
+func routeNames(routes []smsprovider.Route) []string {
+	names := make([]string, len(routes))
+	for i, route := range routes {
+		names[i] = route.Name
+	}
+	return names
+}
+
+func TestRouter_Routes(t *testing.T) {
+	enabled := smsprovider.Config{Enable: true}
+
+	configs := []smsprovider.RouteConfig{
+		{Name: "fallback", Priority: 10, Config: enabled},
+		{Name: "backup", Priority: 5, Config: enabled},
+		{Name: "mci", Priority: 1, Prefixes: []string{"98912", "98913"}, Config: enabled},
+		{Name: "irancell", Prefixes: []string{"98935"}, Config: enabled},
+		{Name: "dedicated", Accounts: []int64{7}, Config: enabled},
+		{Name: "disabled", Config: smsprovider.Config{Enable: false}},
+	}
+
+	providers := smsprovider.Providers{}
+	for _, cfg := range configs {
+		providers[cfg.Name] = &mocks.SMSProvider{}
+	}
+
+	router, err := smsprovider.NewRouter(configs, providers)
+	assert.NoError(t, err)
+
+	t.Run("prefers the matching prefix and orders the rest by priority", func(t *testing.T) {
+		routes := router.Routes(1, "+989121234567")
+
+		assert.Equal(t, []string{"mci", "backup", "fallback"}, routeNames(routes))
+		assert.Same(t, providers["mci"], routes[0].Provider)
+	})
+
+	t.Run("skips routes whose prefixes do not match", func(t *testing.T) {
+		routes := router.Routes(1, "989351234567")
+
+		assert.Equal(t, []string{"irancell", "backup", "fallback"}, routeNames(routes))
+	})
+
+	t.Run("puts routes bound to the account first", func(t *testing.T) {
+		routes := router.Routes(7, "989121234567")
+
+		assert.Equal(t, []string{"dedicated", "mci", "backup", "fallback"}, routeNames(routes))
+	})
+}
+
+func TestNewRouter(t *testing.T) {
+	t.Run("rejects duplicate names", func(t *testing.T) {
+		configs := []smsprovider.RouteConfig{{Name: "a"}, {Name: "a"}}
+
+		_, err := smsprovider.NewRouter(configs, smsprovider.Providers{"a": &mocks.SMSProvider{}})
+
+		assert.ErrorIs(t, err, smsprovider.ErrInvalidRoute)
+	})
+
+	t.Run("rejects routes without a client", func(t *testing.T) {
+		_, err := smsprovider.NewRouter([]smsprovider.RouteConfig{{Name: "a"}}, smsprovider.Providers{})
+
+		assert.ErrorIs(t, err, smsprovider.ErrInvalidRoute)
+	})
+}
@@ -0,0 +1,58 @@
+package smsprovider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/smpp"
+)
+
+// SMPPProvider sends through an SMPP transceiver session. A multi-segment message is identified by the id of its
+// first segment, which is the receipt the delivery report flow matches; receipts for later segments find no message.
+// Once the first segment is accepted the message counts as sent even if a later segment is refused: the SMSC will
+// deliver what it accepted, so retrying would send those segments twice.
+type SMPPProvider struct {
+	client *smpp.Client
+}
+
+func NewSMPPProvider(client *smpp.Client) Provider {
+	return &SMPPProvider{client: client}
+}
+
+func (s *SMPPProvider) Send(ctx context.Context, from string, to string, text string) (Response, error) {
+	ids, err := s.client.Submit(ctx, from, to, text)
+	if len(ids) == 0 {
+		return Response{}, errors.New(smppErrorCode(err))
+	}
+	if err != nil {
+		return Response{MessageID: ids[0], Status: "partial"}, nil
+	}
+
+	return Response{MessageID: ids[0], Status: "submitted"}, nil
+}
+
+// smppErrorCode maps command statuses the way the HTTP dialect maps response codes: bad destinations are invalid
+// numbers, SMSC overload is retryable and any other refusal is permanent. Session failures are network errors.
+func smppErrorCode(err error) string {
+	var status smpp.StatusError
+	if errors.As(err, &status) {
+		switch uint32(status) {
+		case smpp.StatusInvalidDstAddr, smpp.StatusInvalidDstTON, smpp.StatusInvalidDstNPI:
+			return ErrorCodeInvalidNumber
+		case smpp.StatusSystemError, smpp.StatusMsgQueueFull, smpp.StatusThrottled, smpp.StatusSubmitFailed:
+			return ErrorCodeServerError
+		default:
+			return ErrorCodeRejected
+		}
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return ErrorCodeCanceled
+	}
+
+	if errors.Is(err, smpp.ErrResponseTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorCodeTimeout
+	}
+
+	return ErrorCodeNetworkError
+}
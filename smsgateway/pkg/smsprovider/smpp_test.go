@@ -0,0 +1,21 @@
+package smsprovider_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/smpp"
+	"github.com/Behyna/sms-services/smsgateway/pkg/smsprovider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMPPProvider(t *testing.T) {
+	t.Run("reports a network error while the session is down", func(t *testing.T) {
+		provider := smsprovider.NewSMPPProvider(smpp.NewClient(smpp.Config{Address: "127.0.0.1:1"}, smpp.Handlers{}))
+
+		_, err := provider.Send(context.Background(), "1000", "989121234567", "hello")
+
+		assert.EqualError(t, err, smsprovider.ErrorCodeNetworkError)
+		assert.True(t, smsprovider.IsRetryable(err))
+	})
+}
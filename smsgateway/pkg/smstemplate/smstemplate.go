@@ -0,0 +1,193 @@
+// Package smstemplate parses message bodies with typed {{placeholders}} and renders them from string variables.
+//
+// A placeholder is written {{name}} or {{name:type}}. Names start with a letter or underscore and may contain
+// letters, digits and underscores. Supported types are text (the default), number and digits; digits keeps leading
+// zeros, which makes it the right type for OTP codes. A number is a plain decimal such as 5, -2 or 12.50, never an
+// exponent, NaN or Inf. No value may be longer than MaxValueLength characters.
+package smstemplate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type Type string
+
+const (
+	TypeText   Type = "text"
+	TypeNumber Type = "number"
+	TypeDigits Type = "digits"
+)
+
+// MaxValueLength caps a variable value in characters, so that one variable cannot carry a whole message body.
+const MaxValueLength = 160
+
+var (
+	ErrUnterminatedPlaceholder = errors.New("unterminated placeholder")
+	ErrInvalidPlaceholder      = errors.New("invalid placeholder")
+	ErrConflictingPlaceholder  = errors.New("placeholder used with different types")
+	ErrNoPlaceholderName       = errors.New("empty placeholder name")
+)
+
+var (
+	namePattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	digitsPattern = regexp.MustCompile(`^[0-9]+$`)
+	numberPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+)
+
+type Placeholder struct {
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+}
+
+// VariableError reports a missing, unknown or mistyped variable at render time.
+type VariableError struct {
+	Name   string
+	Reason string
+}
+
+func (e *VariableError) Error() string {
+	return fmt.Sprintf("variable %q %s", e.Name, e.Reason)
+}
+
+type part struct {
+	literal     string
+	placeholder string
+}
+
+type Template struct {
+	parts        []part
+	placeholders []Placeholder
+	types        map[string]Type
+}
+
+func Parse(body string) (*Template, error) {
+	t := &Template{types: make(map[string]Type)}
+
+	rest := body
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			t.parts = append(t.parts, part{literal: rest})
+			break
+		}
+
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return nil, ErrUnterminatedPlaceholder
+		}
+		end += start
+
+		placeholder, err := parsePlaceholder(rest[start+2 : end])
+		if err != nil {
+			return nil, err
+		}
+
+		if existing, ok := t.types[placeholder.Name]; ok && existing != placeholder.Type {
+			return nil, fmt.Errorf("%w: %s", ErrConflictingPlaceholder, placeholder.Name)
+		}
+
+		if _, ok := t.types[placeholder.Name]; !ok {
+			t.types[placeholder.Name] = placeholder.Type
+			t.placeholders = append(t.placeholders, placeholder)
+		}
+
+		t.parts = append(t.parts, part{literal: rest[:start]}, part{placeholder: placeholder.Name})
+		rest = rest[end+2:]
+	}
+
+	return t, nil
+}
+
+func parsePlaceholder(raw string) (Placeholder, error) {
+	name, typ, hasType := strings.Cut(strings.TrimSpace(raw), ":")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Placeholder{}, ErrNoPlaceholderName
+	}
+
+	if !namePattern.MatchString(name) {
+		return Placeholder{}, fmt.Errorf("%w: %q", ErrInvalidPlaceholder, raw)
+	}
+
+	placeholder := Placeholder{Name: name, Type: TypeText}
+	if !hasType {
+		return placeholder, nil
+	}
+
+	switch Type(strings.TrimSpace(typ)) {
+	case TypeText, TypeNumber, TypeDigits:
+		placeholder.Type = Type(strings.TrimSpace(typ))
+		return placeholder, nil
+	default:
+		return Placeholder{}, fmt.Errorf("%w: unknown type in %q", ErrInvalidPlaceholder, raw)
+	}
+}
+
+// Placeholders returns each distinct placeholder once, in order of first appearance.
+func (t *Template) Placeholders() []Placeholder {
+	return t.placeholders
+}
+
+// Render substitutes every placeholder. Each placeholder needs a variable of its type and variables that match no
+// placeholder are rejected, so that a misspelt name fails instead of silently sending a template default.
+func (t *Template) Render(variables map[string]string) (string, error) {
+	for _, name := range sortedKeys(variables) {
+		if _, ok := t.types[name]; !ok {
+			return "", &VariableError{Name: name, Reason: "is not used by the template"}
+		}
+	}
+
+	for _, placeholder := range t.placeholders {
+		value, ok := variables[placeholder.Name]
+		if !ok {
+			return "", &VariableError{Name: placeholder.Name, Reason: "is required"}
+		}
+
+		if err := checkType(placeholder.Type, value); err != nil {
+			return "", &VariableError{Name: placeholder.Name, Reason: err.Error()}
+		}
+	}
+
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.placeholder == "" {
+			b.WriteString(p.literal)
+			continue
+		}
+		b.WriteString(variables[p.placeholder])
+	}
+
+	return b.String(), nil
+}
+
+func checkType(typ Type, value string) error {
+	if utf8.RuneCountInString(value) > MaxValueLength {
+		return fmt.Errorf("must be at most %d characters", MaxValueLength)
+	}
+
+	switch typ {
+	case TypeNumber:
+		if !numberPattern.MatchString(value) {
+			return errors.New("must be a decimal number")
+		}
+	case TypeDigits:
+		if !digitsPattern.MatchString(value) {
+			return errors.New("must contain only digits")
+		}
+	}
+	return nil
+}
+
+func sortedKeys(variables map[string]string) []string {
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,93 @@
+package smstemplate_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/smstemplate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("collects distinct typed placeholders", func(t *testing.T) {
+		tmpl, err := smstemplate.Parse("Hi {{ name }}, code {{code:digits}}. {{name}}, expires in {{mins:number}}.")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []smstemplate.Placeholder{
+			{Name: "name", Type: smstemplate.TypeText},
+			{Name: "code", Type: smstemplate.TypeDigits},
+			{Name: "mins", Type: smstemplate.TypeNumber},
+		}, tmpl.Placeholders())
+	})
+
+	t.Run("rejects malformed bodies", func(t *testing.T) {
+		cases := map[string]error{
+			"Your code {{code":              smstemplate.ErrUnterminatedPlaceholder,
+			"Hello {{}}":                    smstemplate.ErrNoPlaceholderName,
+			"Hello {{first name}}":          smstemplate.ErrInvalidPlaceholder,
+			"Hello {{name:date}}":           smstemplate.ErrInvalidPlaceholder,
+			"{{code:digits}} {{code:text}}": smstemplate.ErrConflictingPlaceholder,
+		}
+
+		for body, want := range cases {
+			_, err := smstemplate.Parse(body)
+			assert.ErrorIs(t, err, want, body)
+		}
+	})
+}
+
+func TestTemplate_Render(t *testing.T) {
+	tmpl, err := smstemplate.Parse("Your code is {{code:digits}}, valid for {{mins:number}} minutes.")
+	assert.NoError(t, err)
+
+	t.Run("renders variables", func(t *testing.T) {
+		text, err := tmpl.Render(map[string]string{"code": "004213", "mins": "5"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Your code is 004213, valid for 5 minutes.", text)
+	})
+
+	t.Run("reports the offending variable", func(t *testing.T) {
+		cases := []struct {
+			variables map[string]string
+			name      string
+		}{
+			{map[string]string{"mins": "5"}, "code"},
+			{map[string]string{"code": "12a4", "mins": "5"}, "code"},
+			{map[string]string{"code": "1234", "mins": "five"}, "mins"},
+			{map[string]string{"code": "1234", "mins": "NaN"}, "mins"},
+			{map[string]string{"code": "1234", "mins": "Inf"}, "mins"},
+			{map[string]string{"code": "1234", "mins": "1e5"}, "mins"},
+			{map[string]string{"code": "1234", "mins": "0x10"}, "mins"},
+			{map[string]string{"code": "1234", "mins": "5."}, "mins"},
+			{map[string]string{"code": strings.Repeat("1", smstemplate.MaxValueLength+1), "mins": "5"}, "code"},
+			{map[string]string{"code": "1234", "mins": "5", "nmae": "Sara"}, "nmae"},
+		}
+
+		for _, tc := range cases {
+			_, err := tmpl.Render(tc.variables)
+
+			var varErr *smstemplate.VariableError
+			assert.True(t, errors.As(err, &varErr))
+			assert.Equal(t, tc.name, varErr.Name)
+		}
+	})
+
+	t.Run("accepts plain decimals", func(t *testing.T) {
+		for _, mins := range []string{"0", "-2", "12.50"} {
+			_, err := tmpl.Render(map[string]string{"code": "1234", "mins": mins})
+			assert.NoError(t, err, mins)
+		}
+	})
+
+	t.Run("renders a body without placeholders unchanged", func(t *testing.T) {
+		plain, err := smstemplate.Parse("Your order has shipped.")
+		assert.NoError(t, err)
+
+		text, err := plain.Render(nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Your order has shipped.", text)
+	})
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Behyna/common/pkg/httpclient"
+)
+
+var ErrTimeout = errors.New("TIMEOUT")
+
+type Request struct {
+	URL       string
+	Secret    string
+	Event     string
+	Payload   []byte
+	Timestamp time.Time
+}
+
+type Response struct {
+	StatusCode int
+}
+
+type Client interface {
+	Deliver(ctx context.Context, request Request) (Response, error)
+}
+
+type client struct {
+	client httpclient.HTTPClient
+}
+
+func NewClient(httpClient httpclient.HTTPClient) Client {
+	return &client{client: httpClient}
+}
+
+// Deliver posts the signed payload. Any non-2xx response is returned as an error along with its status code.
+func (c *client) Deliver(ctx context.Context, request Request) (Response, error) {
+	timestamp := request.Timestamp.Unix()
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		EventHeader:     request.Event,
+		TimestampHeader: strconv.FormatInt(timestamp, 10),
+		SignatureHeader: Sign(request.Secret, timestamp, request.Payload),
+	}
+
+	resp, err := c.client.Post(ctx, request.URL, bytes.NewReader(request.Payload), headers)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return Response{}, ErrTimeout
+		}
+
+		return Response{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Response{StatusCode: resp.StatusCode}, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return Response{StatusCode: resp.StatusCode}, nil
+}
@@ -0,0 +1,90 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Behyna/sms-services/smsgateway/pkg/mocks"
+	"github.com/Behyna/sms-services/smsgateway/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"delivered"}`)
+
+	t.Run("produces a verifiable signature", func(t *testing.T) {
+		signature := webhook.Sign("secret", 1700000000, body)
+
+		assert.True(t, strings.HasPrefix(signature, "sha256="))
+		assert.True(t, webhook.Verify("secret", 1700000000, body, signature))
+	})
+
+	t.Run("rejects tampered body, timestamp or secret", func(t *testing.T) {
+		signature := webhook.Sign("secret", 1700000000, body)
+
+		assert.False(t, webhook.Verify("secret", 1700000000, []byte(`{"event":"refunded"}`), signature))
+		assert.False(t, webhook.Verify("secret", 1700000001, body, signature))
+		assert.False(t, webhook.Verify("other", 1700000000, body, signature))
+	})
+}
+
+func TestClient_Deliver(t *testing.T) {
+	request := webhook.Request{
+		URL:       "https://customer.test/hooks",
+		Secret:    "secret",
+		Event:     "delivered",
+		Payload:   []byte(`{"message_id":1}`),
+		Timestamp: time.Unix(1700000000, 0),
+	}
+
+	matchHeaders := mock.MatchedBy(func(headers map[string]string) bool {
+		return headers[webhook.EventHeader] == "delivered" &&
+			headers[webhook.TimestampHeader] == "1700000000" &&
+			headers[webhook.SignatureHeader] == webhook.Sign("secret", 1700000000, request.Payload)
+	})
+
+	t.Run("successful delivery", func(t *testing.T) {
+		mockClient := &mocks.HTTPClient{}
+		client := webhook.NewClient(mockClient)
+
+		mockClient.On("Post", context.Background(), request.URL, mock.Anything, matchHeaders).
+			Return(&http.Response{StatusCode: 204, Body: io.NopCloser(strings.NewReader(""))}, nil)
+
+		resp, err := client.Deliver(context.Background(), request)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 204, resp.StatusCode)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("non-2xx response is an error", func(t *testing.T) {
+		mockClient := &mocks.HTTPClient{}
+		client := webhook.NewClient(mockClient)
+
+		mockClient.On("Post", context.Background(), request.URL, mock.Anything, mock.Anything).
+			Return(&http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader(""))}, nil)
+
+		resp, err := client.Deliver(context.Background(), request)
+
+		assert.Error(t, err)
+		assert.Equal(t, 500, resp.StatusCode)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		mockClient := &mocks.HTTPClient{}
+		client := webhook.NewClient(mockClient)
+
+		mockClient.On("Post", context.Background(), request.URL, mock.Anything, mock.Anything).
+			Return((*http.Response)(nil), context.DeadlineExceeded)
+
+		_, err := client.Deliver(context.Background(), request)
+
+		assert.True(t, errors.Is(err, webhook.ErrTimeout))
+	})
+}